@@ -0,0 +1,702 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jizak1/Microservices-Golang/shared/middleware"
+	"github.com/jizak1/Microservices-Golang/shared/utils"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+)
+
+// Security event untuk API key
+const (
+	SecurityEventAPIKeyCreated = "api_key_created"
+	SecurityEventAPIKeyRevoked = "api_key_revoked"
+)
+
+// apiKeyPrefix awalan semua API key, supaya mudah dikenali oleh secret scanner
+const apiKeyPrefix = "umk_"
+
+// apiKeyLastUsedInterval last_used_at hanya ditulis ulang jika sudah lebih lama dari ini,
+// supaya request beruntun dengan key yang sama tidak selalu menulis ke database
+const apiKeyLastUsedInterval = time.Minute
+
+// APIKeyScopes scope yang boleh diberikan ke API key. Scope read dipakai untuk request GET/HEAD/OPTIONS,
+// scope write untuk method lain, lihat middleware.Authenticate.
+var APIKeyScopes = []string{"users:read", "users:write", "products:read", "products:write"}
+
+var (
+	// ErrAPIKeyNotFound API key tidak ada, bukan milik user, atau sudah di-revoke
+	ErrAPIKeyNotFound = errors.New("api key not found")
+	// ErrAPIKeyLimitReached user sudah punya API key aktif sebanyak batas maksimal
+	ErrAPIKeyLimitReached = errors.New("api key limit reached")
+)
+
+// APIKey API key milik user. Key aslinya hanya ditampilkan sekali saat dibuat, yang disimpan hanya hash-nya.
+type APIKey struct {
+	ID            int            `json:"id" db:"id"`
+	UserID        int            `json:"user_id" db:"user_id"`
+	Name          string         `json:"name" db:"name"`
+	Prefix        string         `json:"prefix" db:"prefix"` // Bagian awal key untuk identifikasi, bukan rahasia
+	KeyHash       string         `json:"-" db:"key_hash"`
+	Scopes        pq.StringArray `json:"scopes" db:"scopes"`
+	ExpiresAt     *time.Time     `json:"expires_at" db:"expires_at"`
+	LastUsedAt    *time.Time     `json:"last_used_at" db:"last_used_at"`
+	CreatedAt     time.Time      `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at" db:"updated_at"`
+	RevokedAt     *time.Time     `json:"revoked_at,omitempty" db:"revoked_at"`
+	RevokedReason *string        `json:"revoked_reason,omitempty" db:"revoked_reason"`
+	Current       bool           `json:"current" db:"-"` // API key yang dipakai request ini
+}
+
+// IsExpired mengecek apakah API key sudah melewati expires_at
+func (k *APIKey) IsExpired(now time.Time) bool {
+	return k.ExpiresAt != nil && !now.Before(*k.ExpiresAt)
+}
+
+// CreatedAPIKey response pembuatan API key, satu-satunya tempat key asli dikembalikan
+type CreatedAPIKey struct {
+	*APIKey
+	Key string `json:"key"`
+}
+
+// CreateAPIKeyRequest struktur request untuk membuat API key
+type CreateAPIKeyRequest struct {
+	Name      string     `json:"name" binding:"required,max=100"`
+	Scopes    []string   `json:"scopes" binding:"required,min=1"`
+	ExpiresAt *time.Time `json:"expires_at"` // Kosong berarti memakai masa berlaku maksimal
+}
+
+// UpdateAPIKeyRequest struktur request untuk mengubah nama atau scope API key
+type UpdateAPIKeyRequest struct {
+	Name   string   `json:"name" binding:"omitempty,max=100"`
+	Scopes []string `json:"scopes"`
+}
+
+// VerifyAPIKeyRequest struktur request endpoint internal verifikasi API key
+type VerifyAPIKeyRequest struct {
+	Key string `json:"key" binding:"required"`
+}
+
+// apiKeyColumns kolom yang dibaca ke struct APIKey
+const apiKeyColumns = `id, user_id, name, prefix, key_hash, scopes, expires_at, last_used_at, created_at, updated_at,
+	revoked_at, revoked_reason`
+
+// APIKeyRepository untuk database operations API key
+type APIKeyRepository struct {
+	db     *sqlx.DB
+	logger *logrus.Logger
+}
+
+// NewAPIKeyRepository membuat instance baru APIKeyRepository
+func NewAPIKeyRepository(db *sqlx.DB, logger *logrus.Logger) *APIKeyRepository {
+	return &APIKeyRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// CreateAPIKey menyimpan API key baru. Baris user dikunci supaya batas jumlah key aktif
+// tidak terlewati oleh request yang berjalan bersamaan.
+func (ar *APIKeyRepository) CreateAPIKey(key *APIKey, maxActive int) error {
+	tx, err := ar.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`SELECT id FROM users WHERE id = $1 FOR UPDATE`, key.UserID); err != nil {
+		return fmt.Errorf("failed to lock user: %w", err)
+	}
+
+	var active int
+	if err := tx.Get(&active, `SELECT COUNT(*) FROM api_keys
+		WHERE user_id = $1 AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > $2)`,
+		key.UserID, key.CreatedAt); err != nil {
+		return fmt.Errorf("failed to count api keys: %w", err)
+	}
+	if maxActive > 0 && active >= maxActive {
+		return ErrAPIKeyLimitReached
+	}
+
+	query := `
+		INSERT INTO api_keys (user_id, name, prefix, key_hash, scopes, expires_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id`
+
+	err = tx.QueryRow(query, key.UserID, key.Name, key.Prefix, key.KeyHash, key.Scopes, key.ExpiresAt,
+		key.CreatedAt, key.UpdatedAt).Scan(&key.ID)
+	if err != nil {
+		ar.logger.WithError(err).WithField("user_id", key.UserID).Error("Failed to create api key")
+		return fmt.Errorf("failed to create api key: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// ListAPIKeys mengambil semua API key user yang belum di-revoke (termasuk yang sudah expired), terbaru dulu
+func (ar *APIKeyRepository) ListAPIKeys(userID int) ([]APIKey, error) {
+	keys := []APIKey{}
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys
+			  WHERE user_id = $1 AND revoked_at IS NULL
+			  ORDER BY created_at DESC, id DESC`
+
+	if err := ar.db.Select(&keys, query, userID); err != nil {
+		ar.logger.WithError(err).WithField("user_id", userID).Error("Failed to list api keys")
+		return nil, fmt.Errorf("failed to list api keys: %w", err)
+	}
+	return keys, nil
+}
+
+// GetAPIKey mengambil API key milik user yang belum di-revoke
+func (ar *APIKeyRepository) GetAPIKey(userID, id int) (*APIKey, error) {
+	var key APIKey
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys
+			  WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL`
+
+	if err := ar.db.Get(&key, query, id, userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrAPIKeyNotFound
+		}
+		return nil, fmt.Errorf("failed to get api key: %w", err)
+	}
+	return &key, nil
+}
+
+// GetAPIKeyByHash mengambil API key yang belum di-revoke berdasarkan hash key
+func (ar *APIKeyRepository) GetAPIKeyByHash(keyHash string) (*APIKey, error) {
+	var key APIKey
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys
+			  WHERE key_hash = $1 AND revoked_at IS NULL`
+
+	if err := ar.db.Get(&key, query, keyHash); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrAPIKeyNotFound
+		}
+		return nil, fmt.Errorf("failed to get api key: %w", err)
+	}
+	return &key, nil
+}
+
+// UpdateAPIKey menyimpan nama dan scope API key
+func (ar *APIKeyRepository) UpdateAPIKey(key *APIKey) error {
+	query := `UPDATE api_keys SET name = $1, scopes = $2, updated_at = $3
+			  WHERE id = $4 AND user_id = $5 AND revoked_at IS NULL`
+
+	result, err := ar.db.Exec(query, key.Name, key.Scopes, key.UpdatedAt, key.ID, key.UserID)
+	if err != nil {
+		ar.logger.WithError(err).WithField("api_key_id", key.ID).Error("Failed to update api key")
+		return fmt.Errorf("failed to update api key: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+// RevokeAPIKey me-revoke API key milik user, key langsung ditolak di request berikutnya
+func (ar *APIKeyRepository) RevokeAPIKey(userID, id int, reason string) error {
+	now := time.Now()
+	query := `UPDATE api_keys SET revoked_at = $1, revoked_reason = $2, updated_at = $1
+			  WHERE id = $3 AND user_id = $4 AND revoked_at IS NULL`
+
+	result, err := ar.db.Exec(query, now, reason, id, userID)
+	if err != nil {
+		ar.logger.WithError(err).WithField("api_key_id", id).Error("Failed to revoke api key")
+		return fmt.Errorf("failed to revoke api key: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+// TouchAPIKey mencatat waktu terakhir API key dipakai
+func (ar *APIKeyRepository) TouchAPIKey(id int, usedAt time.Time) error {
+	if _, err := ar.db.Exec(`UPDATE api_keys SET last_used_at = $1 WHERE id = $2`, usedAt, id); err != nil {
+		return fmt.Errorf("failed to update api key last used: %w", err)
+	}
+	return nil
+}
+
+// APIKeyService untuk business logic API key. APIKeyService juga menerapkan utils.APIKeyVerifier
+// untuk middleware.Authenticate.
+type APIKeyService struct {
+	repo        *APIKeyRepository
+	userRepo    *UserRepository
+	events      *SecurityEventRepository
+	maxLifetime time.Duration
+	maxPerUser  int
+	logger      *logrus.Logger
+}
+
+// NewAPIKeyService membuat instance baru APIKeyService
+func NewAPIKeyService(repo *APIKeyRepository, userRepo *UserRepository, events *SecurityEventRepository,
+	maxLifetime time.Duration, maxPerUser int, logger *logrus.Logger) *APIKeyService {
+	return &APIKeyService{
+		repo:        repo,
+		userRepo:    userRepo,
+		events:      events,
+		maxLifetime: maxLifetime,
+		maxPerUser:  maxPerUser,
+		logger:      logger,
+	}
+}
+
+// CreateAPIKey membuat API key baru untuk user. Key asli hanya ada di return value ini.
+func (as *APIKeyService) CreateAPIKey(userID int, req CreateAPIKeyRequest, metadata ClientMetadata) (*CreatedAPIKey, error) {
+	if _, err := as.userRepo.GetUserByID(userID); err != nil {
+		return nil, err
+	}
+
+	prefix, secret, err := generateAPIKey()
+	if err != nil {
+		return nil, err
+	}
+	rawKey := prefix + "_" + secret
+
+	now := time.Now()
+	key := &APIKey{
+		UserID:    userID,
+		Name:      strings.TrimSpace(req.Name),
+		Prefix:    prefix,
+		KeyHash:   utils.HashToken(rawKey),
+		Scopes:    normalizeAPIKeyScopes(req.Scopes),
+		ExpiresAt: req.ExpiresAt,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if key.ExpiresAt == nil && as.maxLifetime > 0 {
+		expiresAt := now.Add(as.maxLifetime)
+		key.ExpiresAt = &expiresAt
+	}
+
+	if err := as.repo.CreateAPIKey(key, as.maxPerUser); err != nil {
+		return nil, err
+	}
+
+	if err := as.events.Record(SecurityEventAPIKeyCreated, userID, &userID, metadata, map[string]interface{}{
+		"api_key_id": key.ID,
+		"name":       key.Name,
+		"scopes":     []string(key.Scopes),
+	}); err != nil {
+		as.logger.WithError(err).WithField("user_id", userID).Warn("Failed to record api key created event")
+	}
+
+	as.logger.WithFields(logrus.Fields{
+		"user_id":    userID,
+		"api_key_id": key.ID,
+	}).Info("API key created")
+
+	return &CreatedAPIKey{APIKey: key, Key: rawKey}, nil
+}
+
+// ListAPIKeys mengambil API key user, currentKeyID menandai key yang sedang dipakai
+func (as *APIKeyService) ListAPIKeys(userID int, currentKeyID string) ([]APIKey, error) {
+	if _, err := as.userRepo.GetUserByID(userID); err != nil {
+		return nil, err
+	}
+
+	keys, err := as.repo.ListAPIKeys(userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range keys {
+		keys[i].Current = currentKeyID != "" && strconv.Itoa(keys[i].ID) == currentKeyID
+	}
+	return keys, nil
+}
+
+// GetAPIKey mengambil satu API key milik user
+func (as *APIKeyService) GetAPIKey(userID, id int) (*APIKey, error) {
+	return as.repo.GetAPIKey(userID, id)
+}
+
+// UpdateAPIKey mengubah nama dan/atau scope API key. Field kosong tidak diubah.
+func (as *APIKeyService) UpdateAPIKey(userID, id int, req UpdateAPIKeyRequest) (*APIKey, error) {
+	key, err := as.repo.GetAPIKey(userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if name := strings.TrimSpace(req.Name); name != "" {
+		key.Name = name
+	}
+	if req.Scopes != nil {
+		key.Scopes = normalizeAPIKeyScopes(req.Scopes)
+	}
+	key.UpdatedAt = time.Now()
+
+	if err := as.repo.UpdateAPIKey(key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// RevokeAPIKey me-revoke API key milik user
+func (as *APIKeyService) RevokeAPIKey(userID, id int, actorID *int, metadata ClientMetadata) error {
+	key, err := as.repo.GetAPIKey(userID, id)
+	if err != nil {
+		return err
+	}
+
+	reason := revokeReason(userID, actorID)
+	if err := as.repo.RevokeAPIKey(userID, id, reason); err != nil {
+		return err
+	}
+
+	if err := as.events.Record(SecurityEventAPIKeyRevoked, userID, actorID, metadata, map[string]interface{}{
+		"api_key_id": key.ID,
+		"name":       key.Name,
+		"reason":     reason,
+	}); err != nil {
+		as.logger.WithError(err).WithField("user_id", userID).Warn("Failed to record api key revoked event")
+	}
+	return nil
+}
+
+// Identify memverifikasi API key dan mengembalikan identitas pemiliknya. Key yang tidak dikenal,
+// sudah di-revoke atau expired, dan key milik user yang tidak aktif atau dihapus ditolak dengan ErrInvalidAPIKey.
+func (as *APIKeyService) Identify(rawKey string) (*utils.APIKeyIdentity, error) {
+	if !strings.HasPrefix(rawKey, apiKeyPrefix) {
+		return nil, utils.ErrInvalidAPIKey
+	}
+
+	key, err := as.repo.GetAPIKeyByHash(utils.HashToken(rawKey))
+	if err != nil {
+		if errors.Is(err, ErrAPIKeyNotFound) {
+			return nil, utils.ErrInvalidAPIKey
+		}
+		return nil, err
+	}
+
+	now := time.Now()
+	if key.IsExpired(now) {
+		return nil, utils.ErrInvalidAPIKey
+	}
+
+	// Role dibaca dari database setiap request, perubahan role langsung berlaku untuk API key
+	user, err := as.userRepo.GetUserByID(key.UserID)
+	if err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			return nil, utils.ErrInvalidAPIKey
+		}
+		return nil, err
+	}
+	if !user.IsActive {
+		return nil, utils.ErrInvalidAPIKey
+	}
+
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= apiKeyLastUsedInterval {
+		if err := as.repo.TouchAPIKey(key.ID, now); err != nil {
+			as.logger.WithError(err).WithField("api_key_id", key.ID).Warn("Failed to update api key last used")
+		}
+	}
+
+	return &utils.APIKeyIdentity{
+		APIKeyID: strconv.Itoa(key.ID),
+		UserID:   strconv.Itoa(user.ID),
+		Username: user.Username,
+		Email:    user.Email,
+		Roles:    user.Roles,
+		Scopes:   key.Scopes,
+	}, nil
+}
+
+// VerifyAPIKey implementasi utils.APIKeyVerifier untuk middleware.Authenticate
+func (as *APIKeyService) VerifyAPIKey(ctx context.Context, rawKey string) (*utils.JWTClaims, error) {
+	identity, err := as.Identify(rawKey)
+	if err != nil {
+		return nil, err
+	}
+	return identity.Claims(), nil
+}
+
+// Helper function untuk membuat API key baru: prefix publik "umk_<8 hex>" dan secret 32 byte
+func generateAPIKey() (string, string, error) {
+	id := make([]byte, 4)
+	if _, err := rand.Read(id); err != nil {
+		return "", "", fmt.Errorf("failed to generate api key: %w", err)
+	}
+
+	secret, err := utils.GenerateSecureToken(32)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to generate api key: %w", err)
+	}
+	return apiKeyPrefix + hex.EncodeToString(id), secret, nil
+}
+
+// Helper function untuk menghapus scope duplikat dengan urutan tetap
+func normalizeAPIKeyScopes(scopes []string) pq.StringArray {
+	normalized := pq.StringArray{}
+	for _, allowed := range APIKeyScopes {
+		for _, scope := range scopes {
+			if strings.TrimSpace(scope) == allowed {
+				normalized = append(normalized, allowed)
+				break
+			}
+		}
+	}
+	return normalized
+}
+
+// validateAPIKeyScopes mengecek semua scope ada di APIKeyScopes
+func validateAPIKeyScopes(scopes []string) []string {
+	var violations []string
+	for _, scope := range scopes {
+		scope = strings.TrimSpace(scope)
+		if !containsAPIKeyScope(scope) {
+			violations = append(violations, fmt.Sprintf("scope %q is not allowed (allowed: %s)", scope,
+				strings.Join(APIKeyScopes, ", ")))
+		}
+	}
+	return violations
+}
+
+// Helper function untuk mengecek scope ada di allowlist
+func containsAPIKeyScope(scope string) bool {
+	for _, allowed := range APIKeyScopes {
+		if scope == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// APIKeyHandler untuk HTTP handlers API key
+type APIKeyHandler struct {
+	service *APIKeyService
+	logger  *logrus.Logger
+}
+
+// NewAPIKeyHandler membuat instance baru APIKeyHandler
+func NewAPIKeyHandler(service *APIKeyService, logger *logrus.Logger) *APIKeyHandler {
+	return &APIKeyHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// CreateMyAPIKey handler untuk POST /users/me/api-keys
+func (ah *APIKeyHandler) CreateMyAPIKey(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestResponse(c, "Invalid JSON format", err.Error())
+		return
+	}
+
+	violations := validateAPIKeyScopes(req.Scopes)
+	if strings.TrimSpace(req.Name) == "" {
+		violations = append(violations, "name cannot be empty")
+	}
+	if req.ExpiresAt != nil {
+		now := time.Now()
+		if !req.ExpiresAt.After(now) {
+			violations = append(violations, "expires_at must be in the future")
+		} else if maxLifetime := ah.service.maxLifetime; maxLifetime > 0 && req.ExpiresAt.After(now.Add(maxLifetime)) {
+			violations = append(violations, fmt.Sprintf("expires_at must be within %s from now", maxLifetime))
+		}
+	}
+	if len(violations) > 0 {
+		utils.ValidationErrorResponse(c, violations)
+		return
+	}
+
+	created, err := ah.service.CreateAPIKey(userID, req, clientMetadata(c))
+	if err != nil {
+		ah.handleAPIKeyError(c, userID, err, "Failed to create API key")
+		return
+	}
+
+	utils.CreatedResponse(c, "API key created successfully, store the key now because it will not be shown again", created)
+}
+
+// ListMyAPIKeys handler untuk GET /users/me/api-keys
+func (ah *APIKeyHandler) ListMyAPIKeys(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	ah.list(c, userID)
+}
+
+// GetMyAPIKey handler untuk GET /users/me/api-keys/:id
+func (ah *APIKeyHandler) GetMyAPIKey(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	id, ok := parseAPIKeyID(c, "id")
+	if !ok {
+		return
+	}
+
+	key, err := ah.service.GetAPIKey(userID, id)
+	if err != nil {
+		ah.handleAPIKeyError(c, userID, err, "Failed to get API key")
+		return
+	}
+
+	utils.SuccessResponse(c, "API key retrieved successfully", key)
+}
+
+// UpdateMyAPIKey handler untuk PUT /users/me/api-keys/:id
+func (ah *APIKeyHandler) UpdateMyAPIKey(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	id, ok := parseAPIKeyID(c, "id")
+	if !ok {
+		return
+	}
+
+	var req UpdateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestResponse(c, "Invalid JSON format", err.Error())
+		return
+	}
+
+	violations := validateAPIKeyScopes(req.Scopes)
+	if req.Scopes != nil && len(req.Scopes) == 0 {
+		violations = append(violations, "scopes must contain at least one scope")
+	}
+	if len(violations) > 0 {
+		utils.ValidationErrorResponse(c, violations)
+		return
+	}
+
+	key, err := ah.service.UpdateAPIKey(userID, id, req)
+	if err != nil {
+		ah.handleAPIKeyError(c, userID, err, "Failed to update API key")
+		return
+	}
+
+	utils.SuccessResponse(c, "API key updated successfully", key)
+}
+
+// RevokeMyAPIKey handler untuk DELETE /users/me/api-keys/:id
+func (ah *APIKeyHandler) RevokeMyAPIKey(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	ah.revoke(c, userID, "id")
+}
+
+// ListUserAPIKeys handler untuk GET /users/:id/api-keys
+func (ah *APIKeyHandler) ListUserAPIKeys(c *gin.Context) {
+	userID, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+	ah.list(c, userID)
+}
+
+// RevokeUserAPIKey handler untuk DELETE /users/:id/api-keys/:key_id
+func (ah *APIKeyHandler) RevokeUserAPIKey(c *gin.Context) {
+	userID, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+	ah.revoke(c, userID, "key_id")
+}
+
+// Verify handler untuk POST /internal/api-keys/verify, dipakai service lain lewat utils.RemoteAPIKeyVerifier
+func (ah *APIKeyHandler) Verify(c *gin.Context) {
+	var req VerifyAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestResponse(c, "Invalid JSON format", err.Error())
+		return
+	}
+
+	identity, err := ah.service.Identify(req.Key)
+	if err != nil {
+		if errors.Is(err, utils.ErrInvalidAPIKey) {
+			utils.ErrorResponse(c, http.StatusUnauthorized, utils.APIKeyInvalidCode, "Invalid API key", "API key is invalid, revoked or expired")
+			return
+		}
+		ah.logger.WithError(err).Error("Failed to verify API key")
+		utils.InternalServerErrorResponse(c, "Failed to verify API key")
+		return
+	}
+
+	utils.SuccessResponse(c, "API key is valid", identity)
+}
+
+// Helper function untuk response daftar API key
+func (ah *APIKeyHandler) list(c *gin.Context, userID int) {
+	currentKeyID := ""
+	if claims, ok := middleware.CurrentUser(c); ok {
+		currentKeyID = claims.APIKeyID
+	}
+
+	keys, err := ah.service.ListAPIKeys(userID, currentKeyID)
+	if err != nil {
+		ah.handleAPIKeyError(c, userID, err, "Failed to list API keys")
+		return
+	}
+
+	utils.SuccessResponse(c, "API keys retrieved successfully", keys)
+}
+
+// Helper function untuk revoke satu API key, param adalah nama path parameter ID key
+func (ah *APIKeyHandler) revoke(c *gin.Context, userID int, param string) {
+	id, ok := parseAPIKeyID(c, param)
+	if !ok {
+		return
+	}
+
+	if err := ah.service.RevokeAPIKey(userID, id, currentActorID(c), clientMetadata(c)); err != nil {
+		ah.handleAPIKeyError(c, userID, err, "Failed to revoke API key")
+		return
+	}
+
+	utils.SuccessResponse(c, "API key revoked successfully", nil)
+}
+
+// Helper function untuk mapping error API key ke HTTP response
+func (ah *APIKeyHandler) handleAPIKeyError(c *gin.Context, userID int, err error, message string) {
+	switch {
+	case errors.Is(err, ErrUserNotFound):
+		utils.NotFoundResponse(c, "User not found")
+	case errors.Is(err, ErrAPIKeyNotFound):
+		utils.NotFoundResponse(c, "API key not found")
+	case errors.Is(err, ErrAPIKeyLimitReached):
+		utils.ConflictResponse(c, "API key limit reached",
+			fmt.Sprintf("A user can have at most %d active API keys, revoke an unused key first", ah.service.maxPerUser))
+	default:
+		ah.logger.WithError(err).WithField("user_id", userID).Error(message)
+		utils.InternalServerErrorResponse(c, message)
+	}
+}
+
+// Helper function untuk parsing ID API key dari path parameter
+func parseAPIKeyID(c *gin.Context, param string) (int, bool) {
+	id, err := strconv.Atoi(c.Param(param))
+	if err != nil {
+		utils.BadRequestResponse(c, "Invalid API key ID format", "API key ID must be a number")
+		return 0, false
+	}
+	return id, true
+}
@@ -0,0 +1,742 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jizak1/Microservices-Golang/shared/middleware"
+	"github.com/jizak1/Microservices-Golang/shared/utils"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	// ErrInvalidCredentials username/email atau password salah
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	// ErrAccountInactive user sudah dinonaktifkan
+	ErrAccountInactive = errors.New("account is inactive")
+)
+
+// defaultUserRole role untuk user biasa
+const defaultUserRole = "user"
+
+// LoginRequest untuk request body login, bisa memakai username atau email
+type LoginRequest struct {
+	Username   string `json:"username" binding:"required_without=Email"`
+	Email      string `json:"email" binding:"omitempty,email"`
+	Password   string `json:"password" binding:"required"`
+	DeviceName string `json:"device_name,omitempty" binding:"omitempty,max=100"`
+}
+
+// RefreshTokenRequest untuk request body refresh dan logout
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// TokenPair access token dan refresh token yang dikembalikan ke client
+type TokenPair struct {
+	AccessToken      string `json:"access_token"`
+	RefreshToken     string `json:"refresh_token"`
+	TokenType        string `json:"token_type"`
+	ExpiresIn        int    `json:"expires_in"`
+	RefreshExpiresIn int    `json:"refresh_expires_in"`
+}
+
+// CookieSessionResponse data cookie session untuk web client, CSRF token wajib dikirim di header X-CSRF-Token
+// untuk request yang mengubah data
+type CookieSessionResponse struct {
+	CSRFToken   string    `json:"csrf_token"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	IdleTimeout int       `json:"idle_timeout"`
+}
+
+// AuthService untuk business logic authentication
+type AuthService struct {
+	userRepo    *UserRepository
+	tokenRepo   *RefreshTokenRepository
+	jwtManager  *utils.JWTManager
+	revocations *utils.TokenRevocationStore
+	hasher      *utils.PasswordHasher
+	throttler   *LoginThrottler
+	events      *SecurityEventRepository
+	challenges  *MFAChallengeStore
+	sessions    *utils.SessionStore
+	accessTTL   time.Duration
+	refreshTTL  time.Duration
+	logger      *logrus.Logger
+
+	// dummyPasswordHash dipakai ketika user tidak ditemukan supaya waktu respon login
+	// sama dengan user yang ada (mencegah user enumeration lewat timing)
+	dummyPasswordHash string
+}
+
+// NewAuthService membuat instance baru AuthService
+func NewAuthService(userRepo *UserRepository, tokenRepo *RefreshTokenRepository, jwtManager *utils.JWTManager,
+	revocations *utils.TokenRevocationStore, hasher *utils.PasswordHasher, throttler *LoginThrottler,
+	events *SecurityEventRepository, challenges *MFAChallengeStore, sessions *utils.SessionStore,
+	accessTTL, refreshTTL time.Duration, logger *logrus.Logger) (*AuthService, error) {
+	dummyPasswordHash, err := hasher.Hash("dummy-password-for-timing")
+	if err != nil {
+		return nil, err
+	}
+
+	return &AuthService{
+		userRepo:          userRepo,
+		tokenRepo:         tokenRepo,
+		jwtManager:        jwtManager,
+		revocations:       revocations,
+		hasher:            hasher,
+		throttler:         throttler,
+		events:            events,
+		challenges:        challenges,
+		sessions:          sessions,
+		accessTTL:         accessTTL,
+		refreshTTL:        refreshTTL,
+		logger:            logger,
+		dummyPasswordHash: dummyPasswordHash,
+	}, nil
+}
+
+// Login memverifikasi password dan membuat token pair dengan family baru.
+// Jika user memakai MFA, yang dikembalikan adalah challenge untuk langkah kedua (POST /auth/mfa/verify).
+// Login gagal dihitung per akun dan per IP, return *LoginThrottledError jika sedang dibatasi.
+func (as *AuthService) Login(req LoginRequest, metadata ClientMetadata) (*TokenPair, *MFAChallengeResponse, error) {
+	user, challenge, err := as.authenticate(req, metadata)
+	if err != nil || challenge != nil {
+		return nil, challenge, err
+	}
+
+	metadata.DeviceName = req.DeviceName
+	tokens, err := as.startSession(user, metadata, false)
+	return tokens, nil, err
+}
+
+// LoginCookieSession sama seperti Login, tapi yang dibuat adalah cookie session server-side untuk web client
+func (as *AuthService) LoginCookieSession(req LoginRequest, metadata ClientMetadata) (*utils.Session, *MFAChallengeResponse, error) {
+	user, challenge, err := as.authenticate(req, metadata)
+	if err != nil || challenge != nil {
+		return nil, challenge, err
+	}
+
+	session, err := as.startCookieSession(user, metadata, false)
+	return session, nil, err
+}
+
+// LogoutCookieSession menghapus cookie session dari store
+func (as *AuthService) LogoutCookieSession(sessionID string) error {
+	return as.sessions.Delete(sessionID)
+}
+
+// CookieSessionIdleTimeout durasi sliding expiration cookie session
+func (as *AuthService) CookieSessionIdleTimeout() time.Duration {
+	return as.sessions.IdleTimeout()
+}
+
+// Helper function untuk memverifikasi login (throttle, password, status akun).
+// Jika user memakai MFA yang dikembalikan adalah challenge, selain itu user yang sudah lolos semua faktor.
+func (as *AuthService) authenticate(req LoginRequest, metadata ClientMetadata) (*User, *MFAChallengeResponse, error) {
+	login := req.Username
+	if login == "" {
+		login = req.Email
+	}
+
+	user, err := as.userRepo.GetUserByLogin(login)
+	if err != nil {
+		if !errors.Is(err, ErrUserNotFound) {
+			return nil, nil, err
+		}
+		user = nil
+	}
+
+	// Throttle dicek sebelum password supaya akun yang dikunci tidak bisa terus ditebak.
+	// Login yang tidak dikenal juga di-throttle supaya responnya sama dengan akun yang ada.
+	throttleKey := loginThrottleKey(user, login)
+	if err := as.throttler.Check(throttleKey, metadata.IPAddress); err != nil {
+		return nil, nil, err
+	}
+	if user != nil && user.IsLocked(time.Now()) {
+		return nil, nil, &LoginThrottledError{RetryAfter: time.Until(*user.LockedUntil)}
+	}
+
+	if user == nil {
+		// Tetap jalankan hash supaya user yang tidak ada tidak bisa dibedakan dari timing
+		_, _, _ = as.hasher.Verify(req.Password, as.dummyPasswordHash)
+		as.recordLoginFailure(nil, throttleKey, metadata)
+		return nil, nil, ErrInvalidCredentials
+	}
+
+	valid, needsRehash, err := as.hasher.Verify(req.Password, user.Password)
+	if err != nil {
+		as.logger.WithError(err).WithField("user_id", user.ID).Error("Failed to verify password hash")
+	}
+	if err != nil || !valid {
+		as.recordLoginFailure(user, throttleKey, metadata)
+		return nil, nil, ErrInvalidCredentials
+	}
+
+	// Status akun dicek setelah password supaya tidak membocorkan status ke orang lain
+	if !user.IsActive {
+		return nil, nil, ErrAccountInactive
+	}
+
+	if needsRehash {
+		as.rehashPassword(user, req.Password)
+	}
+
+	// Counter login gagal baru di-reset setelah MFA lolos, kode MFA salah ikut dihitung
+	if user.MFAEnabled {
+		challenge, err := as.challenges.Create(MFAChallenge{UserID: user.ID, DeviceName: req.DeviceName})
+		return nil, challenge, err
+	}
+
+	as.clearLoginFailures(user, throttleKey)
+	return user, nil, nil
+}
+
+// startSession membuat token pair dengan family baru setelah semua faktor login lolos,
+// mfa menandai login yang sudah melewati verifikasi MFA
+func (as *AuthService) startSession(user *User, metadata ClientMetadata, mfa bool) (*TokenPair, error) {
+	familyID, err := utils.GenerateSecureToken(16)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate token family: %w", err)
+	}
+
+	return as.issueTokenPair(user, familyID, metadata, mfa)
+}
+
+// startCookieSession membuat cookie session setelah semua faktor login lolos
+func (as *AuthService) startCookieSession(user *User, metadata ClientMetadata, mfa bool) (*utils.Session, error) {
+	session, err := as.sessions.Create(strconv.Itoa(user.ID), user.Username, user.Email, user.Roles,
+		utils.AuthMethods(mfa), metadata.UserAgent, metadata.IPAddress)
+	if err != nil {
+		as.logger.WithError(err).WithField("user_id", user.ID).Error("Failed to create cookie session")
+		return nil, err
+	}
+	return session, nil
+}
+
+// Refresh melakukan rotasi refresh token. Token lama tidak bisa dipakai lagi.
+func (as *AuthService) Refresh(refreshToken string, metadata ClientMetadata) (*TokenPair, error) {
+	newRefreshToken, err := utils.GenerateSecureToken(32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+
+	now := time.Now()
+	newToken := &RefreshToken{
+		TokenHash:  utils.HashToken(newRefreshToken),
+		DeviceName: metadata.DeviceName,
+		UserAgent:  metadata.UserAgent,
+		IPAddress:  metadata.IPAddress,
+		ExpiresAt:  now.Add(as.refreshTTL),
+		CreatedAt:  now,
+	}
+
+	current, err := as.tokenRepo.RotateToken(utils.HashToken(refreshToken), newToken)
+	if err != nil {
+		// Family sudah di-revoke karena reuse, access token dari sesi tersebut juga ikut di-revoke
+		if errors.Is(err, ErrRefreshTokenReused) && current != nil {
+			as.revokeSessionTokens(current.FamilyID)
+		}
+		return nil, err
+	}
+
+	user, err := as.userRepo.GetUserByID(newToken.UserID)
+	if err != nil || !user.IsActive {
+		_ = as.tokenRepo.RevokeFamily(newToken.FamilyID, "user_inactive")
+		return nil, ErrRefreshTokenInvalid
+	}
+
+	// amr access token baru mengikuti cara login sesi ini, bukan cara refresh
+	session, err := as.tokenRepo.GetActiveSession(user.ID, newToken.FamilyID)
+	if err != nil {
+		return nil, ErrRefreshTokenInvalid
+	}
+
+	accessToken, err := as.jwtManager.GenerateSessionToken(strconv.Itoa(user.ID), user.Username, user.Email,
+		user.Roles, newToken.FamilyID, utils.AuthMethods(session.MFA), as.accessTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	return as.buildTokenPair(accessToken, newRefreshToken), nil
+}
+
+// Logout me-revoke family dari refresh token (logout dari device ini).
+// Access token yang dikirim juga langsung di-revoke supaya tidak bisa dipakai sampai expired.
+func (as *AuthService) Logout(refreshToken, accessToken string) error {
+	if accessToken != "" {
+		if err := as.revocations.RevokeTokenString(as.jwtManager, accessToken); err != nil {
+			as.logger.WithError(err).Warn("Failed to revoke access token on logout")
+		}
+	}
+
+	token, err := as.tokenRepo.GetTokenByHash(utils.HashToken(refreshToken))
+	if err != nil {
+		return err
+	}
+
+	return as.RevokeSession(token.FamilyID, "logout")
+}
+
+// RevokeSession mengakhiri satu sesi: refresh token family di-revoke dan access token dengan sid tersebut
+// langsung ditolak saat verifikasi
+func (as *AuthService) RevokeSession(sessionID, reason string) error {
+	if err := as.tokenRepo.RevokeFamily(sessionID, reason); err != nil {
+		return err
+	}
+
+	if err := as.revocations.RevokeSession(sessionID, as.accessTTL); err != nil {
+		as.logger.WithError(err).WithField("session_id", sessionID).Error("Failed to revoke session access tokens")
+		return fmt.Errorf("failed to revoke session access tokens: %w", err)
+	}
+	return nil
+}
+
+// RevokeUserAccess me-revoke semua refresh token dan access token user yang sudah dibuat,
+// dipakai ketika user dinonaktifkan atau dihapus.
+func (as *AuthService) RevokeUserAccess(userID int, reason string) error {
+	if err := as.tokenRepo.RevokeAllForUser(userID, reason); err != nil {
+		return err
+	}
+
+	if err := as.RevokeAccessTokens(userID); err != nil {
+		return err
+	}
+
+	as.logger.WithFields(logrus.Fields{
+		"user_id": userID,
+		"reason":  reason,
+	}).Info("User access revoked")
+	return nil
+}
+
+// RevokeAccessTokens me-revoke access token user yang sudah dibuat tanpa menyentuh refresh token,
+// dipakai ketika role user berubah supaya token berikutnya (hasil refresh) membawa role terbaru.
+// Cookie session juga dihapus karena menyimpan role user saat login.
+func (as *AuthService) RevokeAccessTokens(userID int) error {
+	if err := as.revocations.RevokeUserTokensBefore(strconv.Itoa(userID), time.Now(), as.accessTTL); err != nil {
+		as.logger.WithError(err).WithField("user_id", userID).Error("Failed to revoke access tokens")
+		return fmt.Errorf("failed to revoke access tokens: %w", err)
+	}
+
+	if err := as.sessions.RevokeUserSessions(strconv.Itoa(userID)); err != nil {
+		as.logger.WithError(err).WithField("user_id", userID).Error("Failed to revoke cookie sessions")
+		return fmt.Errorf("failed to revoke cookie sessions: %w", err)
+	}
+	return nil
+}
+
+// UnlockUser membuka kunci akun yang terkunci karena terlalu banyak login gagal
+func (as *AuthService) UnlockUser(userID int, actorID *int, metadata ClientMetadata) (*User, error) {
+	user, err := as.userRepo.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	wasLocked := user.IsLocked(time.Now())
+	if err := as.userRepo.ResetLoginFailures(userID); err != nil {
+		return nil, err
+	}
+	if err := as.throttler.Reset(loginThrottleKey(user, "")); err != nil {
+		as.logger.WithError(err).WithField("user_id", userID).Warn("Failed to reset login throttle on unlock")
+	}
+
+	if err := as.events.Record(SecurityEventAccountUnlocked, userID, actorID, metadata, map[string]interface{}{
+		"was_locked":      wasLocked,
+		"failed_attempts": user.FailedLoginAttempts,
+	}); err != nil {
+		as.logger.WithError(err).WithField("user_id", userID).Warn("Failed to record unlock event")
+	}
+
+	return as.userRepo.GetUserByID(userID)
+}
+
+// CurrentUser mengambil data user pemilik access token
+func (as *AuthService) CurrentUser(userID int) (*User, error) {
+	return as.userRepo.GetUserByID(userID)
+}
+
+// Helper function untuk mencatat login gagal dan mengunci akun jika sudah melewati batas.
+// Error di sini hanya di-log supaya respon login gagal tetap sama.
+func (as *AuthService) recordLoginFailure(user *User, throttleKey string, metadata ClientMetadata) {
+	failures, locked, err := as.throttler.RecordFailure(throttleKey, metadata.IPAddress)
+	if err != nil {
+		as.logger.WithError(err).Warn("Failed to record login failure")
+	}
+
+	if user == nil {
+		return
+	}
+
+	if err := as.userRepo.RecordFailedLogin(user.ID); err != nil {
+		as.logger.WithError(err).WithField("user_id", user.ID).Warn("Failed to persist login failure")
+	}
+
+	if !locked {
+		return
+	}
+
+	lockedUntil := time.Now().Add(as.throttler.LockoutDuration())
+	if err := as.userRepo.LockUser(user.ID, lockedUntil); err != nil {
+		return
+	}
+
+	if err := as.events.Record(SecurityEventAccountLocked, user.ID, nil, metadata, map[string]interface{}{
+		"failed_attempts": failures,
+		"locked_until":    lockedUntil.UTC(),
+	}); err != nil {
+		as.logger.WithError(err).WithField("user_id", user.ID).Warn("Failed to record lockout event")
+	}
+
+	as.logger.WithFields(logrus.Fields{
+		"user_id":      user.ID,
+		"locked_until": lockedUntil,
+	}).Warn("Account locked after too many failed logins")
+}
+
+// Helper function untuk reset counter login gagal setelah login sukses
+func (as *AuthService) clearLoginFailures(user *User, throttleKey string) {
+	if err := as.throttler.Reset(throttleKey); err != nil {
+		as.logger.WithError(err).WithField("user_id", user.ID).Warn("Failed to reset login throttle")
+	}
+
+	if user.FailedLoginAttempts == 0 && user.LockedAt == nil {
+		return
+	}
+	if err := as.userRepo.ResetLoginFailures(user.ID); err != nil {
+		as.logger.WithError(err).WithField("user_id", user.ID).Warn("Failed to reset login failures")
+	}
+}
+
+// Helper function untuk upgrade hash password lama (algoritma atau cost lama) saat login.
+// Gagal rehash tidak membatalkan login, hash akan dicoba lagi di login berikutnya.
+func (as *AuthService) rehashPassword(user *User, password string) {
+	passwordHash, err := as.hasher.Hash(password)
+	if err == nil {
+		err = as.userRepo.UpdateUser(user.ID, map[string]interface{}{"password_hash": passwordHash})
+	}
+	if err != nil {
+		as.logger.WithError(err).WithField("user_id", user.ID).Warn("Failed to upgrade password hash")
+		return
+	}
+
+	user.Password = passwordHash
+	as.logger.WithField("user_id", user.ID).Info("Password hash upgraded")
+}
+
+// Helper function untuk revoke access token satu sesi, error hanya di-log
+func (as *AuthService) revokeSessionTokens(sessionID string) {
+	if err := as.revocations.RevokeSession(sessionID, as.accessTTL); err != nil {
+		as.logger.WithError(err).WithField("session_id", sessionID).Error("Failed to revoke session access tokens")
+	}
+}
+
+// Helper function untuk membuat sesi baru dengan access token dan refresh token pertamanya
+func (as *AuthService) issueTokenPair(user *User, familyID string, metadata ClientMetadata, mfa bool) (*TokenPair, error) {
+	accessToken, err := as.jwtManager.GenerateSessionToken(strconv.Itoa(user.ID), user.Username, user.Email,
+		user.Roles, familyID, utils.AuthMethods(mfa), as.accessTTL)
+	if err != nil {
+		as.logger.WithError(err).Error("Failed to generate access token")
+		return nil, fmt.Errorf("failed to generate access token: %w", err)
+	}
+
+	refreshToken, err := utils.GenerateSecureToken(32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+
+	now := time.Now()
+	token := &RefreshToken{
+		UserID:     user.ID,
+		FamilyID:   familyID,
+		TokenHash:  utils.HashToken(refreshToken),
+		DeviceName: metadata.DeviceName,
+		UserAgent:  metadata.UserAgent,
+		IPAddress:  metadata.IPAddress,
+		ExpiresAt:  now.Add(as.refreshTTL),
+		CreatedAt:  now,
+	}
+
+	session := &Session{
+		ID:         familyID,
+		UserID:     user.ID,
+		DeviceName: metadata.DeviceName,
+		UserAgent:  metadata.UserAgent,
+		IPAddress:  metadata.IPAddress,
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  token.ExpiresAt,
+		MFA:        mfa,
+	}
+	if err := as.tokenRepo.CreateSession(session, token); err != nil {
+		return nil, err
+	}
+
+	return as.buildTokenPair(accessToken, refreshToken), nil
+}
+
+func (as *AuthService) buildTokenPair(accessToken, refreshToken string) *TokenPair {
+	return &TokenPair{
+		AccessToken:      accessToken,
+		RefreshToken:     refreshToken,
+		TokenType:        "Bearer",
+		ExpiresIn:        int(as.accessTTL.Seconds()),
+		RefreshExpiresIn: int(as.refreshTTL.Seconds()),
+	}
+}
+
+// AuthHandler untuk HTTP handlers authentication
+type AuthHandler struct {
+	service      *AuthService
+	cookieConfig middleware.SessionCookieConfig
+	logger       *logrus.Logger
+}
+
+// NewAuthHandler membuat instance baru AuthHandler
+func NewAuthHandler(service *AuthService, cookieConfig middleware.SessionCookieConfig, logger *logrus.Logger) *AuthHandler {
+	return &AuthHandler{
+		service:      service,
+		cookieConfig: cookieConfig,
+		logger:       logger,
+	}
+}
+
+// Login handler untuk POST /auth/login
+func (ah *AuthHandler) Login(c *gin.Context) {
+	var req LoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestResponse(c, "Invalid JSON format", err.Error())
+		return
+	}
+
+	tokens, challenge, err := ah.service.Login(req, clientMetadata(c))
+	if err != nil {
+		ah.loginErrorResponse(c, err)
+		return
+	}
+
+	if challenge != nil {
+		utils.SuccessResponse(c, "MFA verification required", challenge)
+		return
+	}
+
+	utils.SuccessResponse(c, "Login successful", tokens)
+}
+
+// LoginSession handler untuk POST /auth/login/session, login web client dengan cookie session.
+// User dengan MFA menyelesaikan login lewat POST /auth/mfa/verify/session.
+func (ah *AuthHandler) LoginSession(c *gin.Context) {
+	var req LoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestResponse(c, "Invalid JSON format", err.Error())
+		return
+	}
+
+	session, challenge, err := ah.service.LoginCookieSession(req, clientMetadata(c))
+	if err != nil {
+		ah.loginErrorResponse(c, err)
+		return
+	}
+
+	if challenge != nil {
+		utils.SuccessResponse(c, "MFA verification required", challenge)
+		return
+	}
+
+	cookieSessionResponse(c, ah.cookieConfig, session, ah.service.CookieSessionIdleTimeout())
+}
+
+// CurrentSession handler untuk GET /auth/session, dipakai web client untuk mengambil CSRF token setelah reload
+func (ah *AuthHandler) CurrentSession(c *gin.Context) {
+	session, ok := middleware.GetSessionFromContext(c)
+	if !ok {
+		utils.UnauthorizedResponse(c, "Session required")
+		return
+	}
+
+	utils.SuccessResponse(c, "Session retrieved successfully", CookieSessionResponse{
+		CSRFToken:   session.CSRFToken,
+		ExpiresAt:   session.ExpiresAt,
+		IdleTimeout: int(ah.service.CookieSessionIdleTimeout().Seconds()),
+	})
+}
+
+// LogoutSession handler untuk DELETE /auth/session
+func (ah *AuthHandler) LogoutSession(c *gin.Context) {
+	session, ok := middleware.GetSessionFromContext(c)
+	if !ok {
+		utils.UnauthorizedResponse(c, "Session required")
+		return
+	}
+
+	if err := ah.service.LogoutCookieSession(session.ID); err != nil {
+		ah.logger.WithError(err).Error("Failed to logout session")
+		utils.InternalServerErrorResponse(c, "Failed to logout")
+		return
+	}
+
+	middleware.ClearSessionCookie(c, ah.cookieConfig)
+	utils.SuccessResponse(c, "Logout successful", nil)
+}
+
+// Refresh handler untuk POST /auth/refresh
+func (ah *AuthHandler) Refresh(c *gin.Context) {
+	var req RefreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestResponse(c, "Invalid JSON format", err.Error())
+		return
+	}
+
+	tokens, err := ah.service.Refresh(req.RefreshToken, clientMetadata(c))
+	if err != nil {
+		if errors.Is(err, ErrRefreshTokenInvalid) || errors.Is(err, ErrRefreshTokenReused) {
+			utils.UnauthorizedResponse(c, err.Error())
+			return
+		}
+		ah.logger.WithError(err).Error("Failed to refresh token")
+		utils.InternalServerErrorResponse(c, "Failed to refresh token")
+		return
+	}
+
+	utils.SuccessResponse(c, "Token refreshed successfully", tokens)
+}
+
+// Logout handler untuk POST /auth/logout
+func (ah *AuthHandler) Logout(c *gin.Context) {
+	var req RefreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestResponse(c, "Invalid JSON format", err.Error())
+		return
+	}
+
+	accessToken := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if err := ah.service.Logout(req.RefreshToken, accessToken); err != nil && !errors.Is(err, ErrRefreshTokenInvalid) {
+		ah.logger.WithError(err).Error("Failed to logout")
+		utils.InternalServerErrorResponse(c, "Failed to logout")
+		return
+	}
+
+	// Logout selalu sukses supaya tidak membocorkan validitas token
+	utils.SuccessResponse(c, "Logout successful", nil)
+}
+
+// Me handler untuk GET /auth/me, mengembalikan user dari access token
+func (ah *AuthHandler) Me(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	user, err := ah.service.CurrentUser(userID)
+	if err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			utils.NotFoundResponse(c, "User not found")
+			return
+		}
+		ah.logger.WithError(err).WithField("user_id", userID).Error("Failed to get current user")
+		utils.InternalServerErrorResponse(c, "Failed to get current user")
+		return
+	}
+
+	utils.SuccessResponse(c, "Current user retrieved successfully", user)
+}
+
+// Helper function untuk response error login yang sama di login token dan login cookie session
+func (ah *AuthHandler) loginErrorResponse(c *gin.Context, err error) {
+	var throttled *LoginThrottledError
+	switch {
+	case errors.Is(err, ErrInvalidCredentials):
+		utils.UnauthorizedResponse(c, "Invalid username/email or password")
+	case errors.Is(err, ErrAccountInactive):
+		utils.ForbiddenResponse(c, "Account is inactive")
+	case errors.As(err, &throttled):
+		loginThrottledResponse(c, throttled)
+	default:
+		ah.logger.WithError(err).Error("Failed to login")
+		utils.InternalServerErrorResponse(c, "Failed to login")
+	}
+}
+
+// Helper function untuk menulis cookie session baru dan mengembalikan CSRF token-nya
+func cookieSessionResponse(c *gin.Context, cookieConfig middleware.SessionCookieConfig, session *utils.Session, idleTimeout time.Duration) {
+	middleware.SetSessionCookie(c, cookieConfig, session.ID, int(idleTimeout.Seconds()))
+	utils.SuccessResponse(c, "Login successful", CookieSessionResponse{
+		CSRFToken:   session.CSRFToken,
+		ExpiresAt:   session.ExpiresAt,
+		IdleTimeout: int(idleTimeout.Seconds()),
+	})
+}
+
+// UnlockUser handler untuk POST /users/:id/unlock (admin)
+func (ah *AuthHandler) UnlockUser(c *gin.Context) {
+	id, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+
+	user, err := ah.service.UnlockUser(id, currentActorID(c), clientMetadata(c))
+	if err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			utils.NotFoundResponse(c, "User not found")
+			return
+		}
+		ah.logger.WithError(err).WithField("user_id", id).Error("Failed to unlock user")
+		utils.InternalServerErrorResponse(c, "Failed to unlock user")
+		return
+	}
+
+	utils.SuccessResponse(c, "User unlocked successfully", user)
+}
+
+// Helper function untuk mengambil ID user dari access token. Response error sudah ditulis jika gagal.
+func currentUserID(c *gin.Context) (int, bool) {
+	claims, ok := middleware.CurrentUser(c)
+	if !ok {
+		utils.UnauthorizedResponse(c, "Authentication required")
+		return 0, false
+	}
+
+	userID, err := strconv.Atoi(claims.UserID)
+	if err != nil {
+		// Token service (client credentials) tidak mewakili user
+		utils.ForbiddenResponse(c, "Token does not belong to a user")
+		return 0, false
+	}
+	return userID, true
+}
+
+// Helper function untuk ID user yang melakukan aksi admin, nil jika request memakai token service
+func currentActorID(c *gin.Context) *int {
+	claims, ok := middleware.CurrentUser(c)
+	if !ok {
+		return nil
+	}
+
+	actorID, err := strconv.Atoi(claims.UserID)
+	if err != nil {
+		return nil
+	}
+	return &actorID
+}
+
+// Helper function untuk response 429 login yang sedang di-throttle
+func loginThrottledResponse(c *gin.Context, throttled *LoginThrottledError) {
+	retryAfter := int(math.Ceil(throttled.RetryAfter.Seconds()))
+	c.Header("Retry-After", strconv.Itoa(retryAfter))
+	utils.ErrorResponse(c, http.StatusTooManyRequests, "LOGIN_THROTTLED", "Too many failed login attempts",
+		fmt.Sprintf("Retry in %d seconds", retryAfter))
+}
+
+// Helper function untuk mengambil metadata device dari request
+func clientMetadata(c *gin.Context) ClientMetadata {
+	return ClientMetadata{
+		UserAgent: c.Request.UserAgent(),
+		IPAddress: c.ClientIP(),
+	}
+}
@@ -0,0 +1,540 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jizak1/Microservices-Golang/shared/utils"
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+// Jenis security event untuk data-subject request
+const (
+	SecurityEventDataExported = "data_exported"
+	SecurityEventUserErased   = "user_erased"
+)
+
+// EventUserErased event yang dikirim ke service lain setelah data user dihapus
+const EventUserErased = "user.erased"
+
+// ErrUserErased user sudah pernah di-erase
+var ErrUserErased = errors.New("user has been erased")
+
+// EraseUserRequest untuk request body erasure
+type EraseUserRequest struct {
+	Reason string `json:"reason" binding:"required,max=255"` // Misal nomor tiket data-subject request
+}
+
+// UserErasure bukti erasure yang disimpan permanen
+type UserErasure struct {
+	ID          int64           `json:"id" db:"id"`
+	UserID      int             `json:"user_id" db:"user_id"`
+	RequestedBy *int            `json:"requested_by" db:"requested_by"`
+	Reason      string          `json:"reason" db:"reason"`
+	Summary     json.RawMessage `json:"summary" db:"summary"` // Jumlah baris yang dianonimkan/dihapus per tabel
+	EventID     string          `json:"event_id" db:"event_id"`
+	ErasedAt    time.Time       `json:"erased_at" db:"erased_at"`
+}
+
+// UserRoleGrant role user beserta siapa yang memberikan
+type UserRoleGrant struct {
+	Role      string    `json:"role" db:"role"`
+	GrantedBy *int      `json:"granted_by" db:"granted_by"`
+	GrantedAt time.Time `json:"granted_at" db:"granted_at"`
+}
+
+// AccountSecurityExport status keamanan akun yang tidak tampil di response user biasa
+type AccountSecurityExport struct {
+	FailedLoginAttempts    int        `json:"failed_login_attempts"`
+	LastFailedLoginAt      *time.Time `json:"last_failed_login_at"`
+	LockedAt               *time.Time `json:"locked_at"`
+	LockedUntil            *time.Time `json:"locked_until"`
+	MFAEnabled             bool       `json:"mfa_enabled"`
+	MFAConfirmedAt         *time.Time `json:"mfa_confirmed_at"`
+	RecoveryCodesRemaining int        `json:"recovery_codes_remaining"`
+}
+
+// EmailVerificationExport riwayat verifikasi email, tanpa token
+type EmailVerificationExport struct {
+	Email     string     `json:"email" db:"email"`
+	ExpiresAt time.Time  `json:"expires_at" db:"expires_at"`
+	UsedAt    *time.Time `json:"used_at" db:"used_at"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+}
+
+// PasswordResetExport riwayat permintaan reset password, tanpa token
+type PasswordResetExport struct {
+	RequestedIP string     `json:"requested_ip" db:"requested_ip"`
+	ExpiresAt   time.Time  `json:"expires_at" db:"expires_at"`
+	UsedAt      *time.Time `json:"used_at" db:"used_at"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+}
+
+// UserDataExport semua data yang disimpan user service tentang satu user
+type UserDataExport struct {
+	GeneratedAt        time.Time                 `json:"generated_at"`
+	Profile            *User                     `json:"profile"`
+	Security           AccountSecurityExport     `json:"security"`
+	Roles              []UserRoleGrant           `json:"roles"`
+	Sessions           []RefreshToken            `json:"sessions"`
+	APIKeys            []APIKey                  `json:"api_keys"`
+	SecurityEvents     []SecurityEvent           `json:"security_events"`
+	EmailVerifications []EmailVerificationExport `json:"email_verifications"`
+	PasswordResets     []PasswordResetExport     `json:"password_resets"`
+}
+
+// GDPRRepository untuk database operations export dan erasure data user
+type GDPRRepository struct {
+	db     *sqlx.DB
+	logger *logrus.Logger
+}
+
+// NewGDPRRepository membuat instance baru GDPRRepository
+func NewGDPRRepository(db *sqlx.DB, logger *logrus.Logger) *GDPRRepository {
+	return &GDPRRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// CollectUserData mengisi semua data terkait user ke export
+func (gr *GDPRRepository) CollectUserData(export *UserDataExport) error {
+	userID := export.Profile.ID
+
+	export.Roles = []UserRoleGrant{}
+	if err := gr.db.Select(&export.Roles, `SELECT r.name AS role, ur.granted_by, ur.granted_at
+		FROM user_roles ur JOIN roles r ON r.id = ur.role_id
+		WHERE ur.user_id = $1 ORDER BY ur.granted_at`, userID); err != nil {
+		return fmt.Errorf("failed to export roles: %w", err)
+	}
+
+	export.Sessions = []RefreshToken{}
+	if err := gr.db.Select(&export.Sessions, `SELECT id, user_id, family_id, device_name, user_agent,
+		ip_address, expires_at, rotated_at, revoked_at, revoked_reason, created_at
+		FROM refresh_tokens WHERE user_id = $1 ORDER BY created_at`, userID); err != nil {
+		return fmt.Errorf("failed to export sessions: %w", err)
+	}
+
+	export.APIKeys = []APIKey{}
+	if err := gr.db.Select(&export.APIKeys, `SELECT `+apiKeyColumns+`
+		FROM api_keys WHERE user_id = $1 ORDER BY created_at`, userID); err != nil {
+		return fmt.Errorf("failed to export api keys: %w", err)
+	}
+
+	export.SecurityEvents = []SecurityEvent{}
+	if err := gr.db.Select(&export.SecurityEvents, `SELECT id, user_id, actor_id, event_type, ip_address, user_agent,
+		metadata, created_at
+		FROM security_events WHERE user_id = $1 OR actor_id = $1 ORDER BY created_at`, userID); err != nil {
+		return fmt.Errorf("failed to export security events: %w", err)
+	}
+
+	export.EmailVerifications = []EmailVerificationExport{}
+	if err := gr.db.Select(&export.EmailVerifications, `SELECT email, expires_at, used_at, created_at
+		FROM email_verification_tokens WHERE user_id = $1 ORDER BY created_at`, userID); err != nil {
+		return fmt.Errorf("failed to export email verifications: %w", err)
+	}
+
+	export.PasswordResets = []PasswordResetExport{}
+	if err := gr.db.Select(&export.PasswordResets, `SELECT requested_ip, expires_at, used_at, created_at
+		FROM password_reset_tokens WHERE user_id = $1 ORDER BY created_at`, userID); err != nil {
+		return fmt.Errorf("failed to export password resets: %w", err)
+	}
+
+	err := gr.db.QueryRow(`SELECT confirmed_at FROM user_mfa WHERE user_id = $1`, userID).Scan(&export.Security.MFAConfirmedAt)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("failed to export mfa: %w", err)
+	}
+
+	if err := gr.db.Get(&export.Security.RecoveryCodesRemaining,
+		`SELECT COUNT(*) FROM mfa_recovery_codes WHERE user_id = $1 AND used_at IS NULL`, userID); err != nil {
+		return fmt.Errorf("failed to export recovery codes: %w", err)
+	}
+
+	return nil
+}
+
+// EraseUser menganonimkan PII user di tempat. Baris user tetap ada supaya foreign key dari tabel lain
+// dan service lain tetap valid. Bukti erasure dan event user.erased disimpan di transaksi yang sama.
+func (gr *GDPRRepository) EraseUser(userID int, requestedBy *int, reason string) (*UserErasure, error) {
+	tx, err := gr.db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var erasedAt *time.Time
+	if err := tx.Get(&erasedAt, `SELECT erased_at FROM users WHERE id = $1 FOR UPDATE`, userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	if erasedAt != nil {
+		return nil, ErrUserErased
+	}
+
+	now := time.Now()
+	if _, err := tx.Exec(`UPDATE users SET
+			username = 'erased-' || id,
+			email = 'erased-' || id || '@erased.invalid',
+			full_name = 'Erased User',
+			password_hash = '!',
+			is_active = false,
+			email_verified_at = NULL,
+			mfa_enabled = false,
+			failed_login_attempts = 0,
+			last_failed_login_at = NULL,
+			locked_at = NULL,
+			locked_until = NULL,
+			deleted_at = COALESCE(deleted_at, $1),
+			erased_at = $1,
+			updated_at = $1,
+			version = version + 1
+		WHERE id = $2`, now, userID); err != nil {
+		return nil, fmt.Errorf("failed to anonymize user: %w", err)
+	}
+
+	// Data turunan yang berisi PII atau credential dihapus, IP dan user agent di audit log dikosongkan
+	summary := map[string]int64{"users": 1}
+	statements := []struct {
+		table string
+		query string
+	}{
+		{"refresh_tokens", `DELETE FROM refresh_tokens WHERE user_id = $1`},
+		{"user_sessions", `DELETE FROM user_sessions WHERE user_id = $1`},
+		{"api_keys", `DELETE FROM api_keys WHERE user_id = $1`},
+		{"email_verification_tokens", `DELETE FROM email_verification_tokens WHERE user_id = $1`},
+		{"password_reset_tokens", `DELETE FROM password_reset_tokens WHERE user_id = $1`},
+		{"user_mfa", `DELETE FROM user_mfa WHERE user_id = $1`},
+		{"mfa_recovery_codes", `DELETE FROM mfa_recovery_codes WHERE user_id = $1`},
+		{"user_roles", `DELETE FROM user_roles WHERE user_id = $1`},
+		{"security_events", `UPDATE security_events SET ip_address = '', user_agent = ''
+			WHERE user_id = $1 OR actor_id = $1`},
+	}
+	for _, statement := range statements {
+		result, err := tx.Exec(statement.query, userID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to erase %s: %w", statement.table, err)
+		}
+		summary[statement.table], _ = result.RowsAffected()
+	}
+
+	encodedSummary, err := json.Marshal(summary)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode erasure summary: %w", err)
+	}
+
+	erasure := &UserErasure{
+		UserID:      userID,
+		RequestedBy: requestedBy,
+		Reason:      reason,
+		Summary:     encodedSummary,
+		ErasedAt:    now,
+	}
+	if err := tx.QueryRow(`INSERT INTO user_erasures (user_id, requested_by, reason, summary, erased_at)
+		VALUES ($1, $2, $3, $4, $5) RETURNING id`, userID, requestedBy, reason, string(encodedSummary), now).Scan(&erasure.ID); err != nil {
+		return nil, fmt.Errorf("failed to record erasure: %w", err)
+	}
+
+	event, err := utils.NewEvent(EventUserErased, serviceName, map[string]interface{}{
+		"user_id":    userID,
+		"erasure_id": erasure.ID,
+		"erased_at":  now.UTC(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := EnqueueEvent(tx, event); err != nil {
+		return nil, err
+	}
+
+	erasure.EventID = event.ID
+	if _, err := tx.Exec(`UPDATE user_erasures SET event_id = $1 WHERE id = $2`, event.ID, erasure.ID); err != nil {
+		return nil, fmt.Errorf("failed to record erasure event: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	gr.logger.WithFields(logrus.Fields{
+		"user_id":    userID,
+		"erasure_id": erasure.ID,
+	}).Info("User erased successfully")
+	return erasure, nil
+}
+
+// GDPRService untuk business logic data-subject request (export dan erasure)
+type GDPRService struct {
+	repo        *GDPRRepository
+	userRepo    *UserRepository
+	authService *AuthService
+	events      *SecurityEventRepository
+	logger      *logrus.Logger
+}
+
+// NewGDPRService membuat instance baru GDPRService
+func NewGDPRService(repo *GDPRRepository, userRepo *UserRepository, authService *AuthService,
+	events *SecurityEventRepository, logger *logrus.Logger) *GDPRService {
+	return &GDPRService{
+		repo:        repo,
+		userRepo:    userRepo,
+		authService: authService,
+		events:      events,
+		logger:      logger,
+	}
+}
+
+// ExportUserData mengumpulkan semua data user, termasuk user yang sudah di-soft delete
+func (gs *GDPRService) ExportUserData(userID int, actorID *int, metadata ClientMetadata) (*UserDataExport, error) {
+	user, err := gs.userRepo.GetUserByIDIncludingDeleted(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	export := &UserDataExport{
+		GeneratedAt: time.Now().UTC(),
+		Profile:     user,
+		Security: AccountSecurityExport{
+			FailedLoginAttempts: user.FailedLoginAttempts,
+			LastFailedLoginAt:   user.LastFailedLoginAt,
+			LockedAt:            user.LockedAt,
+			LockedUntil:         user.LockedUntil,
+			MFAEnabled:          user.MFAEnabled,
+		},
+	}
+	if err := gs.repo.CollectUserData(export); err != nil {
+		return nil, err
+	}
+
+	if err := gs.events.Record(SecurityEventDataExported, userID, actorID, metadata, nil); err != nil {
+		gs.logger.WithError(err).WithField("user_id", userID).Warn("Failed to record data export event")
+	}
+	return export, nil
+}
+
+// EraseUser menjalankan erasure dan langsung mematikan semua access token user
+func (gs *GDPRService) EraseUser(userID int, req EraseUserRequest, actorID *int, metadata ClientMetadata) (*UserErasure, error) {
+	erasure, err := gs.repo.EraseUser(userID, actorID, req.Reason)
+	if err != nil {
+		return nil, err
+	}
+
+	// Refresh token sudah dihapus di transaksi erasure, access token yang masih berlaku di-revoke
+	if err := gs.authService.RevokeAccessTokens(userID); err != nil {
+		gs.logger.WithError(err).WithField("user_id", userID).Warn("Failed to revoke access tokens after erasure")
+	}
+
+	if err := gs.events.Record(SecurityEventUserErased, userID, actorID, metadata,
+		map[string]interface{}{"erasure_id": erasure.ID}); err != nil {
+		gs.logger.WithError(err).WithField("user_id", userID).Warn("Failed to record erasure event")
+	}
+	return erasure, nil
+}
+
+// GDPRHandler untuk HTTP handlers export dan erasure
+type GDPRHandler struct {
+	service *GDPRService
+	logger  *logrus.Logger
+}
+
+// NewGDPRHandler membuat instance baru GDPRHandler
+func NewGDPRHandler(service *GDPRService, logger *logrus.Logger) *GDPRHandler {
+	return &GDPRHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// Export handler untuk GET /users/:id/export, response berupa file zip berisi JSON dan CSV
+func (gh *GDPRHandler) Export(c *gin.Context) {
+	id, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+
+	export, err := gh.service.ExportUserData(id, currentActorID(c), clientMetadata(c))
+	if err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			utils.NotFoundResponse(c, "User not found")
+			return
+		}
+		gh.logger.WithError(err).WithField("user_id", id).Error("Failed to export user data")
+		utils.InternalServerErrorResponse(c, "Failed to export user data")
+		return
+	}
+
+	archive, err := buildExportArchive(export)
+	if err != nil {
+		gh.logger.WithError(err).WithField("user_id", id).Error("Failed to build export archive")
+		utils.InternalServerErrorResponse(c, "Failed to export user data")
+		return
+	}
+
+	filename := fmt.Sprintf("user-%d-export-%s.zip", id, export.GeneratedAt.Format("20060102T150405Z"))
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusOK, "application/zip", archive)
+}
+
+// Erase handler untuk POST /users/:id/erase
+func (gh *GDPRHandler) Erase(c *gin.Context) {
+	id, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+
+	var req EraseUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestResponse(c, "Invalid JSON format", err.Error())
+		return
+	}
+
+	erasure, err := gh.service.EraseUser(id, req, currentActorID(c), clientMetadata(c))
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrUserNotFound):
+			utils.NotFoundResponse(c, "User not found")
+		case errors.Is(err, ErrUserErased):
+			utils.ConflictResponse(c, "Failed to erase user", err.Error())
+		default:
+			gh.logger.WithError(err).WithField("user_id", id).Error("Failed to erase user")
+			utils.InternalServerErrorResponse(c, "Failed to erase user")
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, "User erased successfully", erasure)
+}
+
+// Helper function untuk membuat zip berisi export.json dan satu CSV per kategori data
+func buildExportArchive(export *UserDataExport) ([]byte, error) {
+	var buffer bytes.Buffer
+	archive := zip.NewWriter(&buffer)
+
+	encoded, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode export: %w", err)
+	}
+	file, err := archive.Create("export.json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create export.json: %w", err)
+	}
+	if _, err := file.Write(encoded); err != nil {
+		return nil, fmt.Errorf("failed to write export.json: %w", err)
+	}
+
+	profile := export.Profile
+	tables := []struct {
+		name   string
+		header []string
+		rows   [][]string
+	}{
+		{
+			name:   "profile.csv",
+			header: []string{"id", "username", "email", "full_name", "is_active", "email_verified_at", "mfa_enabled", "created_at", "updated_at", "deleted_at"},
+			rows: [][]string{{
+				strconv.Itoa(profile.ID), profile.Username, profile.Email, profile.FullName,
+				strconv.FormatBool(profile.IsActive), formatExportTime(profile.EmailVerifiedAt),
+				strconv.FormatBool(profile.MFAEnabled), formatExportTime(&profile.CreatedAt),
+				formatExportTime(&profile.UpdatedAt), formatExportTime(profile.DeletedAt),
+			}},
+		},
+		{name: "roles.csv", header: []string{"role", "granted_by", "granted_at"}},
+		{name: "sessions.csv", header: []string{"id", "family_id", "device_name", "user_agent", "ip_address", "created_at", "expires_at", "rotated_at", "revoked_at", "revoked_reason"}},
+		{name: "security_events.csv", header: []string{"id", "event_type", "actor_id", "ip_address", "user_agent", "metadata", "created_at"}},
+		{name: "email_verifications.csv", header: []string{"email", "created_at", "expires_at", "used_at"}},
+		{name: "password_resets.csv", header: []string{"requested_ip", "created_at", "expires_at", "used_at"}},
+		{name: "api_keys.csv", header: []string{"id", "name", "prefix", "scopes", "created_at", "expires_at", "last_used_at", "revoked_at", "revoked_reason"}},
+	}
+
+	for _, role := range export.Roles {
+		tables[1].rows = append(tables[1].rows, []string{role.Role, formatExportInt(role.GrantedBy), formatExportTime(&role.GrantedAt)})
+	}
+	for _, session := range export.Sessions {
+		revokedReason := ""
+		if session.RevokedReason != nil {
+			revokedReason = *session.RevokedReason
+		}
+		tables[2].rows = append(tables[2].rows, []string{
+			strconv.FormatInt(session.ID, 10), session.FamilyID, session.DeviceName, session.UserAgent, session.IPAddress,
+			formatExportTime(&session.CreatedAt), formatExportTime(&session.ExpiresAt), formatExportTime(session.RotatedAt),
+			formatExportTime(session.RevokedAt), revokedReason,
+		})
+	}
+	for _, event := range export.SecurityEvents {
+		tables[3].rows = append(tables[3].rows, []string{
+			strconv.FormatInt(event.ID, 10), event.EventType, formatExportInt(event.ActorID), event.IPAddress,
+			event.UserAgent, string(event.Metadata), formatExportTime(&event.CreatedAt),
+		})
+	}
+	for _, verification := range export.EmailVerifications {
+		tables[4].rows = append(tables[4].rows, []string{
+			verification.Email, formatExportTime(&verification.CreatedAt), formatExportTime(&verification.ExpiresAt),
+			formatExportTime(verification.UsedAt),
+		})
+	}
+	for _, reset := range export.PasswordResets {
+		tables[5].rows = append(tables[5].rows, []string{
+			reset.RequestedIP, formatExportTime(&reset.CreatedAt), formatExportTime(&reset.ExpiresAt),
+			formatExportTime(reset.UsedAt),
+		})
+	}
+	for _, key := range export.APIKeys {
+		revokedReason := ""
+		if key.RevokedReason != nil {
+			revokedReason = *key.RevokedReason
+		}
+		tables[6].rows = append(tables[6].rows, []string{
+			strconv.Itoa(key.ID), key.Name, key.Prefix, strings.Join(key.Scopes, " "), formatExportTime(&key.CreatedAt),
+			formatExportTime(key.ExpiresAt), formatExportTime(key.LastUsedAt), formatExportTime(key.RevokedAt), revokedReason,
+		})
+	}
+
+	for _, table := range tables {
+		file, err := archive.Create(table.name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create %s: %w", table.name, err)
+		}
+
+		writer := csv.NewWriter(file)
+		if err := writer.Write(table.header); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", table.name, err)
+		}
+		if err := writer.WriteAll(table.rows); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", table.name, err)
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		return nil, fmt.Errorf("failed to finalize archive: %w", err)
+	}
+	return buffer.Bytes(), nil
+}
+
+// Helper function untuk format waktu di CSV, kosong jika nil
+func formatExportTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// Helper function untuk format ID opsional di CSV
+func formatExportInt(value *int) string {
+	if value == nil {
+		return ""
+	}
+	return strconv.Itoa(*value)
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/jizak1/Microservices-Golang/shared/database"
+	"github.com/sirupsen/logrus"
+)
+
+// LoginThrottleConfig konfigurasi brute-force protection untuk login
+type LoginThrottleConfig struct {
+	MaxAccountFailures int           // Login gagal per akun sebelum akun dikunci
+	MaxIPFailures      int           // Login gagal per IP (semua akun) sebelum IP diblokir
+	FailureWindow      time.Duration // Counter gagal di-reset jika tidak ada kegagalan selama window ini
+	LockoutDuration    time.Duration
+	BaseDelay          time.Duration // Delay setelah gagal pertama, dikali dua setiap gagal berikutnya
+	MaxDelay           time.Duration
+}
+
+// LoginThrottledError login ditolak sementara karena terlalu banyak percobaan gagal
+type LoginThrottledError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LoginThrottledError) Error() string {
+	return fmt.Sprintf("too many failed login attempts, retry in %s", e.RetryAfter.Round(time.Second))
+}
+
+// LoginThrottler menghitung login gagal per akun dan per IP di Redis
+type LoginThrottler struct {
+	redis  *database.RedisClient
+	config LoginThrottleConfig
+	logger *logrus.Logger
+}
+
+// NewLoginThrottler membuat instance baru LoginThrottler
+func NewLoginThrottler(redis *database.RedisClient, config LoginThrottleConfig, logger *logrus.Logger) *LoginThrottler {
+	return &LoginThrottler{
+		redis:  redis,
+		config: config,
+		logger: logger,
+	}
+}
+
+// Check dipanggil sebelum password diverifikasi. Return *LoginThrottledError jika akun sedang
+// dikunci, masih dalam delay setelah login gagal, atau IP sudah melewati batas.
+func (lt *LoginThrottler) Check(accountKey, ip string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	pipe := lt.redis.Client.Pipeline()
+	lockTTL := pipe.PTTL(ctx, lt.key("lock", accountKey))
+	delayTTL := pipe.PTTL(ctx, lt.key("delay", accountKey))
+	ipFailures := pipe.Get(ctx, lt.key("ip", ip))
+	ipTTL := pipe.PTTL(ctx, lt.key("ip", ip))
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		// Fail open seperti rate limiter, lockout di database tetap berlaku
+		lt.logger.WithError(err).Warn("Failed to check login throttle")
+		return nil
+	}
+
+	if ttl := lockTTL.Val(); ttl > 0 {
+		return &LoginThrottledError{RetryAfter: ttl}
+	}
+
+	if failures, _ := strconv.Atoi(ipFailures.Val()); lt.config.MaxIPFailures > 0 && failures >= lt.config.MaxIPFailures {
+		return &LoginThrottledError{RetryAfter: ipTTL.Val()}
+	}
+
+	if ttl := delayTTL.Val(); ttl > 0 {
+		return &LoginThrottledError{RetryAfter: ttl}
+	}
+
+	return nil
+}
+
+// RecordFailure mencatat login gagal. locked true jika kegagalan ini membuat akun dikunci.
+func (lt *LoginThrottler) RecordFailure(accountKey, ip string) (failures int, locked bool, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	accountCounter := lt.key("account", accountKey)
+	ipCounter := lt.key("ip", ip)
+
+	var accountFailures *redis.IntCmd
+	_, err = lt.redis.Client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		accountFailures = pipe.Incr(ctx, accountCounter)
+		pipe.Expire(ctx, accountCounter, lt.config.FailureWindow)
+		pipe.Incr(ctx, ipCounter)
+		pipe.Expire(ctx, ipCounter, lt.config.FailureWindow)
+		return nil
+	})
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to record login failure: %w", err)
+	}
+
+	failures = int(accountFailures.Val())
+	if failures >= lt.config.MaxAccountFailures {
+		// Counter di-reset supaya setelah lockout selesai user mendapat jatah percobaan baru
+		_, err = lt.redis.Client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(ctx, lt.key("lock", accountKey), "1", lt.config.LockoutDuration)
+			pipe.Del(ctx, accountCounter, lt.key("delay", accountKey))
+			return nil
+		})
+		if err != nil {
+			return failures, false, fmt.Errorf("failed to lock account: %w", err)
+		}
+		return failures, true, nil
+	}
+
+	if err := lt.redis.Client.Set(ctx, lt.key("delay", accountKey), "1", lt.delayFor(failures)).Err(); err != nil {
+		return failures, false, fmt.Errorf("failed to set login delay: %w", err)
+	}
+	return failures, false, nil
+}
+
+// Reset menghapus counter, delay, dan lock akun (setelah login sukses atau unlock admin).
+// Counter per IP tidak di-reset supaya satu akun valid tidak bisa dipakai menghapus jejak credential stuffing.
+func (lt *LoginThrottler) Reset(accountKey string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err := lt.redis.Client.Del(ctx,
+		lt.key("account", accountKey),
+		lt.key("delay", accountKey),
+		lt.key("lock", accountKey),
+	).Err()
+	if err != nil {
+		return fmt.Errorf("failed to reset login throttle: %w", err)
+	}
+	return nil
+}
+
+// LockoutDuration lama akun dikunci setelah terlalu banyak login gagal
+func (lt *LoginThrottler) LockoutDuration() time.Duration {
+	return lt.config.LockoutDuration
+}
+
+// Helper function untuk delay progresif: BaseDelay, 2x, 4x, ... maksimal MaxDelay
+func (lt *LoginThrottler) delayFor(failures int) time.Duration {
+	delay := lt.config.BaseDelay
+	for i := 1; i < failures && delay < lt.config.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > lt.config.MaxDelay {
+		delay = lt.config.MaxDelay
+	}
+	return delay
+}
+
+// Helper function untuk key Redis login throttle
+func (lt *LoginThrottler) key(kind, value string) string {
+	return "login_throttle:" + kind + ":" + value
+}
+
+// Helper function untuk key throttle akun. User yang ada memakai ID supaya login dengan
+// username dan email berbagi counter yang sama, login yang tidak dikenal memakai nilai login.
+func loginThrottleKey(user *User, login string) string {
+	if user != nil {
+		return "user:" + strconv.Itoa(user.ID)
+	}
+	return "login:" + strings.ToLower(strings.TrimSpace(login))
+}
@@ -0,0 +1,659 @@
+package main
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jizak1/Microservices-Golang/shared/database"
+	"github.com/jizak1/Microservices-Golang/shared/middleware"
+	"github.com/jizak1/Microservices-Golang/shared/utils"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// mfaRecoveryCodeCount jumlah recovery code yang dibuat saat MFA diaktifkan
+	mfaRecoveryCodeCount = 10
+	// mfaChallengeMaxAttempts kode salah per challenge sebelum challenge dibatalkan
+	mfaChallengeMaxAttempts = 5
+	// mfaCodeSkew toleransi perbedaan jam device user (dalam step 30 detik)
+	mfaCodeSkew = 1
+)
+
+// Jenis security event MFA
+const (
+	SecurityEventMFAEnabled          = "mfa_enabled"
+	SecurityEventMFAReset            = "mfa_reset"
+	SecurityEventMFARecoveryCodeUsed = "mfa_recovery_code_used"
+)
+
+var (
+	// ErrMFAAlreadyEnabled MFA sudah aktif, harus di-reset dulu sebelum enroll ulang
+	ErrMFAAlreadyEnabled = errors.New("mfa is already enabled")
+	// ErrMFANotEnrolled user belum memulai enrollment MFA
+	ErrMFANotEnrolled = errors.New("mfa enrollment not found")
+	// ErrMFACodeInvalid kode TOTP atau recovery code salah / sudah dipakai
+	ErrMFACodeInvalid = errors.New("invalid mfa code")
+	// ErrMFAChallengeInvalid challenge token tidak ada, expired, atau terlalu banyak kode salah
+	ErrMFAChallengeInvalid = errors.New("mfa challenge is invalid or expired")
+)
+
+// ConfirmMFARequest untuk request body konfirmasi enrollment MFA
+type ConfirmMFARequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// VerifyMFARequest untuk request body langkah kedua login, pakai kode TOTP atau recovery code
+type VerifyMFARequest struct {
+	MFAToken     string `json:"mfa_token" binding:"required"`
+	Code         string `json:"code" binding:"required_without=RecoveryCode"`
+	RecoveryCode string `json:"recovery_code"`
+}
+
+// MFAEnrollment secret yang harus dimasukkan user ke authenticator app
+type MFAEnrollment struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+}
+
+// MFAChallengeResponse dikembalikan login jika user memakai MFA
+type MFAChallengeResponse struct {
+	MFARequired bool   `json:"mfa_required"`
+	MFAToken    string `json:"mfa_token"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+// RecoveryCodesResponse recovery code yang hanya ditampilkan sekali
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// UserMFA data MFA user di database
+type UserMFA struct {
+	UserID          int        `db:"user_id"`
+	SecretEncrypted string     `db:"secret_encrypted"`
+	ConfirmedAt     *time.Time `db:"confirmed_at"`
+	LastUsedStep    int64      `db:"last_used_step"`
+	CreatedAt       time.Time  `db:"created_at"`
+	UpdatedAt       time.Time  `db:"updated_at"`
+}
+
+// MFARepository untuk database operations MFA
+type MFARepository struct {
+	db     *sqlx.DB
+	logger *logrus.Logger
+}
+
+// NewMFARepository membuat instance baru MFARepository
+func NewMFARepository(db *sqlx.DB, logger *logrus.Logger) *MFARepository {
+	return &MFARepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// SaveSecret menyimpan secret (terenkripsi) yang belum dikonfirmasi, enrollment lama ditimpa
+func (mr *MFARepository) SaveSecret(userID int, secretEncrypted string) error {
+	query := `INSERT INTO user_mfa (user_id, secret_encrypted, confirmed_at, last_used_step, created_at, updated_at)
+			  VALUES ($1, $2, NULL, 0, $3, $3)
+			  ON CONFLICT (user_id) DO UPDATE SET secret_encrypted = EXCLUDED.secret_encrypted,
+			  confirmed_at = NULL, last_used_step = 0, updated_at = EXCLUDED.updated_at`
+
+	if _, err := mr.db.Exec(query, userID, secretEncrypted, time.Now()); err != nil {
+		mr.logger.WithError(err).WithField("user_id", userID).Error("Failed to save MFA secret")
+		return fmt.Errorf("failed to save mfa secret: %w", err)
+	}
+	return nil
+}
+
+// GetMFA mengambil data MFA user
+func (mr *MFARepository) GetMFA(userID int) (*UserMFA, error) {
+	var mfa UserMFA
+	query := `SELECT user_id, secret_encrypted, confirmed_at, last_used_step, created_at, updated_at
+			  FROM user_mfa WHERE user_id = $1`
+
+	if err := mr.db.Get(&mfa, query, userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrMFANotEnrolled
+		}
+		return nil, fmt.Errorf("failed to get mfa: %w", err)
+	}
+	return &mfa, nil
+}
+
+// Enable mengaktifkan MFA dan mengganti semua recovery code dalam satu transaction
+func (mr *MFARepository) Enable(userID int, usedStep int64, recoveryCodeHashes []string) error {
+	tx, err := mr.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	if _, err := tx.Exec(`UPDATE user_mfa SET confirmed_at = $1, last_used_step = $2, updated_at = $1
+		WHERE user_id = $3`, now, usedStep, userID); err != nil {
+		return fmt.Errorf("failed to confirm mfa: %w", err)
+	}
+
+	if _, err := tx.Exec(`DELETE FROM mfa_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+
+	if _, err := tx.Exec(`INSERT INTO mfa_recovery_codes (user_id, code_hash, created_at)
+		SELECT $1, code_hash, $2 FROM UNNEST($3::text[]) AS code_hash`, userID, now, pq.Array(recoveryCodeHashes)); err != nil {
+		return fmt.Errorf("failed to create recovery codes: %w", err)
+	}
+
+	if _, err := tx.Exec(`UPDATE users SET mfa_enabled = true, updated_at = $1, version = version + 1 WHERE id = $2`, now, userID); err != nil {
+		return fmt.Errorf("failed to enable mfa: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// UseStep menandai time step TOTP sebagai sudah dipakai. Return false jika step ini
+// (atau step yang lebih baru) sudah pernah dipakai, mencegah kode yang sama dipakai dua kali.
+func (mr *MFARepository) UseStep(userID int, step int64) (bool, error) {
+	result, err := mr.db.Exec(`UPDATE user_mfa SET last_used_step = $1, updated_at = $2
+		WHERE user_id = $3 AND confirmed_at IS NOT NULL AND last_used_step < $1`, step, time.Now(), userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to use mfa step: %w", err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	return rowsAffected == 1, nil
+}
+
+// UseRecoveryCode memakai recovery code (sekali pakai)
+func (mr *MFARepository) UseRecoveryCode(userID int, codeHash string) (bool, error) {
+	result, err := mr.db.Exec(`UPDATE mfa_recovery_codes SET used_at = $1
+		WHERE user_id = $2 AND code_hash = $3 AND used_at IS NULL`, time.Now(), userID, codeHash)
+	if err != nil {
+		return false, fmt.Errorf("failed to use recovery code: %w", err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	return rowsAffected == 1, nil
+}
+
+// Reset menghapus secret dan recovery code lalu menonaktifkan MFA
+func (mr *MFARepository) Reset(userID int) error {
+	tx, err := mr.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM user_mfa WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to delete mfa: %w", err)
+	}
+
+	if _, err := tx.Exec(`DELETE FROM mfa_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+
+	result, err := tx.Exec(`UPDATE users SET mfa_enabled = false, updated_at = $1, version = version + 1 WHERE id = $2`, time.Now(), userID)
+	if err != nil {
+		return fmt.Errorf("failed to disable mfa: %w", err)
+	}
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		return ErrUserNotFound
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// MFAChallenge login yang sudah lolos password dan menunggu kode MFA
+type MFAChallenge struct {
+	UserID     int    `json:"user_id"`
+	DeviceName string `json:"device_name"`
+}
+
+// MFAChallengeStore menyimpan challenge login MFA di Redis, yang disimpan hanya hash token
+type MFAChallengeStore struct {
+	redis *database.RedisClient
+	ttl   time.Duration
+}
+
+// NewMFAChallengeStore membuat instance baru MFAChallengeStore
+func NewMFAChallengeStore(redis *database.RedisClient, ttl time.Duration) *MFAChallengeStore {
+	return &MFAChallengeStore{
+		redis: redis,
+		ttl:   ttl,
+	}
+}
+
+// Create membuat challenge baru dan mengembalikan token untuk client
+func (ms *MFAChallengeStore) Create(challenge MFAChallenge) (*MFAChallengeResponse, error) {
+	token, err := utils.GenerateSecureToken(32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate mfa challenge token: %w", err)
+	}
+
+	if err := ms.redis.SetWithExpiration(ms.key(token), challenge, ms.ttl); err != nil {
+		return nil, err
+	}
+
+	return &MFAChallengeResponse{
+		MFARequired: true,
+		MFAToken:    token,
+		ExpiresIn:   int(ms.ttl.Seconds()),
+	}, nil
+}
+
+// Get mengambil challenge dari token
+func (ms *MFAChallengeStore) Get(token string) (*MFAChallenge, error) {
+	var challenge MFAChallenge
+	if err := ms.redis.GetAndUnmarshal(ms.key(token), &challenge); err != nil {
+		if errors.Is(err, database.ErrKeyNotFound) {
+			return nil, ErrMFAChallengeInvalid
+		}
+		return nil, err
+	}
+	return &challenge, nil
+}
+
+// RecordFailure mencatat kode salah, challenge dibatalkan setelah terlalu banyak percobaan
+func (ms *MFAChallengeStore) RecordFailure(token string) error {
+	attempts, err := ms.redis.IncrementCounter(ms.key(token)+":attempts", ms.ttl)
+	if err != nil {
+		return err
+	}
+
+	if attempts >= mfaChallengeMaxAttempts {
+		return ms.Delete(token)
+	}
+	return nil
+}
+
+// Delete menghapus challenge setelah dipakai atau dibatalkan
+func (ms *MFAChallengeStore) Delete(token string) error {
+	if err := ms.redis.Delete(ms.key(token)); err != nil {
+		return err
+	}
+	return ms.redis.Delete(ms.key(token) + ":attempts")
+}
+
+// Helper function untuk key Redis challenge
+func (ms *MFAChallengeStore) key(token string) string {
+	return "mfa_challenge:" + utils.HashToken(token)
+}
+
+// MFAService untuk business logic enrollment dan verifikasi MFA
+type MFAService struct {
+	repo        *MFARepository
+	userRepo    *UserRepository
+	authService *AuthService
+	challenges  *MFAChallengeStore
+	secretBox   *utils.SecretBox
+	events      *SecurityEventRepository
+	issuer      string
+	logger      *logrus.Logger
+}
+
+// NewMFAService membuat instance baru MFAService
+func NewMFAService(repo *MFARepository, userRepo *UserRepository, authService *AuthService, challenges *MFAChallengeStore,
+	secretBox *utils.SecretBox, events *SecurityEventRepository, issuer string, logger *logrus.Logger) *MFAService {
+	return &MFAService{
+		repo:        repo,
+		userRepo:    userRepo,
+		authService: authService,
+		challenges:  challenges,
+		secretBox:   secretBox,
+		events:      events,
+		issuer:      issuer,
+		logger:      logger,
+	}
+}
+
+// Enroll membuat secret TOTP baru. MFA belum aktif sampai user mengkonfirmasi dengan kode pertama.
+func (ms *MFAService) Enroll(userID int) (*MFAEnrollment, error) {
+	user, err := ms.userRepo.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.MFAEnabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	encrypted, err := ms.secretBox.Encrypt(secret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt mfa secret: %w", err)
+	}
+
+	if err := ms.repo.SaveSecret(userID, encrypted); err != nil {
+		return nil, err
+	}
+
+	return &MFAEnrollment{
+		Secret:     secret,
+		OTPAuthURI: utils.TOTPURI(ms.issuer, user.Email, secret),
+	}, nil
+}
+
+// Confirm mengaktifkan MFA jika kode dari authenticator app benar, lalu membuat recovery code
+func (ms *MFAService) Confirm(userID int, code string, metadata ClientMetadata) ([]string, error) {
+	user, err := ms.userRepo.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.MFAEnabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	mfa, err := ms.repo.GetMFA(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := ms.secretBox.Decrypt(mfa.SecretEncrypted)
+	if err != nil {
+		return nil, err
+	}
+
+	valid, step, err := utils.ValidateTOTPCode(secret, code, time.Now(), mfaCodeSkew)
+	if err != nil {
+		return nil, err
+	}
+	if !valid {
+		return nil, ErrMFACodeInvalid
+	}
+
+	codes, hashes, err := generateRecoveryCodes(mfaRecoveryCodeCount)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ms.repo.Enable(userID, step, hashes); err != nil {
+		return nil, err
+	}
+
+	if err := ms.events.Record(SecurityEventMFAEnabled, userID, &userID, metadata, nil); err != nil {
+		ms.logger.WithError(err).WithField("user_id", userID).Warn("Failed to record mfa enabled event")
+	}
+
+	return codes, nil
+}
+
+// Verify langkah kedua login: cek kode TOTP atau recovery code lalu buat token pair.
+// Kode salah dihitung sebagai login gagal supaya ikut kena throttle dan lockout.
+func (ms *MFAService) Verify(req VerifyMFARequest, metadata ClientMetadata) (*TokenPair, error) {
+	user, challenge, err := ms.completeChallenge(req, metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	metadata.DeviceName = challenge.DeviceName
+	return ms.authService.startSession(user, metadata, true)
+}
+
+// VerifyCookieSession sama seperti Verify, tapi yang dibuat adalah cookie session (login dari POST /auth/login/session)
+func (ms *MFAService) VerifyCookieSession(req VerifyMFARequest, metadata ClientMetadata) (*utils.Session, error) {
+	user, _, err := ms.completeChallenge(req, metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	return ms.authService.startCookieSession(user, metadata, true)
+}
+
+// Helper function untuk memverifikasi kode MFA dari challenge login, challenge dihapus jika kode benar
+func (ms *MFAService) completeChallenge(req VerifyMFARequest, metadata ClientMetadata) (*User, *MFAChallenge, error) {
+	challenge, err := ms.challenges.Get(req.MFAToken)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	user, err := ms.userRepo.GetUserByID(challenge.UserID)
+	if err != nil || !user.IsActive || !user.MFAEnabled {
+		_ = ms.challenges.Delete(req.MFAToken)
+		return nil, nil, ErrMFAChallengeInvalid
+	}
+
+	throttleKey := loginThrottleKey(user, "")
+	if err := ms.authService.throttler.Check(throttleKey, metadata.IPAddress); err != nil {
+		return nil, nil, err
+	}
+
+	valid, usedRecoveryCode, err := ms.checkCode(user.ID, req)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !valid {
+		if err := ms.challenges.RecordFailure(req.MFAToken); err != nil {
+			ms.logger.WithError(err).WithField("user_id", user.ID).Warn("Failed to record mfa failure")
+		}
+		ms.authService.recordLoginFailure(user, throttleKey, metadata)
+		return nil, nil, ErrMFACodeInvalid
+	}
+
+	if err := ms.challenges.Delete(req.MFAToken); err != nil {
+		return nil, nil, err
+	}
+	ms.authService.clearLoginFailures(user, throttleKey)
+
+	if usedRecoveryCode {
+		if err := ms.events.Record(SecurityEventMFARecoveryCodeUsed, user.ID, &user.ID, metadata, nil); err != nil {
+			ms.logger.WithError(err).WithField("user_id", user.ID).Warn("Failed to record recovery code event")
+		}
+	}
+
+	return user, challenge, nil
+}
+
+// Reset menonaktifkan MFA user (oleh admin), misalnya ketika user kehilangan device
+func (ms *MFAService) Reset(userID int, actorID *int, metadata ClientMetadata) error {
+	if err := ms.repo.Reset(userID); err != nil {
+		return err
+	}
+
+	if err := ms.events.Record(SecurityEventMFAReset, userID, actorID, metadata, nil); err != nil {
+		ms.logger.WithError(err).WithField("user_id", userID).Warn("Failed to record mfa reset event")
+	}
+	return nil
+}
+
+// Helper function untuk mengecek kode TOTP atau recovery code
+func (ms *MFAService) checkCode(userID int, req VerifyMFARequest) (valid bool, usedRecoveryCode bool, err error) {
+	if req.RecoveryCode != "" {
+		valid, err := ms.repo.UseRecoveryCode(userID, utils.HashToken(normalizeRecoveryCode(req.RecoveryCode)))
+		return valid, valid, err
+	}
+
+	mfa, err := ms.repo.GetMFA(userID)
+	if err != nil {
+		return false, false, err
+	}
+
+	secret, err := ms.secretBox.Decrypt(mfa.SecretEncrypted)
+	if err != nil {
+		return false, false, err
+	}
+
+	valid, step, err := utils.ValidateTOTPCode(secret, req.Code, time.Now(), mfaCodeSkew)
+	if err != nil || !valid {
+		return false, false, err
+	}
+
+	valid, err = ms.repo.UseStep(userID, step)
+	return valid, false, err
+}
+
+// Helper function untuk membuat recovery code (format xxxxx-xxxxx) beserta hash-nya
+func generateRecoveryCodes(count int) (codes []string, hashes []string, err error) {
+	encoding := base32.StdEncoding.WithPadding(base32.NoPadding)
+	for i := 0; i < count; i++ {
+		buffer := make([]byte, 7)
+		if _, err := rand.Read(buffer); err != nil {
+			return nil, nil, fmt.Errorf("failed to generate recovery code: %w", err)
+		}
+
+		raw := strings.ToLower(encoding.EncodeToString(buffer))[:10]
+		codes = append(codes, raw[:5]+"-"+raw[5:])
+		hashes = append(hashes, utils.HashToken(raw))
+	}
+	return codes, hashes, nil
+}
+
+// Helper function supaya recovery code bisa diketik dengan atau tanpa tanda hubung
+func normalizeRecoveryCode(code string) string {
+	return strings.NewReplacer("-", "", " ", "").Replace(strings.ToLower(strings.TrimSpace(code)))
+}
+
+// MFAHandler untuk HTTP handlers MFA
+type MFAHandler struct {
+	service      *MFAService
+	cookieConfig middleware.SessionCookieConfig
+	logger       *logrus.Logger
+}
+
+// NewMFAHandler membuat instance baru MFAHandler
+func NewMFAHandler(service *MFAService, cookieConfig middleware.SessionCookieConfig, logger *logrus.Logger) *MFAHandler {
+	return &MFAHandler{
+		service:      service,
+		cookieConfig: cookieConfig,
+		logger:       logger,
+	}
+}
+
+// Enroll handler untuk POST /auth/mfa/enroll
+func (mh *MFAHandler) Enroll(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	enrollment, err := mh.service.Enroll(userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrUserNotFound):
+			utils.NotFoundResponse(c, "User not found")
+		case errors.Is(err, ErrMFAAlreadyEnabled):
+			utils.ConflictResponse(c, "Failed to enroll MFA", err.Error())
+		default:
+			mh.logger.WithError(err).WithField("user_id", userID).Error("Failed to enroll MFA")
+			utils.InternalServerErrorResponse(c, "Failed to enroll MFA")
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, "Scan the secret with an authenticator app, then confirm with a code", enrollment)
+}
+
+// Confirm handler untuk POST /auth/mfa/confirm
+func (mh *MFAHandler) Confirm(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req ConfirmMFARequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestResponse(c, "Invalid JSON format", err.Error())
+		return
+	}
+
+	codes, err := mh.service.Confirm(userID, req.Code, clientMetadata(c))
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrUserNotFound):
+			utils.NotFoundResponse(c, "User not found")
+		case errors.Is(err, ErrMFAAlreadyEnabled):
+			utils.ConflictResponse(c, "Failed to confirm MFA", err.Error())
+		case errors.Is(err, ErrMFANotEnrolled), errors.Is(err, ErrMFACodeInvalid):
+			utils.BadRequestResponse(c, "Failed to confirm MFA", err.Error())
+		default:
+			mh.logger.WithError(err).WithField("user_id", userID).Error("Failed to confirm MFA")
+			utils.InternalServerErrorResponse(c, "Failed to confirm MFA")
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, "MFA enabled, store the recovery codes in a safe place", RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// Verify handler untuk POST /auth/mfa/verify
+func (mh *MFAHandler) Verify(c *gin.Context) {
+	var req VerifyMFARequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestResponse(c, "Invalid JSON format", err.Error())
+		return
+	}
+
+	tokens, err := mh.service.Verify(req, clientMetadata(c))
+	if err != nil {
+		mh.verifyErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, "Login successful", tokens)
+}
+
+// VerifySession handler untuk POST /auth/mfa/verify/session, langkah kedua dari POST /auth/login/session
+func (mh *MFAHandler) VerifySession(c *gin.Context) {
+	var req VerifyMFARequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestResponse(c, "Invalid JSON format", err.Error())
+		return
+	}
+
+	session, err := mh.service.VerifyCookieSession(req, clientMetadata(c))
+	if err != nil {
+		mh.verifyErrorResponse(c, err)
+		return
+	}
+
+	cookieSessionResponse(c, mh.cookieConfig, session, mh.service.authService.CookieSessionIdleTimeout())
+}
+
+// Helper function untuk response error verifikasi MFA
+func (mh *MFAHandler) verifyErrorResponse(c *gin.Context, err error) {
+	var throttled *LoginThrottledError
+	switch {
+	case errors.As(err, &throttled):
+		loginThrottledResponse(c, throttled)
+	case errors.Is(err, ErrMFAChallengeInvalid), errors.Is(err, ErrMFACodeInvalid):
+		utils.UnauthorizedResponse(c, err.Error())
+	default:
+		mh.logger.WithError(err).Error("Failed to verify MFA")
+		utils.InternalServerErrorResponse(c, "Failed to verify MFA")
+	}
+}
+
+// Reset handler untuk POST /users/:id/mfa/reset (admin)
+func (mh *MFAHandler) Reset(c *gin.Context) {
+	id, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+
+	if err := mh.service.Reset(id, currentActorID(c), clientMetadata(c)); err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			utils.NotFoundResponse(c, "User not found")
+			return
+		}
+		mh.logger.WithError(err).WithField("user_id", id).Error("Failed to reset MFA")
+		utils.InternalServerErrorResponse(c, "Failed to reset MFA")
+		return
+	}
+
+	utils.SuccessResponse(c, "MFA reset successfully", nil)
+}
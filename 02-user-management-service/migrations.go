@@ -0,0 +1,393 @@
+package main
+
+import "github.com/jizak1/Microservices-Golang/shared/database"
+
+// migrations schema user service. Jangan ubah migration yang sudah dirilis,
+// tambahkan migration baru dengan versi berikutnya.
+var migrations = []database.Migration{
+	{
+		Version: 1,
+		Name:    "create_users",
+		Up: `
+			CREATE TABLE IF NOT EXISTS users (
+				id SERIAL PRIMARY KEY,
+				username VARCHAR(50) UNIQUE NOT NULL,
+				email VARCHAR(100) UNIQUE NOT NULL,
+				full_name VARCHAR(100) NOT NULL,
+				password_hash VARCHAR(255) NOT NULL,
+				is_active BOOLEAN DEFAULT true,
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+			);
+
+			CREATE INDEX IF NOT EXISTS idx_users_email ON users(email);
+			CREATE INDEX IF NOT EXISTS idx_users_username ON users(username);
+			CREATE INDEX IF NOT EXISTS idx_users_is_active ON users(is_active);`,
+	},
+	{
+		Version: 2,
+		Name:    "add_users_role",
+		Up:      `ALTER TABLE users ADD COLUMN IF NOT EXISTS role VARCHAR(50) NOT NULL DEFAULT 'user';`,
+	},
+	{
+		Version: 3,
+		Name:    "create_refresh_tokens",
+		Up: `
+			CREATE TABLE IF NOT EXISTS refresh_tokens (
+				id BIGSERIAL PRIMARY KEY,
+				user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+				family_id VARCHAR(64) NOT NULL,
+				token_hash VARCHAR(64) UNIQUE NOT NULL,
+				device_name VARCHAR(100) NOT NULL DEFAULT '',
+				user_agent TEXT NOT NULL DEFAULT '',
+				ip_address VARCHAR(64) NOT NULL DEFAULT '',
+				expires_at TIMESTAMP NOT NULL,
+				rotated_at TIMESTAMP,
+				revoked_at TIMESTAMP,
+				revoked_reason VARCHAR(50),
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+			);
+
+			CREATE INDEX IF NOT EXISTS idx_refresh_tokens_user_id ON refresh_tokens(user_id);
+			CREATE INDEX IF NOT EXISTS idx_refresh_tokens_family_id ON refresh_tokens(family_id);`,
+	},
+	{
+		Version: 4,
+		Name:    "create_email_verification",
+		Up: `
+			ALTER TABLE users ADD COLUMN IF NOT EXISTS email_verified_at TIMESTAMP;
+
+			CREATE TABLE IF NOT EXISTS email_verification_tokens (
+				id BIGSERIAL PRIMARY KEY,
+				user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+				email VARCHAR(100) NOT NULL,
+				token_hash VARCHAR(64) UNIQUE NOT NULL,
+				expires_at TIMESTAMP NOT NULL,
+				used_at TIMESTAMP,
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+			);
+
+			CREATE INDEX IF NOT EXISTS idx_email_verification_tokens_user_id ON email_verification_tokens(user_id);`,
+	},
+	{
+		Version: 5,
+		Name:    "create_password_reset_tokens",
+		Up: `
+			CREATE TABLE IF NOT EXISTS password_reset_tokens (
+				id BIGSERIAL PRIMARY KEY,
+				user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+				token_hash VARCHAR(64) UNIQUE NOT NULL,
+				requested_ip VARCHAR(64) NOT NULL DEFAULT '',
+				expires_at TIMESTAMP NOT NULL,
+				used_at TIMESTAMP,
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+			);
+
+			CREATE INDEX IF NOT EXISTS idx_password_reset_tokens_user_id ON password_reset_tokens(user_id);`,
+	},
+	{
+		Version: 6,
+		Name:    "add_users_login_lockout",
+		Up: `
+			ALTER TABLE users ADD COLUMN IF NOT EXISTS failed_login_attempts INTEGER NOT NULL DEFAULT 0;
+			ALTER TABLE users ADD COLUMN IF NOT EXISTS last_failed_login_at TIMESTAMP;
+			ALTER TABLE users ADD COLUMN IF NOT EXISTS locked_at TIMESTAMP;
+			ALTER TABLE users ADD COLUMN IF NOT EXISTS locked_until TIMESTAMP;`,
+	},
+	{
+		Version: 7,
+		Name:    "create_security_events",
+		Up: `
+			CREATE TABLE IF NOT EXISTS security_events (
+				id BIGSERIAL PRIMARY KEY,
+				user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+				actor_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+				event_type VARCHAR(50) NOT NULL,
+				ip_address VARCHAR(64) NOT NULL DEFAULT '',
+				user_agent TEXT NOT NULL DEFAULT '',
+				metadata JSONB NOT NULL DEFAULT '{}',
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+			);
+
+			CREATE INDEX IF NOT EXISTS idx_security_events_user_id ON security_events(user_id, created_at);
+			CREATE INDEX IF NOT EXISTS idx_security_events_event_type ON security_events(event_type);`,
+	},
+	{
+		Version: 8,
+		Name:    "create_user_mfa",
+		Up: `
+			ALTER TABLE users ADD COLUMN IF NOT EXISTS mfa_enabled BOOLEAN NOT NULL DEFAULT false;
+
+			CREATE TABLE IF NOT EXISTS user_mfa (
+				user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+				secret_encrypted TEXT NOT NULL,
+				confirmed_at TIMESTAMP,
+				last_used_step BIGINT NOT NULL DEFAULT 0,
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+			);
+
+			CREATE TABLE IF NOT EXISTS mfa_recovery_codes (
+				id BIGSERIAL PRIMARY KEY,
+				user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+				code_hash VARCHAR(64) NOT NULL,
+				used_at TIMESTAMP,
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+			);
+
+			CREATE INDEX IF NOT EXISTS idx_mfa_recovery_codes_user_id ON mfa_recovery_codes(user_id);`,
+	},
+	{
+		Version: 9,
+		Name:    "create_roles_and_permissions",
+		Up: `
+			CREATE TABLE IF NOT EXISTS roles (
+				id SERIAL PRIMARY KEY,
+				name VARCHAR(50) UNIQUE NOT NULL,
+				description VARCHAR(255) NOT NULL DEFAULT '',
+				is_system BOOLEAN NOT NULL DEFAULT false,
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+			);
+
+			CREATE TABLE IF NOT EXISTS permissions (
+				id SERIAL PRIMARY KEY,
+				name VARCHAR(100) UNIQUE NOT NULL,
+				description VARCHAR(255) NOT NULL DEFAULT '',
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+			);
+
+			CREATE TABLE IF NOT EXISTS role_permissions (
+				role_id INTEGER NOT NULL REFERENCES roles(id) ON DELETE CASCADE,
+				permission_id INTEGER NOT NULL REFERENCES permissions(id) ON DELETE CASCADE,
+				PRIMARY KEY (role_id, permission_id)
+			);
+
+			CREATE TABLE IF NOT EXISTS user_roles (
+				user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+				role_id INTEGER NOT NULL REFERENCES roles(id) ON DELETE CASCADE,
+				granted_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+				granted_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				PRIMARY KEY (user_id, role_id)
+			);
+
+			CREATE INDEX IF NOT EXISTS idx_user_roles_role_id ON user_roles(role_id);
+
+			INSERT INTO roles (name, description, is_system) VALUES
+				('admin', 'Full access to all resources', true),
+				('user', 'Default role for registered users', true)
+			ON CONFLICT (name) DO NOTHING;
+
+			INSERT INTO permissions (name, description) VALUES
+				('*', 'All permissions'),
+				('users:read', 'Read user profiles'),
+				('users:update', 'Update any user'),
+				('users:delete', 'Delete any user'),
+				('roles:manage', 'Manage roles and role assignments')
+			ON CONFLICT (name) DO NOTHING;
+
+			INSERT INTO role_permissions (role_id, permission_id)
+			SELECT r.id, p.id FROM roles r JOIN permissions p
+				ON (r.name = 'admin' AND p.name = '*') OR (r.name = 'user' AND p.name = 'users:read')
+			ON CONFLICT DO NOTHING;
+
+			-- Pindahkan role lama dari kolom users.role ke user_roles
+			DO $$
+			BEGIN
+				IF EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name = 'users' AND column_name = 'role') THEN
+					INSERT INTO user_roles (user_id, role_id)
+					SELECT u.id, r.id FROM users u JOIN roles r ON r.name = u.role
+					ON CONFLICT DO NOTHING;
+				END IF;
+			END $$;
+
+			ALTER TABLE users DROP COLUMN IF EXISTS role;`,
+	},
+	{
+		Version: 10,
+		Name:    "add_users_soft_delete",
+		Up: `
+			ALTER TABLE users ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+
+			-- Unique hanya untuk user yang tidak dihapus, policy reserve/release dicek di aplikasi
+			ALTER TABLE users DROP CONSTRAINT IF EXISTS users_username_key;
+			ALTER TABLE users DROP CONSTRAINT IF EXISTS users_email_key;
+			CREATE UNIQUE INDEX IF NOT EXISTS idx_users_username_active ON users(username) WHERE deleted_at IS NULL;
+			CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email_active ON users(email) WHERE deleted_at IS NULL;
+
+			CREATE INDEX IF NOT EXISTS idx_users_deleted_at ON users(deleted_at) WHERE deleted_at IS NOT NULL;`,
+	},
+	{
+		Version: 11,
+		Name:    "create_user_erasures_and_event_outbox",
+		Up: `
+			ALTER TABLE users ADD COLUMN IF NOT EXISTS erased_at TIMESTAMP;
+
+			CREATE TABLE IF NOT EXISTS user_erasures (
+				id BIGSERIAL PRIMARY KEY,
+				user_id INTEGER NOT NULL REFERENCES users(id),
+				requested_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+				reason VARCHAR(255) NOT NULL,
+				summary JSONB NOT NULL DEFAULT '{}',
+				event_id VARCHAR(64) NOT NULL DEFAULT '',
+				erased_at TIMESTAMP NOT NULL
+			);
+
+			CREATE INDEX IF NOT EXISTS idx_user_erasures_user_id ON user_erasures(user_id);
+
+			CREATE TABLE IF NOT EXISTS event_outbox (
+				id BIGSERIAL PRIMARY KEY,
+				event_id VARCHAR(64) UNIQUE NOT NULL,
+				event_type VARCHAR(100) NOT NULL,
+				payload JSONB NOT NULL,
+				attempts INTEGER NOT NULL DEFAULT 0,
+				last_error TEXT,
+				published_at TIMESTAMP,
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+			);
+
+			CREATE INDEX IF NOT EXISTS idx_event_outbox_pending ON event_outbox(id) WHERE published_at IS NULL;`,
+	},
+	{
+		Version: 12,
+		Name:    "add_users_search_indexes",
+		Up: `
+			-- Trigram index untuk pencarian ILIKE prefix/substring di GET /users
+			CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+			CREATE INDEX IF NOT EXISTS idx_users_username_trgm ON users USING gin (username gin_trgm_ops);
+			CREATE INDEX IF NOT EXISTS idx_users_email_trgm ON users USING gin (email gin_trgm_ops);
+			CREATE INDEX IF NOT EXISTS idx_users_full_name_trgm ON users USING gin (full_name gin_trgm_ops);
+
+			-- Index untuk sorting dan filter tanggal, hanya user yang tidak dihapus (query default)
+			CREATE INDEX IF NOT EXISTS idx_users_active_created_at ON users(created_at, id) WHERE deleted_at IS NULL;
+			CREATE INDEX IF NOT EXISTS idx_users_active_updated_at ON users(updated_at, id) WHERE deleted_at IS NULL;
+			CREATE INDEX IF NOT EXISTS idx_users_active_full_name ON users(full_name, id) WHERE deleted_at IS NULL;
+			CREATE INDEX IF NOT EXISTS idx_users_is_active_created_at ON users(is_active, created_at) WHERE deleted_at IS NULL;`,
+	},
+	{
+		Version: 13,
+		Name:    "add_users_version",
+		Up: `
+			-- Version untuk optimistic concurrency (ETag/If-Match), naik setiap baris users diupdate
+			ALTER TABLE users ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;`,
+	},
+	{
+		Version: 14,
+		Name:    "create_user_import_jobs",
+		Up: `
+			CREATE TABLE IF NOT EXISTS user_import_jobs (
+				id SERIAL PRIMARY KEY,
+				format VARCHAR(10) NOT NULL,
+				dry_run BOOLEAN NOT NULL DEFAULT false,
+				status VARCHAR(20) NOT NULL,
+				total_rows INTEGER NOT NULL DEFAULT 0,
+				succeeded_rows INTEGER NOT NULL DEFAULT 0,
+				failed_rows INTEGER NOT NULL DEFAULT 0,
+				error TEXT,
+				created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+				created_at TIMESTAMP NOT NULL,
+				started_at TIMESTAMP,
+				finished_at TIMESTAMP
+			);
+
+			CREATE TABLE IF NOT EXISTS user_import_errors (
+				id BIGSERIAL PRIMARY KEY,
+				job_id INTEGER NOT NULL REFERENCES user_import_jobs(id) ON DELETE CASCADE,
+				line INTEGER NOT NULL,
+				message TEXT NOT NULL
+			);
+
+			CREATE INDEX IF NOT EXISTS idx_user_import_errors_job_id ON user_import_errors(job_id, line);`,
+	},
+	{
+		Version: 15,
+		Name:    "create_user_sessions",
+		Up: `
+			-- Satu sesi = satu refresh token family (id sesi = family_id)
+			CREATE TABLE IF NOT EXISTS user_sessions (
+				id VARCHAR(64) PRIMARY KEY,
+				user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+				device_name VARCHAR(100) NOT NULL DEFAULT '',
+				user_agent TEXT NOT NULL DEFAULT '',
+				ip_address VARCHAR(64) NOT NULL DEFAULT '',
+				created_at TIMESTAMP NOT NULL,
+				last_seen_at TIMESTAMP NOT NULL,
+				expires_at TIMESTAMP NOT NULL,
+				revoked_at TIMESTAMP,
+				revoked_reason VARCHAR(50)
+			);
+
+			CREATE INDEX IF NOT EXISTS idx_user_sessions_user_id ON user_sessions(user_id, last_seen_at);
+
+			-- Sesi dari refresh token yang masih aktif
+			INSERT INTO user_sessions (id, user_id, device_name, user_agent, ip_address, created_at, last_seen_at, expires_at)
+			SELECT t.family_id, t.user_id, t.device_name, t.user_agent, t.ip_address,
+				COALESCE((SELECT MIN(f.created_at) FROM refresh_tokens f WHERE f.family_id = t.family_id), NOW()),
+				COALESCE(t.created_at, NOW()), t.expires_at
+			FROM refresh_tokens t
+			WHERE t.rotated_at IS NULL AND t.revoked_at IS NULL AND t.expires_at > NOW()
+			ON CONFLICT (id) DO NOTHING;`,
+	},
+	{
+		Version: 16,
+		Name:    "create_api_keys",
+		Up: `
+			-- API key milik user, hanya hash SHA-256 yang disimpan
+			CREATE TABLE IF NOT EXISTS api_keys (
+				id SERIAL PRIMARY KEY,
+				user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+				name VARCHAR(100) NOT NULL,
+				prefix VARCHAR(20) NOT NULL,
+				key_hash VARCHAR(64) NOT NULL UNIQUE,
+				scopes TEXT[] NOT NULL DEFAULT '{}',
+				expires_at TIMESTAMP,
+				last_used_at TIMESTAMP,
+				created_at TIMESTAMP NOT NULL,
+				updated_at TIMESTAMP NOT NULL,
+				revoked_at TIMESTAMP,
+				revoked_reason VARCHAR(50)
+			);
+
+			CREATE INDEX IF NOT EXISTS idx_api_keys_user_id ON api_keys(user_id, created_at);`,
+	},
+	{
+		Version: 17,
+		Name:    "add_user_admin_permissions",
+		Up: `
+			-- Permission untuk endpoint admin yang sebelumnya hanya dicek lewat role admin
+			INSERT INTO permissions (name, description) VALUES
+				('users:import', 'Bulk import users'),
+				('users:export', 'Export user data, including GDPR exports of other users')
+			ON CONFLICT (name) DO NOTHING;`,
+	},
+	{
+		Version: 18,
+		Name:    "make_users_email_unique_case_insensitive",
+		Up: `
+			-- Login dan pengecekan email memakai LOWER(email), unique index juga harus case-insensitive.
+			-- Gagal jika masih ada user aktif dengan email yang hanya berbeda huruf besar/kecil.
+			DROP INDEX IF EXISTS idx_users_email_active;
+			CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email_active ON users (LOWER(email)) WHERE deleted_at IS NULL;`,
+	},
+	{
+		Version: 19,
+		Name:    "add_event_outbox_dead_letter",
+		Up: `
+			-- Event yang payload-nya rusak atau terus gagal dikirim tidak lagi menahan event setelahnya
+			ALTER TABLE event_outbox ADD COLUMN IF NOT EXISTS dead_lettered_at TIMESTAMP;
+
+			DROP INDEX IF EXISTS idx_event_outbox_pending;
+			CREATE INDEX IF NOT EXISTS idx_event_outbox_pending ON event_outbox(id)
+				WHERE published_at IS NULL AND dead_lettered_at IS NULL;
+			CREATE INDEX IF NOT EXISTS idx_event_outbox_dead_lettered ON event_outbox(dead_lettered_at)
+				WHERE dead_lettered_at IS NOT NULL;`,
+	},
+	{
+		Version: 20,
+		Name:    "add_user_sessions_mfa",
+		Up: `
+			-- Sesi yang login-nya melewati MFA, dipakai untuk claim amr saat refresh token
+			ALTER TABLE user_sessions ADD COLUMN IF NOT EXISTS mfa BOOLEAN NOT NULL DEFAULT false;`,
+	},
+}
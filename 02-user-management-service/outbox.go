@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jizak1/Microservices-Golang/shared/utils"
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+// outboxBatchSize jumlah event yang dikirim per putaran dispatcher
+const outboxBatchSize = 100
+
+// outboxEvent baris di tabel event_outbox
+type outboxEvent struct {
+	ID       int64           `db:"id"`
+	EventID  string          `db:"event_id"`
+	Payload  json.RawMessage `db:"payload"`
+	Attempts int             `db:"attempts"`
+}
+
+// EnqueueEvent menyimpan event ke tabel outbox di dalam transaksi yang sama dengan perubahan datanya,
+// supaya event tidak hilang walaupun publisher sedang tidak bisa dihubungi
+func EnqueueEvent(tx *sqlx.Tx, event utils.Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	query := `INSERT INTO event_outbox (event_id, event_type, payload, created_at) VALUES ($1, $2, $3, $4)`
+	if _, err := tx.Exec(query, event.ID, event.Type, string(payload), time.Now()); err != nil {
+		return fmt.Errorf("failed to enqueue event: %w", err)
+	}
+	return nil
+}
+
+// OutboxDispatcher mengirim event dari tabel outbox ke EventPublisher
+type OutboxDispatcher struct {
+	db          *sqlx.DB
+	publisher   utils.EventPublisher
+	maxAttempts int
+	logger      *logrus.Logger
+}
+
+// NewOutboxDispatcher membuat instance baru OutboxDispatcher
+func NewOutboxDispatcher(db *sqlx.DB, publisher utils.EventPublisher, maxAttempts int, logger *logrus.Logger) *OutboxDispatcher {
+	if maxAttempts <= 0 {
+		maxAttempts = 1
+	}
+
+	return &OutboxDispatcher{
+		db:          db,
+		publisher:   publisher,
+		maxAttempts: maxAttempts,
+		logger:      logger,
+	}
+}
+
+// DispatchPending mengirim event yang belum terkirim sesuai urutan. Baris dikunci dengan SKIP LOCKED
+// supaya beberapa instance tidak mengirim event yang sama. Pengiriman berhenti di event pertama yang gagal
+// agar urutan event tetap terjaga, event tersebut dicoba lagi di putaran berikutnya.
+// Event yang payload-nya tidak bisa dibaca, atau sudah gagal maxAttempts kali, dipindah ke dead letter
+// (dead_lettered_at diisi) supaya tidak menahan event setelahnya.
+func (od *OutboxDispatcher) DispatchPending() (int, error) {
+	tx, err := od.db.Beginx()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var pending []outboxEvent
+	query := `SELECT id, event_id, payload, attempts FROM event_outbox
+			  WHERE published_at IS NULL AND dead_lettered_at IS NULL
+			  ORDER BY id LIMIT $1 FOR UPDATE SKIP LOCKED`
+	if err := tx.Select(&pending, query, outboxBatchSize); err != nil {
+		return 0, fmt.Errorf("failed to get pending events: %w", err)
+	}
+
+	published := 0
+	for _, row := range pending {
+		var event utils.Event
+		if err := json.Unmarshal(row.Payload, &event); err != nil {
+			// Payload rusak tidak akan berhasil dikirim walaupun dicoba lagi
+			if err := od.deadLetter(tx, row, fmt.Errorf("failed to decode event: %w", err)); err != nil {
+				return published, err
+			}
+			continue
+		}
+
+		if err := od.publish(event); err != nil {
+			if row.Attempts+1 >= od.maxAttempts {
+				if err := od.deadLetter(tx, row, err); err != nil {
+					return published, err
+				}
+				continue
+			}
+
+			od.logger.WithError(err).WithFields(logrus.Fields{
+				"event_id": row.EventID,
+				"attempts": row.Attempts + 1,
+			}).Warn("Failed to publish outbox event")
+
+			if _, markErr := tx.Exec(`UPDATE event_outbox SET attempts = attempts + 1, last_error = $1 WHERE id = $2`,
+				err.Error(), row.ID); markErr != nil {
+				return published, fmt.Errorf("failed to mark event as failed: %w", markErr)
+			}
+			break
+		}
+
+		if _, err := tx.Exec(`UPDATE event_outbox SET published_at = $1, attempts = attempts + 1, last_error = NULL WHERE id = $2`,
+			time.Now(), row.ID); err != nil {
+			return published, fmt.Errorf("failed to mark event as published: %w", err)
+		}
+		published++
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return published, nil
+}
+
+// Run menjalankan DispatchPending setiap interval sampai context dibatalkan
+func (od *OutboxDispatcher) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := od.DispatchPending(); err != nil {
+			od.logger.WithError(err).Error("Failed to dispatch outbox events")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Helper function untuk mengirim satu event dari outbox
+func (od *OutboxDispatcher) publish(event utils.Event) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	return od.publisher.Publish(ctx, event)
+}
+
+// Helper function untuk memindahkan event ke dead letter, event tetap disimpan untuk diperiksa manual
+func (od *OutboxDispatcher) deadLetter(tx *sqlx.Tx, row outboxEvent, cause error) error {
+	od.logger.WithError(cause).WithFields(logrus.Fields{
+		"event_id": row.EventID,
+		"attempts": row.Attempts + 1,
+	}).Error("Outbox event moved to dead letter")
+
+	if _, err := tx.Exec(`UPDATE event_outbox SET attempts = attempts + 1, last_error = $1, dead_lettered_at = $2 WHERE id = $3`,
+		cause.Error(), time.Now(), row.ID); err != nil {
+		return fmt.Errorf("failed to dead letter event: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,386 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jizak1/Microservices-Golang/shared/utils"
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	// ErrPasswordResetTokenInvalid token reset tidak ada, sudah dipakai, atau expired
+	ErrPasswordResetTokenInvalid = errors.New("password reset token is invalid or expired")
+	// ErrCurrentPasswordIncorrect password lama salah saat ganti password
+	ErrCurrentPasswordIncorrect = errors.New("current password is incorrect")
+	// ErrPasswordUnchanged password baru sama dengan password lama
+	ErrPasswordUnchanged = errors.New("new password must be different from the current password")
+)
+
+// ForgotPasswordRequest untuk request body lupa password
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// ResetPasswordRequest untuk request body reset password dengan token dari email
+type ResetPasswordRequest struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required"`
+}
+
+// ChangePasswordRequest untuk request body ganti password oleh user yang sudah login
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required"`
+}
+
+// PasswordResetRepository untuk database operations token reset password
+type PasswordResetRepository struct {
+	db     *sqlx.DB
+	logger *logrus.Logger
+}
+
+// NewPasswordResetRepository membuat instance baru PasswordResetRepository
+func NewPasswordResetRepository(db *sqlx.DB, logger *logrus.Logger) *PasswordResetRepository {
+	return &PasswordResetRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// CreateToken menyimpan hash token reset baru. Token lama yang belum dipakai langsung tidak berlaku.
+func (pr *PasswordResetRepository) CreateToken(userID int, tokenHash, requestedIP string, expiresAt time.Time) error {
+	tx, err := pr.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`UPDATE password_reset_tokens SET used_at = $1
+		WHERE user_id = $2 AND used_at IS NULL`, time.Now(), userID); err != nil {
+		return fmt.Errorf("failed to invalidate password reset tokens: %w", err)
+	}
+
+	if _, err := tx.Exec(`INSERT INTO password_reset_tokens (user_id, token_hash, requested_ip, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5)`, userID, tokenHash, requestedIP, expiresAt, time.Now()); err != nil {
+		pr.logger.WithError(err).WithField("user_id", userID).Error("Failed to create password reset token")
+		return fmt.Errorf("failed to create password reset token: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// GetTokenUserID mengambil user pemilik token reset yang masih berlaku tanpa memakai token
+func (pr *PasswordResetRepository) GetTokenUserID(tokenHash string) (int, error) {
+	var userID int
+	query := `SELECT user_id FROM password_reset_tokens
+			  WHERE token_hash = $1 AND used_at IS NULL AND expires_at > $2`
+	if err := pr.db.Get(&userID, query, tokenHash, time.Now()); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, ErrPasswordResetTokenInvalid
+		}
+		return 0, fmt.Errorf("failed to get password reset token: %w", err)
+	}
+	return userID, nil
+}
+
+// ResetPassword memakai token (sekali pakai) dan mengganti password dalam satu transaction.
+// Link reset dikirim ke email, jadi email user sekaligus dianggap terverifikasi.
+func (pr *PasswordResetRepository) ResetPassword(tokenHash, passwordHash string) (int, error) {
+	tx, err := pr.db.Beginx()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var token struct {
+		ID        int64      `db:"id"`
+		UserID    int        `db:"user_id"`
+		ExpiresAt time.Time  `db:"expires_at"`
+		UsedAt    *time.Time `db:"used_at"`
+	}
+
+	query := `SELECT id, user_id, expires_at, used_at
+			  FROM password_reset_tokens WHERE token_hash = $1 FOR UPDATE`
+	if err := tx.Get(&token, query, tokenHash); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, ErrPasswordResetTokenInvalid
+		}
+		return 0, fmt.Errorf("failed to get password reset token: %w", err)
+	}
+
+	now := time.Now()
+	if token.UsedAt != nil || now.After(token.ExpiresAt) {
+		return 0, ErrPasswordResetTokenInvalid
+	}
+
+	// Semua token reset user di-invalidate, bukan hanya yang dipakai
+	if _, err := tx.Exec(`UPDATE password_reset_tokens SET used_at = $1
+		WHERE user_id = $2 AND used_at IS NULL`, now, token.UserID); err != nil {
+		return 0, fmt.Errorf("failed to consume password reset token: %w", err)
+	}
+
+	result, err := tx.Exec(`UPDATE users SET password_hash = $1, updated_at = $2, version = version + 1,
+		email_verified_at = COALESCE(email_verified_at, $2)
+		WHERE id = $3 AND is_active = true`, passwordHash, now, token.UserID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to update password: %w", err)
+	}
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		return 0, ErrPasswordResetTokenInvalid
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return token.UserID, nil
+}
+
+// PasswordService untuk business logic lupa, reset, dan ganti password
+type PasswordService struct {
+	resetRepo   *PasswordResetRepository
+	userRepo    *UserRepository
+	authService *AuthService
+	hasher      *utils.PasswordHasher
+	policy      *utils.PasswordPolicy
+	mailer      utils.Mailer
+	baseURL     string
+	resetTTL    time.Duration
+	logger      *logrus.Logger
+}
+
+// NewPasswordService membuat instance baru PasswordService
+func NewPasswordService(resetRepo *PasswordResetRepository, userRepo *UserRepository, authService *AuthService,
+	hasher *utils.PasswordHasher, policy *utils.PasswordPolicy, mailer utils.Mailer, baseURL string,
+	resetTTL time.Duration, logger *logrus.Logger) *PasswordService {
+	return &PasswordService{
+		resetRepo:   resetRepo,
+		userRepo:    userRepo,
+		authService: authService,
+		hasher:      hasher,
+		policy:      policy,
+		mailer:      mailer,
+		baseURL:     baseURL,
+		resetTTL:    resetTTL,
+		logger:      logger,
+	}
+}
+
+// Forgot mengirim link reset password di background dan langsung return, supaya waktu respon sama
+// untuk email yang terdaftar maupun tidak (endpoint tidak bisa dipakai untuk mengecek email).
+// Error hanya di-log.
+func (ps *PasswordService) Forgot(email, requestedIP string) {
+	go func() {
+		if err := ps.sendResetLink(email, requestedIP); err != nil {
+			ps.logger.WithError(err).Error("Failed to process forgot password")
+		}
+	}()
+}
+
+// Helper function untuk membuat token reset dan mengirim email, email yang tidak terdaftar dilewati
+func (ps *PasswordService) sendResetLink(email, requestedIP string) error {
+	user, err := ps.userRepo.GetUserByEmail(email)
+	if err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	if !user.IsActive {
+		return nil
+	}
+
+	token, err := utils.GenerateSecureToken(32)
+	if err != nil {
+		return fmt.Errorf("failed to generate password reset token: %w", err)
+	}
+
+	if err := ps.resetRepo.CreateToken(user.ID, utils.HashToken(token), requestedIP, time.Now().Add(ps.resetTTL)); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	message := utils.EmailMessage{
+		To:      []string{user.Email},
+		Subject: "Reset your password",
+		TextBody: fmt.Sprintf("Hi %s,\n\nWe received a request to reset your password. Open the link below to choose a new one:\n\n"+
+			"%s/reset-password?token=%s\n\nThis link expires in %s and can only be used once. "+
+			"If you did not request a password reset, you can ignore this email.\n",
+			user.FullName, ps.baseURL, token, ps.resetTTL),
+	}
+
+	if err := ps.mailer.Send(ctx, message); err != nil {
+		ps.logger.WithError(err).WithField("user_id", user.ID).Error("Failed to send password reset email")
+		return err
+	}
+
+	ps.logger.WithField("user_id", user.ID).Info("Password reset email sent")
+	return nil
+}
+
+// Reset mengganti password dengan token dari email lalu me-revoke semua session user
+func (ps *PasswordService) Reset(token, newPassword string) error {
+	tokenHash := utils.HashToken(token)
+
+	// User diambil dulu supaya password baru bisa dicek terhadap username dan email
+	tokenUserID, err := ps.resetRepo.GetTokenUserID(tokenHash)
+	if err != nil {
+		return err
+	}
+
+	user, err := ps.userRepo.GetUserByID(tokenUserID)
+	if err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			return ErrPasswordResetTokenInvalid
+		}
+		return err
+	}
+
+	if err := ps.policy.Validate(newPassword, user.Username, user.Email); err != nil {
+		return err
+	}
+
+	passwordHash, err := ps.hasher.Hash(newPassword)
+	if err != nil {
+		return err
+	}
+
+	userID, err := ps.resetRepo.ResetPassword(tokenHash, passwordHash)
+	if err != nil {
+		return err
+	}
+
+	return ps.authService.RevokeUserAccess(userID, "password_reset")
+}
+
+// Change mengganti password user yang sudah login, password lama wajib benar.
+// Semua session di-revoke, termasuk session yang dipakai untuk request ini.
+func (ps *PasswordService) Change(userID int, currentPassword, newPassword string) error {
+	user, err := ps.userRepo.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+
+	valid, _, err := ps.hasher.Verify(currentPassword, user.Password)
+	if err != nil {
+		return err
+	}
+	if !valid {
+		return ErrCurrentPasswordIncorrect
+	}
+
+	if currentPassword == newPassword {
+		return ErrPasswordUnchanged
+	}
+
+	if err := ps.policy.Validate(newPassword, user.Username, user.Email); err != nil {
+		return err
+	}
+
+	passwordHash, err := ps.hasher.Hash(newPassword)
+	if err != nil {
+		return err
+	}
+
+	if err := ps.userRepo.UpdateUser(userID, map[string]interface{}{"password_hash": passwordHash}); err != nil {
+		return err
+	}
+
+	return ps.authService.RevokeUserAccess(userID, "password_changed")
+}
+
+// PasswordHandler untuk HTTP handlers password
+type PasswordHandler struct {
+	service *PasswordService
+	logger  *logrus.Logger
+}
+
+// NewPasswordHandler membuat instance baru PasswordHandler
+func NewPasswordHandler(service *PasswordService, logger *logrus.Logger) *PasswordHandler {
+	return &PasswordHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// ForgotPassword handler untuk POST /auth/password/forgot
+func (ph *PasswordHandler) ForgotPassword(c *gin.Context) {
+	var req ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestResponse(c, "Invalid JSON format", err.Error())
+		return
+	}
+
+	ph.service.Forgot(req.Email, c.ClientIP())
+	utils.SuccessResponse(c, "If the email is registered, a password reset link has been sent", nil)
+}
+
+// ResetPassword handler untuk POST /auth/password/reset
+func (ph *PasswordHandler) ResetPassword(c *gin.Context) {
+	var req ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestResponse(c, "Invalid JSON format", err.Error())
+		return
+	}
+
+	if err := ph.service.Reset(req.Token, req.NewPassword); err != nil {
+		var policyErr *utils.PasswordPolicyError
+		if errors.As(err, &policyErr) {
+			utils.ValidationErrorResponse(c, policyErr.Violations)
+			return
+		}
+		if errors.Is(err, ErrPasswordResetTokenInvalid) {
+			utils.BadRequestResponse(c, "Invalid password reset token", err.Error())
+			return
+		}
+		ph.logger.WithError(err).Error("Failed to reset password")
+		utils.InternalServerErrorResponse(c, "Failed to reset password")
+		return
+	}
+
+	utils.SuccessResponse(c, "Password has been reset, please login again", nil)
+}
+
+// ChangePassword handler untuk PUT /users/:id/password
+func (ph *PasswordHandler) ChangePassword(c *gin.Context) {
+	id, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+
+	var req ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestResponse(c, "Invalid JSON format", err.Error())
+		return
+	}
+
+	if err := ph.service.Change(id, req.CurrentPassword, req.NewPassword); err != nil {
+		var policyErr *utils.PasswordPolicyError
+		switch {
+		case errors.As(err, &policyErr):
+			utils.ValidationErrorResponse(c, policyErr.Violations)
+		case errors.Is(err, ErrUserNotFound):
+			utils.NotFoundResponse(c, "User not found")
+		case errors.Is(err, ErrCurrentPasswordIncorrect), errors.Is(err, ErrPasswordUnchanged):
+			utils.BadRequestResponse(c, "Failed to change password", err.Error())
+		default:
+			ph.logger.WithError(err).WithField("user_id", id).Error("Failed to change password")
+			utils.InternalServerErrorResponse(c, "Failed to change password")
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, "Password changed successfully, please login again", nil)
+}
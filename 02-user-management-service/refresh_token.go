@@ -0,0 +1,197 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	// ErrRefreshTokenInvalid token tidak ditemukan, sudah expired atau sudah di-revoke
+	ErrRefreshTokenInvalid = errors.New("refresh token is invalid or expired")
+	// ErrRefreshTokenReused token yang sudah di-rotate dipakai lagi, indikasi token dicuri
+	ErrRefreshTokenReused = errors.New("refresh token reuse detected")
+)
+
+// RefreshToken model untuk refresh token yang disimpan dalam bentuk hash
+type RefreshToken struct {
+	ID            int64      `json:"id" db:"id"`
+	UserID        int        `json:"user_id" db:"user_id"`
+	FamilyID      string     `json:"family_id" db:"family_id"`
+	TokenHash     string     `json:"-" db:"token_hash"`
+	DeviceName    string     `json:"device_name" db:"device_name"`
+	UserAgent     string     `json:"user_agent" db:"user_agent"`
+	IPAddress     string     `json:"ip_address" db:"ip_address"`
+	ExpiresAt     time.Time  `json:"expires_at" db:"expires_at"`
+	RotatedAt     *time.Time `json:"rotated_at,omitempty" db:"rotated_at"`
+	RevokedAt     *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+	RevokedReason *string    `json:"revoked_reason,omitempty" db:"revoked_reason"`
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+}
+
+// ClientMetadata informasi device yang melakukan login
+type ClientMetadata struct {
+	DeviceName string
+	UserAgent  string
+	IPAddress  string
+}
+
+// RefreshTokenRepository untuk database operations refresh token
+type RefreshTokenRepository struct {
+	db     *sqlx.DB
+	logger *logrus.Logger
+}
+
+// NewRefreshTokenRepository membuat instance baru RefreshTokenRepository
+func NewRefreshTokenRepository(db *sqlx.DB, logger *logrus.Logger) *RefreshTokenRepository {
+	return &RefreshTokenRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// RotateToken menandai token lama sebagai rotated dan menyimpan token baru di family yang sama.
+// Jika token lama sudah pernah di-rotate atau di-revoke, seluruh family di-revoke.
+func (rr *RefreshTokenRepository) RotateToken(tokenHash string, newToken *RefreshToken) (*RefreshToken, error) {
+	tx, err := rr.db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var current RefreshToken
+	query := `SELECT id, user_id, family_id, token_hash, device_name, user_agent, ip_address,
+			  expires_at, rotated_at, revoked_at, revoked_reason, created_at
+			  FROM refresh_tokens WHERE token_hash = $1 FOR UPDATE`
+
+	if err := tx.Get(&current, query, tokenHash); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrRefreshTokenInvalid
+		}
+		return nil, fmt.Errorf("failed to get refresh token: %w", err)
+	}
+
+	now := time.Now()
+
+	if current.RotatedAt != nil || current.RevokedAt != nil {
+		// Reuse detection: token lama dipakai lagi, revoke seluruh family
+		if _, err := tx.Exec(`UPDATE refresh_tokens SET revoked_at = $1, revoked_reason = $2
+			WHERE family_id = $3 AND revoked_at IS NULL`, now, "reuse_detected", current.FamilyID); err != nil {
+			return nil, fmt.Errorf("failed to revoke token family: %w", err)
+		}
+		if _, err := tx.Exec(`UPDATE user_sessions SET revoked_at = $1, revoked_reason = $2
+			WHERE id = $3 AND revoked_at IS NULL`, now, "reuse_detected", current.FamilyID); err != nil {
+			return nil, fmt.Errorf("failed to revoke session: %w", err)
+		}
+		if err := tx.Commit(); err != nil {
+			return nil, fmt.Errorf("failed to commit transaction: %w", err)
+		}
+
+		rr.logger.WithFields(logrus.Fields{
+			"user_id":   current.UserID,
+			"family_id": current.FamilyID,
+		}).Warn("Refresh token reuse detected, token family revoked")
+		return &current, ErrRefreshTokenReused
+	}
+
+	if now.After(current.ExpiresAt) {
+		return nil, ErrRefreshTokenInvalid
+	}
+
+	if _, err := tx.Exec(`UPDATE refresh_tokens SET rotated_at = $1 WHERE id = $2`, now, current.ID); err != nil {
+		return nil, fmt.Errorf("failed to rotate refresh token: %w", err)
+	}
+
+	newToken.UserID = current.UserID
+	newToken.FamilyID = current.FamilyID
+	if newToken.DeviceName == "" {
+		newToken.DeviceName = current.DeviceName
+	}
+
+	insertQuery := `
+		INSERT INTO refresh_tokens (user_id, family_id, token_hash, device_name, user_agent, ip_address, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id`
+
+	err = tx.QueryRow(insertQuery, newToken.UserID, newToken.FamilyID, newToken.TokenHash, newToken.DeviceName,
+		newToken.UserAgent, newToken.IPAddress, newToken.ExpiresAt, newToken.CreatedAt).Scan(&newToken.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create refresh token: %w", err)
+	}
+
+	// Setiap refresh dihitung sebagai aktivitas terakhir sesi
+	if _, err := tx.Exec(`UPDATE user_sessions SET last_seen_at = $1, user_agent = $2, ip_address = $3, expires_at = $4
+		WHERE id = $5`, now, newToken.UserAgent, newToken.IPAddress, newToken.ExpiresAt, newToken.FamilyID); err != nil {
+		return nil, fmt.Errorf("failed to update session: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return &current, nil
+}
+
+// GetTokenByHash mengambil refresh token berdasarkan hash
+func (rr *RefreshTokenRepository) GetTokenByHash(tokenHash string) (*RefreshToken, error) {
+	var token RefreshToken
+	query := `SELECT id, user_id, family_id, token_hash, device_name, user_agent, ip_address,
+			  expires_at, rotated_at, revoked_at, revoked_reason, created_at
+			  FROM refresh_tokens WHERE token_hash = $1`
+
+	if err := rr.db.Get(&token, query, tokenHash); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrRefreshTokenInvalid
+		}
+		return nil, fmt.Errorf("failed to get refresh token: %w", err)
+	}
+
+	return &token, nil
+}
+
+// RevokeFamily me-revoke semua token dalam satu family (satu login di satu device) beserta sesinya
+func (rr *RefreshTokenRepository) RevokeFamily(familyID, reason string) error {
+	err := rr.revoke(`family_id = $3`, `id = $3`, reason, familyID)
+	if err != nil {
+		rr.logger.WithError(err).WithField("family_id", familyID).Error("Failed to revoke token family")
+		return fmt.Errorf("failed to revoke token family: %w", err)
+	}
+
+	return nil
+}
+
+// RevokeAllForUser me-revoke semua refresh token dan sesi milik user
+func (rr *RefreshTokenRepository) RevokeAllForUser(userID int, reason string) error {
+	err := rr.revoke(`user_id = $3`, `user_id = $3`, reason, userID)
+	if err != nil {
+		rr.logger.WithError(err).WithField("user_id", userID).Error("Failed to revoke user tokens")
+		return fmt.Errorf("failed to revoke user tokens: %w", err)
+	}
+
+	return nil
+}
+
+// Helper function untuk revoke refresh token dan sesi dalam satu transaksi supaya keduanya selalu sinkron
+func (rr *RefreshTokenRepository) revoke(tokenCondition, sessionCondition, reason string, key interface{}) error {
+	tx, err := rr.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	if _, err := tx.Exec(`UPDATE refresh_tokens SET revoked_at = $1, revoked_reason = $2
+		WHERE revoked_at IS NULL AND `+tokenCondition, now, reason, key); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`UPDATE user_sessions SET revoked_at = $1, revoked_reason = $2
+		WHERE revoked_at IS NULL AND `+sessionCondition, now, reason, key); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
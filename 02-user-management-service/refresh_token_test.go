@@ -0,0 +1,214 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+// fakeRefreshTokenDB database/sql driver minimal untuk RotateToken: SELECT ... FOR UPDATE mengembalikan token,
+// INSERT ... RETURNING id mengembalikan id baru, dan semua statement dicatat supaya bisa dicek
+type fakeRefreshTokenDB struct {
+	token      *RefreshToken // nil berarti token tidak ditemukan
+	statements []string
+	committed  bool
+}
+
+func (f *fakeRefreshTokenDB) Connect(context.Context) (driver.Conn, error) {
+	return &fakeConn{db: f}, nil
+}
+func (f *fakeRefreshTokenDB) Driver() driver.Driver { return nil }
+
+// executed mengecek apakah ada statement yang mengandung fragment SQL
+func (f *fakeRefreshTokenDB) executed(fragment string) bool {
+	for _, statement := range f.statements {
+		if strings.Contains(statement, fragment) {
+			return true
+		}
+	}
+	return false
+}
+
+type fakeConn struct{ db *fakeRefreshTokenDB }
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{db: c.db, query: query}, nil
+}
+func (c *fakeConn) Close() error              { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error) { return &fakeTx{db: c.db}, nil }
+
+type fakeTx struct{ db *fakeRefreshTokenDB }
+
+func (t *fakeTx) Commit() error   { t.db.committed = true; return nil }
+func (t *fakeTx) Rollback() error { return nil }
+
+type fakeStmt struct {
+	db    *fakeRefreshTokenDB
+	query string
+}
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.db.statements = append(s.db.statements, s.query)
+	return driver.RowsAffected(1), nil
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.db.statements = append(s.db.statements, s.query)
+
+	if strings.Contains(s.query, "RETURNING id") {
+		return &fakeRows{columns: []string{"id"}, values: [][]driver.Value{{int64(42)}}}, nil
+	}
+
+	rows := &fakeRows{columns: []string{"id", "user_id", "family_id", "token_hash", "device_name", "user_agent",
+		"ip_address", "expires_at", "rotated_at", "revoked_at", "revoked_reason", "created_at"}}
+	if token := s.db.token; token != nil {
+		rows.values = append(rows.values, []driver.Value{token.ID, int64(token.UserID), token.FamilyID, token.TokenHash,
+			token.DeviceName, token.UserAgent, token.IPAddress, token.ExpiresAt, nullableTime(token.RotatedAt),
+			nullableTime(token.RevokedAt), nil, token.CreatedAt})
+	}
+	return rows, nil
+}
+
+type fakeRows struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
+
+// Helper function untuk kolom timestamp yang boleh NULL
+func nullableTime(value *time.Time) driver.Value {
+	if value == nil {
+		return nil
+	}
+	return *value
+}
+
+func TestRotateToken(t *testing.T) {
+	now := time.Now()
+	earlier := now.Add(-time.Minute)
+
+	tests := []struct {
+		name         string
+		token        *RefreshToken
+		wantErr      error
+		wantCurrent  bool
+		wantCommit   bool
+		wantExecuted []string
+		wantSkipped  []string
+	}{
+		{
+			name:         "active token is rotated",
+			token:        &RefreshToken{ID: 7, UserID: 1, FamilyID: "family", DeviceName: "laptop", ExpiresAt: now.Add(time.Hour)},
+			wantCurrent:  true,
+			wantCommit:   true,
+			wantExecuted: []string{"SET rotated_at", "INSERT INTO refresh_tokens", "UPDATE user_sessions SET last_seen_at"},
+			wantSkipped:  []string{"reuse_detected"},
+		},
+		{
+			name:         "rotated token is reuse",
+			token:        &RefreshToken{ID: 7, UserID: 1, FamilyID: "family", ExpiresAt: now.Add(time.Hour), RotatedAt: &earlier},
+			wantErr:      ErrRefreshTokenReused,
+			wantCurrent:  true,
+			wantCommit:   true,
+			wantExecuted: []string{"UPDATE refresh_tokens SET revoked_at", "UPDATE user_sessions SET revoked_at"},
+			wantSkipped:  []string{"SET rotated_at", "INSERT INTO refresh_tokens"},
+		},
+		{
+			name:         "revoked token is reuse",
+			token:        &RefreshToken{ID: 7, UserID: 1, FamilyID: "family", ExpiresAt: now.Add(time.Hour), RevokedAt: &earlier},
+			wantErr:      ErrRefreshTokenReused,
+			wantCurrent:  true,
+			wantCommit:   true,
+			wantExecuted: []string{"UPDATE refresh_tokens SET revoked_at", "UPDATE user_sessions SET revoked_at"},
+			wantSkipped:  []string{"SET rotated_at", "INSERT INTO refresh_tokens"},
+		},
+		{
+			// Token yang sudah di-rotate tetap dianggap reuse walaupun sudah expired
+			name:         "expired rotated token is reuse",
+			token:        &RefreshToken{ID: 7, UserID: 1, FamilyID: "family", ExpiresAt: earlier, RotatedAt: &earlier},
+			wantErr:      ErrRefreshTokenReused,
+			wantCurrent:  true,
+			wantCommit:   true,
+			wantExecuted: []string{"UPDATE refresh_tokens SET revoked_at", "UPDATE user_sessions SET revoked_at"},
+		},
+		{
+			name:        "expired token",
+			token:       &RefreshToken{ID: 7, UserID: 1, FamilyID: "family", ExpiresAt: earlier},
+			wantErr:     ErrRefreshTokenInvalid,
+			wantSkipped: []string{"SET rotated_at", "INSERT INTO refresh_tokens", "SET revoked_at"},
+		},
+		{
+			name:        "unknown token",
+			wantErr:     ErrRefreshTokenInvalid,
+			wantSkipped: []string{"SET rotated_at", "INSERT INTO refresh_tokens", "SET revoked_at"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeRefreshTokenDB{token: tt.token}
+			logger := logrus.New()
+			logger.SetOutput(io.Discard)
+			repo := NewRefreshTokenRepository(sqlx.NewDb(sql.OpenDB(fake), "postgres"), logger)
+
+			newToken := &RefreshToken{TokenHash: "new-hash", ExpiresAt: now.Add(2 * time.Hour), CreatedAt: now}
+			current, err := repo.RotateToken("old-hash", newToken)
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("RotateToken() error = %v, want %v", err, tt.wantErr)
+			}
+			// Auth service butuh token lama saat reuse untuk me-revoke access token sesi tersebut
+			if (current != nil) != tt.wantCurrent {
+				t.Fatalf("RotateToken() current = %+v, want present %v", current, tt.wantCurrent)
+			}
+			if current != nil && current.FamilyID != tt.token.FamilyID {
+				t.Errorf("current.FamilyID = %q, want %q", current.FamilyID, tt.token.FamilyID)
+			}
+			if fake.committed != tt.wantCommit {
+				t.Errorf("committed = %v, want %v", fake.committed, tt.wantCommit)
+			}
+			for _, fragment := range tt.wantExecuted {
+				if !fake.executed(fragment) {
+					t.Errorf("expected statement containing %q, got %v", fragment, fake.statements)
+				}
+			}
+			for _, fragment := range tt.wantSkipped {
+				if fake.executed(fragment) {
+					t.Errorf("unexpected statement containing %q", fragment)
+				}
+			}
+
+			if tt.wantErr == nil {
+				// Token baru masuk ke family yang sama dan mewarisi nama device
+				if newToken.ID != 42 || newToken.UserID != tt.token.UserID || newToken.FamilyID != tt.token.FamilyID {
+					t.Errorf("new token = %+v, want id 42 in family %q", newToken, tt.token.FamilyID)
+				}
+				if newToken.DeviceName != tt.token.DeviceName {
+					t.Errorf("new token DeviceName = %q, want %q", newToken.DeviceName, tt.token.DeviceName)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,740 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jizak1/Microservices-Golang/shared/middleware"
+	"github.com/jizak1/Microservices-Golang/shared/utils"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+)
+
+// adminRole role dengan semua permission, minimal harus ada satu user dengan role ini
+const adminRole = "admin"
+
+// Jenis security event perubahan role
+const (
+	SecurityEventRoleGranted = "role_granted"
+	SecurityEventRoleRevoked = "role_revoked"
+)
+
+// rolePermissionsQuery dipakai Authorizer untuk memuat mapping role -> permission dari database
+const rolePermissionsQuery = `SELECT r.name AS role, p.name AS permission
+	FROM role_permissions rp
+	JOIN roles r ON r.id = rp.role_id
+	JOIN permissions p ON p.id = rp.permission_id`
+
+var (
+	// ErrRoleNotFound role tidak ada
+	ErrRoleNotFound = errors.New("role not found")
+	// ErrRoleExists nama role sudah dipakai
+	ErrRoleExists = errors.New("role already exists")
+	// ErrInvalidRoleName nama role tidak sesuai format
+	ErrInvalidRoleName = errors.New("role name must start with a letter and contain only lowercase letters, digits, '_' or '-'")
+	// ErrUnknownPermission permission tidak ada di katalog
+	ErrUnknownPermission = errors.New("unknown permission")
+	// ErrSystemRole role bawaan (admin, user) tidak boleh dihapus
+	ErrSystemRole = errors.New("system roles cannot be deleted")
+	// ErrRoleInUse role masih dipakai user
+	ErrRoleInUse = errors.New("role is still assigned to users")
+	// ErrLastAdmin role admin tidak boleh dicabut dari admin terakhir
+	ErrLastAdmin = errors.New("cannot revoke admin role from the last admin")
+	// ErrPrivilegedRole role dengan permission * hanya boleh dikelola oleh user yang juga punya permission *
+	ErrPrivilegedRole = errors.New("only users with all permissions (*) can manage privileged roles")
+)
+
+var roleNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_-]*$`)
+
+// Role model untuk database
+type Role struct {
+	ID          int            `json:"id" db:"id"`
+	Name        string         `json:"name" db:"name"`
+	Description string         `json:"description" db:"description"`
+	IsSystem    bool           `json:"is_system" db:"is_system"` // Role bawaan dari migration
+	Permissions pq.StringArray `json:"permissions" db:"permissions"`
+	CreatedAt   time.Time      `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at" db:"updated_at"`
+}
+
+// Permission model untuk database, katalog permission diisi lewat migration
+type Permission struct {
+	ID          int    `json:"id" db:"id"`
+	Name        string `json:"name" db:"name"`
+	Description string `json:"description" db:"description"`
+}
+
+// CreateRoleRequest untuk request body create role
+type CreateRoleRequest struct {
+	Name        string   `json:"name" binding:"required,min=2,max=50"`
+	Description string   `json:"description" binding:"max=255"`
+	Permissions []string `json:"permissions"`
+}
+
+// UpdateRoleRequest untuk request body update role, field yang kosong tidak diubah
+type UpdateRoleRequest struct {
+	Description *string   `json:"description,omitempty" binding:"omitempty,max=255"`
+	Permissions *[]string `json:"permissions,omitempty"`
+}
+
+// AssignRoleRequest untuk request body memberi role ke user
+type AssignRoleRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
+// roleColumns kolom yang dibaca ke struct Role
+const roleColumns = `r.id, r.name, r.description, r.is_system, r.created_at, r.updated_at,
+	ARRAY(SELECT p.name FROM role_permissions rp JOIN permissions p ON p.id = rp.permission_id
+		  WHERE rp.role_id = r.id ORDER BY p.name) AS permissions`
+
+// RoleRepository untuk database operations role dan permission
+type RoleRepository struct {
+	db     *sqlx.DB
+	logger *logrus.Logger
+}
+
+// NewRoleRepository membuat instance baru RoleRepository
+func NewRoleRepository(db *sqlx.DB, logger *logrus.Logger) *RoleRepository {
+	return &RoleRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// ListRoles mengambil semua role beserta permission-nya
+func (rr *RoleRepository) ListRoles() ([]Role, error) {
+	roles := []Role{}
+	query := `SELECT ` + roleColumns + ` FROM roles r ORDER BY r.name`
+
+	if err := rr.db.Select(&roles, query); err != nil {
+		return nil, fmt.Errorf("failed to get roles: %w", err)
+	}
+	return roles, nil
+}
+
+// GetRoleByID mengambil role berdasarkan ID
+func (rr *RoleRepository) GetRoleByID(id int) (*Role, error) {
+	return rr.getRole(`SELECT `+roleColumns+` FROM roles r WHERE r.id = $1`, id)
+}
+
+// GetRoleByName mengambil role berdasarkan nama
+func (rr *RoleRepository) GetRoleByName(name string) (*Role, error) {
+	return rr.getRole(`SELECT `+roleColumns+` FROM roles r WHERE r.name = $1`, name)
+}
+
+// CreateRole menyimpan role baru beserta permission-nya
+func (rr *RoleRepository) CreateRole(role *Role, permissions []string) error {
+	tx, err := rr.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	err = tx.QueryRow(`INSERT INTO roles (name, description, is_system, created_at, updated_at)
+		VALUES ($1, $2, false, $3, $3) RETURNING id`, role.Name, role.Description, now).Scan(&role.ID)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return ErrRoleExists
+		}
+		return fmt.Errorf("failed to create role: %w", err)
+	}
+
+	if err := setRolePermissions(tx, role.ID, permissions); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	rr.logger.WithField("role", role.Name).Info("Role created successfully")
+	return nil
+}
+
+// UpdateRole mengubah deskripsi dan/atau mengganti semua permission role
+func (rr *RoleRepository) UpdateRole(id int, description *string, permissions *[]string) error {
+	tx, err := rr.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`UPDATE roles SET description = COALESCE($1, description), updated_at = $2 WHERE id = $3`,
+		description, time.Now(), id)
+	if err != nil {
+		return fmt.Errorf("failed to update role: %w", err)
+	}
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		return ErrRoleNotFound
+	}
+
+	if permissions != nil {
+		if err := setRolePermissions(tx, id, *permissions); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// DeleteRole menghapus role yang bukan role bawaan dan tidak dipakai user manapun
+func (rr *RoleRepository) DeleteRole(id int) error {
+	role, err := rr.GetRoleByID(id)
+	if err != nil {
+		return err
+	}
+	if role.IsSystem {
+		return ErrSystemRole
+	}
+
+	count, err := rr.CountUsersWithRole(id)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrRoleInUse
+	}
+
+	if _, err := rr.db.Exec(`DELETE FROM roles WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("failed to delete role: %w", err)
+	}
+
+	rr.logger.WithField("role", role.Name).Info("Role deleted successfully")
+	return nil
+}
+
+// ListPermissions mengambil katalog permission
+func (rr *RoleRepository) ListPermissions() ([]Permission, error) {
+	permissions := []Permission{}
+	if err := rr.db.Select(&permissions, `SELECT id, name, description FROM permissions ORDER BY name`); err != nil {
+		return nil, fmt.Errorf("failed to get permissions: %w", err)
+	}
+	return permissions, nil
+}
+
+// AssignRole memberi role ke user. Return false jika user sudah punya role tersebut.
+func (rr *RoleRepository) AssignRole(userID, roleID int, grantedBy *int) (bool, error) {
+	result, err := rr.db.Exec(`INSERT INTO user_roles (user_id, role_id, granted_by, granted_at)
+		VALUES ($1, $2, $3, $4) ON CONFLICT (user_id, role_id) DO NOTHING`, userID, roleID, grantedBy, time.Now())
+	if err != nil {
+		return false, fmt.Errorf("failed to assign role: %w", err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	return rowsAffected == 1, nil
+}
+
+// RevokeRole mencabut role dari user. Return false jika user memang tidak punya role tersebut.
+// Jika keepLast true, role tidak boleh dicabut dari pemegang terakhirnya (ErrLastAdmin). Semua baris
+// pemegang role dikunci dalam transaksi yang sama, jadi dua revoke bersamaan tidak bisa menghabiskan role.
+func (rr *RoleRepository) RevokeRole(userID, roleID int, keepLast bool) (bool, error) {
+	tx, err := rr.db.Beginx()
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if keepLast {
+		holders := []int{}
+		if err := tx.Select(&holders, `SELECT ur.user_id FROM user_roles ur JOIN users u ON u.id = ur.user_id
+			WHERE ur.role_id = $1 AND u.deleted_at IS NULL
+			FOR UPDATE OF ur`, roleID); err != nil {
+			return false, fmt.Errorf("failed to lock role users: %w", err)
+		}
+		hasRole := false
+		for _, holder := range holders {
+			hasRole = hasRole || holder == userID
+		}
+		if hasRole && len(holders) <= 1 {
+			return false, ErrLastAdmin
+		}
+	}
+
+	result, err := tx.Exec(`DELETE FROM user_roles WHERE user_id = $1 AND role_id = $2`, userID, roleID)
+	if err != nil {
+		return false, fmt.Errorf("failed to revoke role: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	return rowsAffected == 1, nil
+}
+
+// CountUsersWithRole menghitung user (yang tidak dihapus) yang punya role tertentu
+func (rr *RoleRepository) CountUsersWithRole(roleID int) (int, error) {
+	var count int
+	query := `SELECT COUNT(*) FROM user_roles ur JOIN users u ON u.id = ur.user_id
+			  WHERE ur.role_id = $1 AND u.deleted_at IS NULL`
+	if err := rr.db.Get(&count, query, roleID); err != nil {
+		return 0, fmt.Errorf("failed to count role users: %w", err)
+	}
+	return count, nil
+}
+
+// Helper function untuk query satu role
+func (rr *RoleRepository) getRole(query string, arg interface{}) (*Role, error) {
+	var role Role
+	if err := rr.db.Get(&role, query, arg); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrRoleNotFound
+		}
+		return nil, fmt.Errorf("failed to get role: %w", err)
+	}
+	return &role, nil
+}
+
+// Helper function untuk mengganti semua permission role, permission harus ada di katalog
+func setRolePermissions(tx *sqlx.Tx, roleID int, permissions []string) error {
+	names := uniqueStrings(permissions)
+
+	var known []string
+	if err := tx.Select(&known, `SELECT name FROM permissions WHERE name = ANY($1)`, pq.Array(names)); err != nil {
+		return fmt.Errorf("failed to check permissions: %w", err)
+	}
+	if len(known) != len(names) {
+		var unknown []string
+		for _, name := range names {
+			if !containsName(known, name) {
+				unknown = append(unknown, name)
+			}
+		}
+		return fmt.Errorf("%w: %s", ErrUnknownPermission, strings.Join(unknown, ", "))
+	}
+
+	if _, err := tx.Exec(`DELETE FROM role_permissions WHERE role_id = $1`, roleID); err != nil {
+		return fmt.Errorf("failed to clear role permissions: %w", err)
+	}
+
+	if _, err := tx.Exec(`INSERT INTO role_permissions (role_id, permission_id)
+		SELECT $1, id FROM permissions WHERE name = ANY($2)`, roleID, pq.Array(names)); err != nil {
+		return fmt.Errorf("failed to set role permissions: %w", err)
+	}
+	return nil
+}
+
+// RoleService untuk business logic role dan permission
+type RoleService struct {
+	repo        *RoleRepository
+	userRepo    *UserRepository
+	authService *AuthService
+	authorizer  *utils.Authorizer
+	events      *SecurityEventRepository
+	logger      *logrus.Logger
+}
+
+// NewRoleService membuat instance baru RoleService
+func NewRoleService(repo *RoleRepository, userRepo *UserRepository, authService *AuthService, authorizer *utils.Authorizer,
+	events *SecurityEventRepository, logger *logrus.Logger) *RoleService {
+	return &RoleService{
+		repo:        repo,
+		userRepo:    userRepo,
+		authService: authService,
+		authorizer:  authorizer,
+		events:      events,
+		logger:      logger,
+	}
+}
+
+// ListRoles mengambil semua role
+func (rs *RoleService) ListRoles() ([]Role, error) {
+	return rs.repo.ListRoles()
+}
+
+// ListPermissions mengambil katalog permission
+func (rs *RoleService) ListPermissions() ([]Permission, error) {
+	return rs.repo.ListPermissions()
+}
+
+// CreateRole membuat role baru. actorRoles adalah role user yang melakukan perubahan.
+func (rs *RoleService) CreateRole(req CreateRoleRequest, actorRoles []string) (*Role, error) {
+	if !roleNamePattern.MatchString(req.Name) {
+		return nil, ErrInvalidRoleName
+	}
+	if containsName(req.Permissions, utils.PermissionWildcard) {
+		if err := rs.requireAllPermissions(actorRoles); err != nil {
+			return nil, err
+		}
+	}
+
+	role := &Role{Name: req.Name, Description: req.Description}
+	if err := rs.repo.CreateRole(role, req.Permissions); err != nil {
+		return nil, err
+	}
+
+	rs.reloadPermissions()
+	return rs.repo.GetRoleByID(role.ID)
+}
+
+// UpdateRole mengubah role. Role dengan permission *, atau perubahan yang menambahkan *,
+// hanya boleh dilakukan oleh user yang punya permission *.
+func (rs *RoleService) UpdateRole(id int, req UpdateRoleRequest, actorRoles []string) (*Role, error) {
+	role, err := rs.repo.GetRoleByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if isPrivilegedRole(role) || (req.Permissions != nil && containsName(*req.Permissions, utils.PermissionWildcard)) {
+		if err := rs.requireAllPermissions(actorRoles); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := rs.repo.UpdateRole(id, req.Description, req.Permissions); err != nil {
+		return nil, err
+	}
+
+	rs.reloadPermissions()
+	return rs.repo.GetRoleByID(id)
+}
+
+// DeleteRole menghapus role
+func (rs *RoleService) DeleteRole(id int) error {
+	if err := rs.repo.DeleteRole(id); err != nil {
+		return err
+	}
+
+	rs.reloadPermissions()
+	return nil
+}
+
+// UserRoles mengambil role efektif user
+func (rs *RoleService) UserRoles(userID int) ([]string, error) {
+	user, err := rs.userRepo.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	return user.Roles, nil
+}
+
+// AssignRole memberi role ke user. Access token lama di-revoke supaya role baru langsung
+// dipakai di token berikutnya (client cukup refresh, tidak perlu login ulang).
+// Role admin (dan role lain dengan permission *) hanya boleh diberikan oleh user yang punya permission *,
+// supaya pemegang roles:manage tidak bisa menjadikan dirinya admin.
+func (rs *RoleService) AssignRole(userID int, roleName string, actorID *int, actorRoles []string, metadata ClientMetadata) ([]string, error) {
+	role, err := rs.repo.GetRoleByName(roleName)
+	if err != nil {
+		return nil, err
+	}
+	if isPrivilegedRole(role) {
+		if err := rs.requireAllPermissions(actorRoles); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := rs.userRepo.GetUserByID(userID); err != nil {
+		return nil, err
+	}
+
+	granted, err := rs.repo.AssignRole(userID, role.ID, actorID)
+	if err != nil {
+		return nil, err
+	}
+
+	if granted {
+		rs.afterRoleChange(SecurityEventRoleGranted, userID, role.Name, actorID, metadata)
+	}
+	return rs.UserRoles(userID)
+}
+
+// RevokeRole mencabut role dari user. Seperti AssignRole, role dengan permission * hanya boleh
+// dicabut oleh user yang punya permission *.
+func (rs *RoleService) RevokeRole(userID int, roleName string, actorID *int, actorRoles []string, metadata ClientMetadata) ([]string, error) {
+	role, err := rs.repo.GetRoleByName(roleName)
+	if err != nil {
+		return nil, err
+	}
+	if isPrivilegedRole(role) {
+		if err := rs.requireAllPermissions(actorRoles); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := rs.userRepo.GetUserByID(userID); err != nil {
+		return nil, err
+	}
+
+	revoked, err := rs.repo.RevokeRole(userID, role.ID, role.Name == adminRole)
+	if err != nil {
+		return nil, err
+	}
+
+	if revoked {
+		rs.afterRoleChange(SecurityEventRoleRevoked, userID, role.Name, actorID, metadata)
+	}
+	return rs.UserRoles(userID)
+}
+
+// Helper function untuk revoke access token dan mencatat security event setelah role user berubah
+func (rs *RoleService) afterRoleChange(eventType string, userID int, roleName string, actorID *int, metadata ClientMetadata) {
+	if err := rs.authService.RevokeAccessTokens(userID); err != nil {
+		rs.logger.WithError(err).WithField("user_id", userID).Warn("Failed to revoke access tokens after role change")
+	}
+
+	if err := rs.events.Record(eventType, userID, actorID, metadata, map[string]interface{}{"role": roleName}); err != nil {
+		rs.logger.WithError(err).WithField("user_id", userID).Warn("Failed to record role change event")
+	}
+}
+
+// Helper function untuk mengecek apakah actor punya permission * dari salah satu role-nya
+func (rs *RoleService) requireAllPermissions(actorRoles []string) error {
+	for _, role := range actorRoles {
+		if rs.authorizer.HasPermission(role, utils.PermissionWildcard) {
+			return nil
+		}
+	}
+	return ErrPrivilegedRole
+}
+
+// Helper function untuk mengecek apakah role memberi semua permission
+func isPrivilegedRole(role *Role) bool {
+	return role.Name == adminRole || containsName(role.Permissions, utils.PermissionWildcard)
+}
+
+// Helper function untuk memuat ulang mapping permission setelah role berubah
+func (rs *RoleService) reloadPermissions() {
+	if err := rs.authorizer.Reload(utils.DatabasePermissionLoader(rs.repo.db, rolePermissionsQuery)); err != nil {
+		rs.logger.WithError(err).Error("Failed to reload role permissions")
+	}
+}
+
+// RefreshPermissionsPeriodically memuat ulang mapping permission secara berkala, supaya
+// perubahan role dari instance lain juga terbaca. Berhenti ketika context dibatalkan.
+func (rs *RoleService) RefreshPermissionsPeriodically(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			rs.reloadPermissions()
+		}
+	}
+}
+
+// Helper function untuk menghapus duplikat dan entry kosong
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	result := []string{}
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value == "" || seen[value] {
+			continue
+		}
+		seen[value] = true
+		result = append(result, value)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// Helper function untuk mengecek nama ada di slice
+func containsName(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// RoleHandler untuk HTTP handlers role dan permission
+type RoleHandler struct {
+	service *RoleService
+	logger  *logrus.Logger
+}
+
+// NewRoleHandler membuat instance baru RoleHandler
+func NewRoleHandler(service *RoleService, logger *logrus.Logger) *RoleHandler {
+	return &RoleHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// ListRoles handler untuk GET /roles
+func (rh *RoleHandler) ListRoles(c *gin.Context) {
+	roles, err := rh.service.ListRoles()
+	if err != nil {
+		rh.logger.WithError(err).Error("Failed to get roles")
+		utils.InternalServerErrorResponse(c, "Failed to get roles")
+		return
+	}
+
+	utils.SuccessResponse(c, "Roles retrieved successfully", roles)
+}
+
+// ListPermissions handler untuk GET /permissions
+func (rh *RoleHandler) ListPermissions(c *gin.Context) {
+	permissions, err := rh.service.ListPermissions()
+	if err != nil {
+		rh.logger.WithError(err).Error("Failed to get permissions")
+		utils.InternalServerErrorResponse(c, "Failed to get permissions")
+		return
+	}
+
+	utils.SuccessResponse(c, "Permissions retrieved successfully", permissions)
+}
+
+// CreateRole handler untuk POST /roles
+func (rh *RoleHandler) CreateRole(c *gin.Context) {
+	var req CreateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestResponse(c, "Invalid JSON format", err.Error())
+		return
+	}
+
+	role, err := rh.service.CreateRole(req, currentRoles(c))
+	if err != nil {
+		rh.handleRoleError(c, err, "Failed to create role")
+		return
+	}
+
+	utils.CreatedResponse(c, "Role created successfully", role)
+}
+
+// UpdateRole handler untuk PUT /roles/:id
+func (rh *RoleHandler) UpdateRole(c *gin.Context) {
+	id, ok := parseRoleID(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestResponse(c, "Invalid JSON format", err.Error())
+		return
+	}
+
+	role, err := rh.service.UpdateRole(id, req, currentRoles(c))
+	if err != nil {
+		rh.handleRoleError(c, err, "Failed to update role")
+		return
+	}
+
+	utils.SuccessResponse(c, "Role updated successfully", role)
+}
+
+// DeleteRole handler untuk DELETE /roles/:id
+func (rh *RoleHandler) DeleteRole(c *gin.Context) {
+	id, ok := parseRoleID(c)
+	if !ok {
+		return
+	}
+
+	if err := rh.service.DeleteRole(id); err != nil {
+		rh.handleRoleError(c, err, "Failed to delete role")
+		return
+	}
+
+	utils.SuccessResponse(c, "Role deleted successfully", nil)
+}
+
+// GetUserRoles handler untuk GET /users/:id/roles
+func (rh *RoleHandler) GetUserRoles(c *gin.Context) {
+	id, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+
+	roles, err := rh.service.UserRoles(id)
+	if err != nil {
+		rh.handleRoleError(c, err, "Failed to get user roles")
+		return
+	}
+
+	utils.SuccessResponse(c, "User roles retrieved successfully", gin.H{"roles": roles})
+}
+
+// AssignRole handler untuk POST /users/:id/roles
+func (rh *RoleHandler) AssignRole(c *gin.Context) {
+	id, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+
+	var req AssignRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestResponse(c, "Invalid JSON format", err.Error())
+		return
+	}
+
+	roles, err := rh.service.AssignRole(id, req.Role, currentActorID(c), currentRoles(c), clientMetadata(c))
+	if err != nil {
+		rh.handleRoleError(c, err, "Failed to assign role")
+		return
+	}
+
+	utils.SuccessResponse(c, "Role assigned successfully", gin.H{"roles": roles})
+}
+
+// RevokeRole handler untuk DELETE /users/:id/roles/:role
+func (rh *RoleHandler) RevokeRole(c *gin.Context) {
+	id, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+
+	roles, err := rh.service.RevokeRole(id, c.Param("role"), currentActorID(c), currentRoles(c), clientMetadata(c))
+	if err != nil {
+		rh.handleRoleError(c, err, "Failed to revoke role")
+		return
+	}
+
+	utils.SuccessResponse(c, "Role revoked successfully", gin.H{"roles": roles})
+}
+
+// Helper function untuk mapping error role ke HTTP response
+func (rh *RoleHandler) handleRoleError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, ErrUserNotFound):
+		utils.NotFoundResponse(c, "User not found")
+	case errors.Is(err, ErrRoleNotFound):
+		utils.NotFoundResponse(c, "Role not found")
+	case errors.Is(err, ErrInvalidRoleName), errors.Is(err, ErrUnknownPermission):
+		utils.BadRequestResponse(c, message, err.Error())
+	case errors.Is(err, ErrPrivilegedRole):
+		utils.ForbiddenResponse(c, err.Error())
+	case errors.Is(err, ErrRoleExists), errors.Is(err, ErrSystemRole), errors.Is(err, ErrRoleInUse), errors.Is(err, ErrLastAdmin):
+		utils.ConflictResponse(c, message, err.Error())
+	default:
+		rh.logger.WithError(err).Error(message)
+		utils.InternalServerErrorResponse(c, message)
+	}
+}
+
+// Helper function untuk mengambil role user yang sedang login
+func currentRoles(c *gin.Context) []string {
+	claims, ok := middleware.CurrentUser(c)
+	if !ok {
+		return nil
+	}
+	return claims.AllRoles()
+}
+
+// Helper function untuk parsing role ID dari URL
+func parseRoleID(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.BadRequestResponse(c, "Invalid role ID", "Role ID must be a number")
+		return 0, false
+	}
+	return id, true
+}
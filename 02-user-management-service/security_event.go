@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+// Jenis security event yang dicatat
+const (
+	SecurityEventAccountLocked   = "account_locked"
+	SecurityEventAccountUnlocked = "account_unlocked"
+)
+
+// SecurityEvent catatan kejadian yang berhubungan dengan keamanan akun
+type SecurityEvent struct {
+	ID        int64           `json:"id" db:"id"`
+	UserID    *int            `json:"user_id" db:"user_id"`
+	ActorID   *int            `json:"actor_id,omitempty" db:"actor_id"` // Nil jika dilakukan oleh sistem
+	EventType string          `json:"event_type" db:"event_type"`
+	IPAddress string          `json:"ip_address" db:"ip_address"`
+	UserAgent string          `json:"user_agent" db:"user_agent"`
+	Metadata  json.RawMessage `json:"metadata" db:"metadata"`
+	CreatedAt time.Time       `json:"created_at" db:"created_at"`
+}
+
+// SecurityEventRepository untuk database operations security event
+type SecurityEventRepository struct {
+	db     *sqlx.DB
+	logger *logrus.Logger
+}
+
+// NewSecurityEventRepository membuat instance baru SecurityEventRepository
+func NewSecurityEventRepository(db *sqlx.DB, logger *logrus.Logger) *SecurityEventRepository {
+	return &SecurityEventRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Record menyimpan security event baru
+func (sr *SecurityEventRepository) Record(eventType string, userID int, actorID *int, client ClientMetadata, metadata map[string]interface{}) error {
+	if metadata == nil {
+		metadata = map[string]interface{}{}
+	}
+
+	encoded, err := json.Marshal(metadata)
+	if err != nil {
+		return fmt.Errorf("failed to encode security event metadata: %w", err)
+	}
+
+	query := `INSERT INTO security_events (user_id, actor_id, event_type, ip_address, user_agent, metadata, created_at)
+			  VALUES ($1, $2, $3, $4, $5, $6, $7)`
+
+	if _, err := sr.db.Exec(query, userID, actorID, eventType, client.IPAddress, client.UserAgent, string(encoded), time.Now()); err != nil {
+		sr.logger.WithError(err).WithFields(logrus.Fields{
+			"user_id":    userID,
+			"event_type": eventType,
+		}).Error("Failed to record security event")
+		return fmt.Errorf("failed to record security event: %w", err)
+	}
+
+	sr.logger.WithFields(logrus.Fields{
+		"user_id":    userID,
+		"event_type": eventType,
+	}).Info("Security event recorded")
+	return nil
+}
@@ -0,0 +1,315 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jizak1/Microservices-Golang/shared/middleware"
+	"github.com/jizak1/Microservices-Golang/shared/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// Security event untuk sesi login
+const (
+	SecurityEventSessionRevoked     = "session_revoked"
+	SecurityEventAllSessionsRevoked = "all_sessions_revoked"
+)
+
+// ErrSessionNotFound sesi tidak ada, bukan milik user, atau sudah tidak aktif
+var ErrSessionNotFound = errors.New("session not found")
+
+// Session satu login aktif di satu device. ID sesi sama dengan family_id refresh token,
+// dan ikut ditulis ke claim sid di access token.
+type Session struct {
+	ID            string     `json:"id" db:"id"`
+	UserID        int        `json:"user_id" db:"user_id"`
+	DeviceName    string     `json:"device_name" db:"device_name"`
+	UserAgent     string     `json:"user_agent" db:"user_agent"`
+	IPAddress     string     `json:"ip_address" db:"ip_address"`
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+	LastSeenAt    time.Time  `json:"last_seen_at" db:"last_seen_at"` // Login atau refresh terakhir
+	ExpiresAt     time.Time  `json:"expires_at" db:"expires_at"`
+	RevokedAt     *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+	RevokedReason *string    `json:"revoked_reason,omitempty" db:"revoked_reason"`
+	MFA           bool       `json:"mfa" db:"mfa"`   // Login sesi ini sudah melewati MFA
+	Current       bool       `json:"current" db:"-"` // Sesi dari access token yang dipakai request ini
+}
+
+// sessionColumns kolom yang dibaca ke struct Session
+const sessionColumns = `id, user_id, device_name, user_agent, ip_address, created_at, last_seen_at, expires_at,
+	revoked_at, revoked_reason, mfa`
+
+// CreateSession menyimpan sesi baru beserta refresh token pertamanya dalam satu transaksi
+func (rr *RefreshTokenRepository) CreateSession(session *Session, token *RefreshToken) error {
+	tx, err := rr.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	sessionQuery := `
+		INSERT INTO user_sessions (id, user_id, device_name, user_agent, ip_address, created_at, last_seen_at, expires_at, mfa)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
+
+	if _, err := tx.Exec(sessionQuery, session.ID, session.UserID, session.DeviceName, session.UserAgent,
+		session.IPAddress, session.CreatedAt, session.LastSeenAt, session.ExpiresAt, session.MFA); err != nil {
+		rr.logger.WithError(err).WithField("user_id", session.UserID).Error("Failed to create session")
+		return fmt.Errorf("failed to create session: %w", err)
+	}
+
+	tokenQuery := `
+		INSERT INTO refresh_tokens (user_id, family_id, token_hash, device_name, user_agent, ip_address, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id`
+
+	err = tx.QueryRow(tokenQuery, token.UserID, token.FamilyID, token.TokenHash, token.DeviceName,
+		token.UserAgent, token.IPAddress, token.ExpiresAt, token.CreatedAt).Scan(&token.ID)
+	if err != nil {
+		rr.logger.WithError(err).WithField("user_id", token.UserID).Error("Failed to create refresh token")
+		return fmt.Errorf("failed to create refresh token: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// ListActiveSessions mengambil semua sesi user yang belum di-revoke dan belum expired, terbaru dulu
+func (rr *RefreshTokenRepository) ListActiveSessions(userID int) ([]Session, error) {
+	sessions := []Session{}
+	query := `SELECT ` + sessionColumns + ` FROM user_sessions
+			  WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > $2
+			  ORDER BY last_seen_at DESC`
+
+	if err := rr.db.Select(&sessions, query, userID, time.Now()); err != nil {
+		rr.logger.WithError(err).WithField("user_id", userID).Error("Failed to list sessions")
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+	return sessions, nil
+}
+
+// GetActiveSession mengambil sesi aktif milik user
+func (rr *RefreshTokenRepository) GetActiveSession(userID int, sessionID string) (*Session, error) {
+	var session Session
+	query := `SELECT ` + sessionColumns + ` FROM user_sessions
+			  WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL AND expires_at > $3`
+
+	if err := rr.db.Get(&session, query, sessionID, userID, time.Now()); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrSessionNotFound
+		}
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+	return &session, nil
+}
+
+// SessionService untuk business logic daftar dan revoke sesi login
+type SessionService struct {
+	tokenRepo   *RefreshTokenRepository
+	userRepo    *UserRepository
+	authService *AuthService
+	events      *SecurityEventRepository
+	logger      *logrus.Logger
+}
+
+// NewSessionService membuat instance baru SessionService
+func NewSessionService(tokenRepo *RefreshTokenRepository, userRepo *UserRepository, authService *AuthService,
+	events *SecurityEventRepository, logger *logrus.Logger) *SessionService {
+	return &SessionService{
+		tokenRepo:   tokenRepo,
+		userRepo:    userRepo,
+		authService: authService,
+		events:      events,
+		logger:      logger,
+	}
+}
+
+// ListSessions mengambil sesi aktif user, currentSessionID menandai sesi yang sedang dipakai
+func (ss *SessionService) ListSessions(userID int, currentSessionID string) ([]Session, error) {
+	if _, err := ss.userRepo.GetUserByID(userID); err != nil {
+		return nil, err
+	}
+
+	sessions, err := ss.tokenRepo.ListActiveSessions(userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range sessions {
+		sessions[i].Current = currentSessionID != "" && sessions[i].ID == currentSessionID
+	}
+	return sessions, nil
+}
+
+// RevokeSession mengakhiri satu sesi. Refresh token dan semua access token dari sesi tersebut langsung tidak berlaku.
+func (ss *SessionService) RevokeSession(userID int, sessionID string, actorID *int, metadata ClientMetadata) error {
+	session, err := ss.tokenRepo.GetActiveSession(userID, sessionID)
+	if err != nil {
+		return err
+	}
+
+	reason := revokeReason(userID, actorID)
+	if err := ss.authService.RevokeSession(session.ID, reason); err != nil {
+		return err
+	}
+
+	if err := ss.events.Record(SecurityEventSessionRevoked, userID, actorID, metadata, map[string]interface{}{
+		"session_id":  session.ID,
+		"device_name": session.DeviceName,
+		"reason":      reason,
+	}); err != nil {
+		ss.logger.WithError(err).WithField("user_id", userID).Warn("Failed to record session revoked event")
+	}
+	return nil
+}
+
+// RevokeAllSessions mengakhiri semua sesi user (log out everywhere), termasuk sesi yang sedang dipakai.
+// Return jumlah sesi aktif yang diakhiri.
+func (ss *SessionService) RevokeAllSessions(userID int, actorID *int, metadata ClientMetadata) (int, error) {
+	sessions, err := ss.ListSessions(userID, "")
+	if err != nil {
+		return 0, err
+	}
+
+	reason := revokeReason(userID, actorID)
+	if err := ss.authService.RevokeUserAccess(userID, reason); err != nil {
+		return 0, err
+	}
+
+	if err := ss.events.Record(SecurityEventAllSessionsRevoked, userID, actorID, metadata, map[string]interface{}{
+		"sessions": len(sessions),
+		"reason":   reason,
+	}); err != nil {
+		ss.logger.WithError(err).WithField("user_id", userID).Warn("Failed to record sessions revoked event")
+	}
+	return len(sessions), nil
+}
+
+// Helper function untuk alasan revoke, dibedakan antara user sendiri dan admin
+func revokeReason(userID int, actorID *int) string {
+	if actorID != nil && *actorID == userID {
+		return "revoked_by_user"
+	}
+	return "revoked_by_admin"
+}
+
+// SessionHandler untuk HTTP handlers sesi login
+type SessionHandler struct {
+	service *SessionService
+	logger  *logrus.Logger
+}
+
+// NewSessionHandler membuat instance baru SessionHandler
+func NewSessionHandler(service *SessionService, logger *logrus.Logger) *SessionHandler {
+	return &SessionHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// ListMySessions handler untuk GET /users/me/sessions
+func (sh *SessionHandler) ListMySessions(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	sh.list(c, userID)
+}
+
+// RevokeMySession handler untuk DELETE /users/me/sessions/:id
+func (sh *SessionHandler) RevokeMySession(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	sh.revoke(c, userID, c.Param("id"))
+}
+
+// RevokeAllMySessions handler untuk DELETE /users/me/sessions (log out everywhere)
+func (sh *SessionHandler) RevokeAllMySessions(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	sh.revokeAll(c, userID)
+}
+
+// ListUserSessions handler untuk GET /users/:id/sessions
+func (sh *SessionHandler) ListUserSessions(c *gin.Context) {
+	userID, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+	sh.list(c, userID)
+}
+
+// RevokeUserSession handler untuk DELETE /users/:id/sessions/:session_id
+func (sh *SessionHandler) RevokeUserSession(c *gin.Context) {
+	userID, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+	sh.revoke(c, userID, c.Param("session_id"))
+}
+
+// RevokeAllUserSessions handler untuk DELETE /users/:id/sessions
+func (sh *SessionHandler) RevokeAllUserSessions(c *gin.Context) {
+	userID, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+	sh.revokeAll(c, userID)
+}
+
+// Helper function untuk response daftar sesi
+func (sh *SessionHandler) list(c *gin.Context, userID int) {
+	currentSessionID := ""
+	if claims, ok := middleware.CurrentUser(c); ok {
+		currentSessionID = claims.SessionID
+	}
+
+	sessions, err := sh.service.ListSessions(userID, currentSessionID)
+	if err != nil {
+		sh.handleSessionError(c, userID, err, "Failed to list sessions")
+		return
+	}
+
+	utils.SuccessResponse(c, "Sessions retrieved successfully", sessions)
+}
+
+// Helper function untuk revoke satu sesi
+func (sh *SessionHandler) revoke(c *gin.Context, userID int, sessionID string) {
+	if err := sh.service.RevokeSession(userID, sessionID, currentActorID(c), clientMetadata(c)); err != nil {
+		sh.handleSessionError(c, userID, err, "Failed to revoke session")
+		return
+	}
+
+	utils.SuccessResponse(c, "Session revoked successfully", nil)
+}
+
+// Helper function untuk revoke semua sesi
+func (sh *SessionHandler) revokeAll(c *gin.Context, userID int) {
+	revoked, err := sh.service.RevokeAllSessions(userID, currentActorID(c), clientMetadata(c))
+	if err != nil {
+		sh.handleSessionError(c, userID, err, "Failed to revoke sessions")
+		return
+	}
+
+	utils.SuccessResponse(c, "All sessions revoked successfully", gin.H{"revoked_sessions": revoked})
+}
+
+// Helper function untuk mapping error sesi ke HTTP response
+func (sh *SessionHandler) handleSessionError(c *gin.Context, userID int, err error, message string) {
+	switch {
+	case errors.Is(err, ErrUserNotFound):
+		utils.NotFoundResponse(c, "User not found")
+	case errors.Is(err, ErrSessionNotFound):
+		utils.NotFoundResponse(c, "Session not found")
+	default:
+		sh.logger.WithError(err).WithField("user_id", userID).Error(message)
+		utils.InternalServerErrorResponse(c, message)
+	}
+}
@@ -0,0 +1,917 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/jizak1/Microservices-Golang/shared/utils"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+)
+
+// Format file import dan export
+const (
+	BulkFormatCSV    = "csv"
+	BulkFormatNDJSON = "ndjson"
+)
+
+// Status job import
+const (
+	ImportStatusPending   = "pending"
+	ImportStatusRunning   = "running"
+	ImportStatusCompleted = "completed"
+	ImportStatusFailed    = "failed"
+)
+
+// maxImportErrors batas error per baris yang disimpan per job, jumlah baris gagal tetap dihitung semua
+const maxImportErrors = 10000
+
+// maxNDJSONLineSize batas panjang satu baris NDJSON
+const maxNDJSONLineSize = 64 << 10
+
+// exportFlushInterval jumlah user yang ditulis sebelum response di-flush ke client
+const exportFlushInterval = 500
+
+// importCSVColumns kolom wajib di header CSV, sama dengan field CreateUserRequest
+var importCSVColumns = []string{"username", "email", "full_name", "password"}
+
+// exportCSVColumns kolom file export CSV
+var exportCSVColumns = []string{"id", "username", "email", "full_name", "roles", "is_active",
+	"email_verified_at", "created_at", "updated_at", "deleted_at"}
+
+// Error yang bisa terjadi saat bulk import
+var (
+	// ErrImportJobNotFound job import tidak ada
+	ErrImportJobNotFound = errors.New("import job not found")
+	// ErrUnsupportedBulkFormat format bukan csv atau ndjson
+	ErrUnsupportedBulkFormat = errors.New("format must be csv or ndjson")
+	// ErrImportTooLarge file import melebihi USER_IMPORT_MAX_SIZE
+	ErrImportTooLarge = errors.New("import file is too large")
+	// ErrImportEmpty file import kosong
+	ErrImportEmpty = errors.New("import file is empty")
+	// ErrImportInterrupted job dihentikan karena service shutdown, batch yang sudah selesai tetap tersimpan
+	ErrImportInterrupted = errors.New("import interrupted by shutdown")
+)
+
+// UserImportJob status dan hasil satu kali bulk import
+type UserImportJob struct {
+	ID            int        `json:"id" db:"id"`
+	Format        string     `json:"format" db:"format"`
+	DryRun        bool       `json:"dry_run" db:"dry_run"`
+	Status        string     `json:"status" db:"status"`
+	TotalRows     int        `json:"total_rows" db:"total_rows"`
+	SucceededRows int        `json:"succeeded_rows" db:"succeeded_rows"` // Saat dry run: baris yang akan berhasil diimport
+	FailedRows    int        `json:"failed_rows" db:"failed_rows"`
+	Error         *string    `json:"error,omitempty" db:"error"` // Alasan job gagal (bukan error per baris)
+	CreatedBy     *int       `json:"created_by" db:"created_by"`
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+	StartedAt     *time.Time `json:"started_at,omitempty" db:"started_at"`
+	FinishedAt    *time.Time `json:"finished_at,omitempty" db:"finished_at"`
+}
+
+// UserImportError error validasi untuk satu baris file import
+type UserImportError struct {
+	Line    int    `json:"line" db:"line"`
+	Message string `json:"message" db:"message"`
+}
+
+// UserImportRepository untuk database operations job import
+type UserImportRepository struct {
+	db     *sqlx.DB
+	logger *logrus.Logger
+}
+
+// NewUserImportRepository membuat instance baru UserImportRepository
+func NewUserImportRepository(db *sqlx.DB, logger *logrus.Logger) *UserImportRepository {
+	return &UserImportRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// CreateJob menyimpan job import baru
+func (ir *UserImportRepository) CreateJob(job *UserImportJob) error {
+	query := `INSERT INTO user_import_jobs (format, dry_run, status, created_by, created_at)
+			  VALUES ($1, $2, $3, $4, $5) RETURNING id`
+
+	if err := ir.db.QueryRow(query, job.Format, job.DryRun, job.Status, job.CreatedBy, job.CreatedAt).Scan(&job.ID); err != nil {
+		ir.logger.WithError(err).Error("Failed to create import job")
+		return fmt.Errorf("failed to create import job: %w", err)
+	}
+	return nil
+}
+
+// GetJob mengambil job import berdasarkan ID
+func (ir *UserImportRepository) GetJob(id int) (*UserImportJob, error) {
+	var job UserImportJob
+	query := `SELECT id, format, dry_run, status, total_rows, succeeded_rows, failed_rows, error,
+				created_by, created_at, started_at, finished_at
+			  FROM user_import_jobs WHERE id = $1`
+
+	if err := ir.db.Get(&job, query, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrImportJobNotFound
+		}
+		return nil, fmt.Errorf("failed to get import job: %w", err)
+	}
+	return &job, nil
+}
+
+// SaveJob menyimpan status dan progress job
+func (ir *UserImportRepository) SaveJob(job *UserImportJob) error {
+	query := `UPDATE user_import_jobs SET status = $1, total_rows = $2, succeeded_rows = $3, failed_rows = $4,
+				error = $5, started_at = $6, finished_at = $7
+			  WHERE id = $8`
+
+	if _, err := ir.db.Exec(query, job.Status, job.TotalRows, job.SucceededRows, job.FailedRows,
+		job.Error, job.StartedAt, job.FinishedAt, job.ID); err != nil {
+		return fmt.Errorf("failed to save import job: %w", err)
+	}
+	return nil
+}
+
+// AddErrors menyimpan error per baris untuk job
+func (ir *UserImportRepository) AddErrors(jobID int, importErrors []UserImportError) error {
+	if len(importErrors) == 0 {
+		return nil
+	}
+
+	lines := make([]int64, len(importErrors))
+	messages := make([]string, len(importErrors))
+	for i, importErr := range importErrors {
+		lines[i] = int64(importErr.Line)
+		messages[i] = importErr.Message
+	}
+
+	query := `INSERT INTO user_import_errors (job_id, line, message)
+			  SELECT $1, line, message FROM unnest($2::int[], $3::text[]) AS e(line, message)`
+	if _, err := ir.db.Exec(query, jobID, pq.Array(lines), pq.Array(messages)); err != nil {
+		return fmt.Errorf("failed to save import errors: %w", err)
+	}
+	return nil
+}
+
+// GetErrors mengambil error per baris untuk job dengan pagination
+func (ir *UserImportRepository) GetErrors(jobID, limit, offset int) ([]UserImportError, int, error) {
+	var total int
+	if err := ir.db.Get(&total, `SELECT COUNT(*) FROM user_import_errors WHERE job_id = $1`, jobID); err != nil {
+		return nil, 0, fmt.Errorf("failed to count import errors: %w", err)
+	}
+
+	importErrors := []UserImportError{}
+	query := `SELECT line, message FROM user_import_errors WHERE job_id = $1 ORDER BY line, id LIMIT $2 OFFSET $3`
+	if err := ir.db.Select(&importErrors, query, jobID, limit, offset); err != nil {
+		return nil, 0, fmt.Errorf("failed to get import errors: %w", err)
+	}
+	return importErrors, total, nil
+}
+
+// UserImporter menjalankan bulk import user di background. File upload disimpan dulu ke file sementara
+// supaya request bisa langsung selesai, status job bisa dicek lewat GET /user-imports/:id.
+type UserImporter struct {
+	repo         *UserImportRepository
+	userRepo     *UserRepository
+	verification *EmailVerificationService
+	hasher       *utils.PasswordHasher
+	policy       *utils.PasswordPolicy
+	maxSize      int64
+	batchSize    int
+	jobsCtx      context.Context
+	running      sync.WaitGroup
+	logger       *logrus.Logger
+}
+
+// NewUserImporter membuat instance baru UserImporter. Job yang sedang berjalan dihentikan saat jobsCtx dibatalkan.
+func NewUserImporter(jobsCtx context.Context, repo *UserImportRepository, userRepo *UserRepository,
+	verification *EmailVerificationService, hasher *utils.PasswordHasher, policy *utils.PasswordPolicy,
+	maxSize int64, batchSize int, logger *logrus.Logger) *UserImporter {
+	return &UserImporter{
+		repo:         repo,
+		userRepo:     userRepo,
+		verification: verification,
+		hasher:       hasher,
+		policy:       policy,
+		maxSize:      maxSize,
+		batchSize:    batchSize,
+		jobsCtx:      jobsCtx,
+		logger:       logger,
+	}
+}
+
+// StartImport menyimpan file import dan menjalankan job di background
+func (ui *UserImporter) StartImport(format string, dryRun bool, createdBy *int, body io.Reader) (*UserImportJob, error) {
+	if format != BulkFormatCSV && format != BulkFormatNDJSON {
+		return nil, ErrUnsupportedBulkFormat
+	}
+
+	path, err := ui.spool(body)
+	if err != nil {
+		return nil, err
+	}
+
+	job := &UserImportJob{
+		Format:    format,
+		DryRun:    dryRun,
+		Status:    ImportStatusPending,
+		CreatedBy: createdBy,
+		CreatedAt: time.Now(),
+	}
+	if err := ui.repo.CreateJob(job); err != nil {
+		os.Remove(path)
+		return nil, err
+	}
+
+	// Goroutine memakai salinan job supaya response tidak membaca data yang sedang diubah
+	run := *job
+	ui.running.Add(1)
+	go func() {
+		defer ui.running.Done()
+		defer os.Remove(path)
+		ui.run(&run, path)
+	}()
+
+	return job, nil
+}
+
+// GetJob mengambil status job import
+func (ui *UserImporter) GetJob(id int) (*UserImportJob, error) {
+	return ui.repo.GetJob(id)
+}
+
+// GetErrors mengambil error per baris job import
+func (ui *UserImporter) GetErrors(id, page, limit int) ([]UserImportError, int, error) {
+	if _, err := ui.repo.GetJob(id); err != nil {
+		return nil, 0, err
+	}
+	return ui.repo.GetErrors(id, limit, utils.CalculateOffset(page, limit))
+}
+
+// Wait menunggu semua job yang sedang berjalan selesai, dipanggil saat shutdown setelah jobsCtx dibatalkan
+func (ui *UserImporter) Wait() {
+	ui.running.Wait()
+}
+
+// Helper function untuk menyimpan body upload ke file sementara dengan batas ukuran
+func (ui *UserImporter) spool(body io.Reader) (string, error) {
+	file, err := os.CreateTemp("", "user-import-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create import file: %w", err)
+	}
+	path := file.Name()
+
+	written, err := io.Copy(file, io.LimitReader(body, ui.maxSize+1))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	switch {
+	case err != nil:
+		err = fmt.Errorf("failed to store import file: %w", err)
+	case written > ui.maxSize:
+		err = ErrImportTooLarge
+	case written == 0:
+		err = ErrImportEmpty
+	}
+	if err != nil {
+		os.Remove(path)
+		return "", err
+	}
+	return path, nil
+}
+
+// importRun state satu job import yang sedang berjalan
+type importRun struct {
+	job          *UserImportJob
+	usernames    map[string]int // Username yang sudah muncul di file -> nomor baris
+	emails       map[string]int // Email (lowercase) yang sudah muncul di file -> nomor baris
+	storedErrors int
+}
+
+// Helper function untuk menjalankan satu job sampai selesai dan menyimpan hasilnya
+func (ui *UserImporter) run(job *UserImportJob, path string) {
+	startedAt := time.Now()
+	job.Status = ImportStatusRunning
+	job.StartedAt = &startedAt
+	if err := ui.repo.SaveJob(job); err != nil {
+		ui.logger.WithError(err).WithField("job_id", job.ID).Error("Failed to mark import job as running")
+	}
+
+	err := ui.process(job, path)
+
+	finishedAt := time.Now()
+	job.FinishedAt = &finishedAt
+	job.Status = ImportStatusCompleted
+	if err != nil {
+		message := err.Error()
+		job.Status = ImportStatusFailed
+		job.Error = &message
+	}
+	if err := ui.repo.SaveJob(job); err != nil {
+		ui.logger.WithError(err).WithField("job_id", job.ID).Error("Failed to save import job result")
+	}
+
+	entry := ui.logger.WithFields(logrus.Fields{
+		"job_id":         job.ID,
+		"dry_run":        job.DryRun,
+		"status":         job.Status,
+		"total_rows":     job.TotalRows,
+		"succeeded_rows": job.SucceededRows,
+		"failed_rows":    job.FailedRows,
+		"duration":       finishedAt.Sub(startedAt).String(),
+	})
+	if err != nil {
+		entry.WithError(err).Warn("User import failed")
+		return
+	}
+	entry.Info("User import completed")
+}
+
+// Helper function untuk membaca file import dan memproses barisnya per batch
+func (ui *UserImporter) process(job *UserImportJob, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open import file: %w", err)
+	}
+	defer file.Close()
+
+	reader, err := newImportRowReader(job.Format, file)
+	if err != nil {
+		return err
+	}
+
+	run := &importRun{job: job, usernames: map[string]int{}, emails: map[string]int{}}
+	batch := make([]importRow, 0, ui.batchSize)
+	for {
+		if ui.jobsCtx.Err() != nil {
+			return ErrImportInterrupted
+		}
+
+		row, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		job.TotalRows++
+		batch = append(batch, row)
+		if len(batch) < ui.batchSize {
+			continue
+		}
+
+		if err := ui.processBatch(run, batch); err != nil {
+			return err
+		}
+		batch = batch[:0]
+	}
+
+	return ui.processBatch(run, batch)
+}
+
+// Helper function untuk memvalidasi dan membuat user untuk satu batch, progress disimpan setelah tiap batch
+func (ui *UserImporter) processBatch(run *importRun, batch []importRow) error {
+	if len(batch) == 0 {
+		return nil
+	}
+
+	var failures []UserImportError
+	fail := func(line int, message string) {
+		run.job.FailedRows++
+		failures = append(failures, UserImportError{Line: line, Message: message})
+	}
+
+	var valid []importRow
+	for _, row := range batch {
+		message, err := ui.validateRow(run, row)
+		if err != nil {
+			return err
+		}
+		if message != "" {
+			fail(row.line, message)
+			continue
+		}
+		valid = append(valid, row)
+	}
+
+	if run.job.DryRun {
+		run.job.SucceededRows += len(valid)
+	} else if err := ui.createUsers(run, valid, fail); err != nil {
+		return err
+	}
+
+	// Error disimpan sampai batas maxImportErrors, failed_rows tetap menghitung semua baris gagal
+	if remaining := maxImportErrors - run.storedErrors; len(failures) > remaining {
+		failures = failures[:remaining]
+	}
+	if err := ui.repo.AddErrors(run.job.ID, failures); err != nil {
+		return err
+	}
+	run.storedErrors += len(failures)
+
+	return ui.repo.SaveJob(run.job)
+}
+
+// Helper function untuk validasi satu baris dengan aturan yang sama seperti POST /users.
+// Hasilnya pesan error untuk baris tersebut, error kedua berarti job harus dihentikan.
+func (ui *UserImporter) validateRow(run *importRun, row importRow) (string, error) {
+	if row.err != "" {
+		return row.err, nil
+	}
+
+	req := row.req
+	if err := binding.Validator.ValidateStruct(&req); err != nil {
+		return err.Error(), nil
+	}
+	if err := ui.policy.Validate(req.Password, req.Username, req.Email); err != nil {
+		return err.Error(), nil
+	}
+
+	if line, ok := run.usernames[req.Username]; ok {
+		return fmt.Sprintf("username %q is already used on line %d", req.Username, line), nil
+	}
+	// Email unik tanpa membedakan huruf besar/kecil, sama seperti unique index di database
+	emailKey := strings.ToLower(req.Email)
+	if line, ok := run.emails[emailKey]; ok {
+		return fmt.Sprintf("email %q is already used on line %d", req.Email, line), nil
+	}
+	run.usernames[req.Username] = row.line
+	run.emails[emailKey] = row.line
+
+	usernameExists, err := ui.userRepo.CheckUsernameExists(req.Username, 0)
+	if err != nil {
+		return "", fmt.Errorf("failed to check username: %w", err)
+	}
+	if usernameExists {
+		return ErrUsernameExists.Error(), nil
+	}
+
+	emailExists, err := ui.userRepo.CheckEmailExists(req.Email, 0)
+	if err != nil {
+		return "", fmt.Errorf("failed to check email: %w", err)
+	}
+	if emailExists {
+		return ErrEmailExists.Error(), nil
+	}
+
+	return "", nil
+}
+
+// Helper function untuk membuat user dari baris yang valid dalam satu transaksi
+func (ui *UserImporter) createUsers(run *importRun, rows []importRow, fail func(line int, message string)) error {
+	if len(rows) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	users := make([]*User, len(rows))
+	for i, row := range rows {
+		if ui.jobsCtx.Err() != nil {
+			return ErrImportInterrupted
+		}
+
+		// Password di-hash di luar transaksi supaya transaksi tidak lama terbuka
+		hashedPassword, err := ui.hasher.Hash(row.req.Password)
+		if err != nil {
+			return fmt.Errorf("failed to hash password: %w", err)
+		}
+		users[i] = &User{
+			Username:  row.req.Username,
+			Email:     row.req.Email,
+			FullName:  row.req.FullName,
+			Password:  hashedPassword,
+			Roles:     pq.StringArray{defaultUserRole},
+			IsActive:  true,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+	}
+
+	// Username/email bisa saja diambil request lain setelah validasi
+	results, err := ui.userRepo.CreateUsersBatch(users)
+	if err != nil {
+		return err
+	}
+
+	for i, user := range users {
+		if results[i] != nil {
+			fail(rows[i].line, results[i].Error())
+			continue
+		}
+		run.job.SucceededRows++
+
+		// Sama seperti POST /users, gagal kirim email tidak membatalkan user yang sudah dibuat
+		if err := ui.verification.SendVerification(user); err != nil {
+			ui.logger.WithError(err).WithField("user_id", user.ID).Warn("Imported user created without verification email")
+		}
+	}
+	return nil
+}
+
+// importRow satu baris data dari file import
+type importRow struct {
+	line int
+	req  CreateUserRequest
+	err  string // Diisi jika baris tidak bisa dibaca (kolom kurang, JSON tidak valid)
+}
+
+// importRowReader membaca baris file import satu per satu, io.EOF jika sudah habis
+type importRowReader interface {
+	Next() (importRow, error)
+}
+
+// Helper function untuk membuat reader sesuai format file
+func newImportRowReader(format string, r io.Reader) (importRowReader, error) {
+	if format == BulkFormatCSV {
+		return newCSVRowReader(r)
+	}
+	return newNDJSONRowReader(r), nil
+}
+
+// csvRowReader membaca file CSV dengan header di baris pertama
+type csvRowReader struct {
+	reader  *csv.Reader
+	columns map[string]int
+}
+
+// Helper function untuk membaca dan memvalidasi header CSV
+func newCSVRowReader(r io.Reader) (*csvRowReader, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read csv header: %w", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		// File dari Excel biasanya diawali BOM
+		if i == 0 {
+			name = strings.TrimPrefix(name, "\ufeff")
+		}
+		name = strings.ToLower(strings.TrimSpace(name))
+
+		if !containsName(importCSVColumns, name) {
+			return nil, fmt.Errorf("unknown csv column %q (expected: %s)", name, strings.Join(importCSVColumns, ", "))
+		}
+		if _, exists := columns[name]; exists {
+			return nil, fmt.Errorf("csv column %q is specified more than once", name)
+		}
+		columns[name] = i
+	}
+	for _, name := range importCSVColumns {
+		if _, exists := columns[name]; !exists {
+			return nil, fmt.Errorf("missing csv column %q", name)
+		}
+	}
+
+	reader.FieldsPerRecord = len(header)
+	return &csvRowReader{reader: reader, columns: columns}, nil
+}
+
+// Next membaca baris data berikutnya
+func (cr *csvRowReader) Next() (importRow, error) {
+	record, err := cr.reader.Read()
+	if err != nil {
+		// Jumlah kolom yang salah hanya menggagalkan baris tersebut, error parsing lain menghentikan job
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) && errors.Is(parseErr.Err, csv.ErrFieldCount) {
+			return importRow{
+				line: parseErr.StartLine,
+				err:  fmt.Sprintf("expected %d columns, got %d", cr.reader.FieldsPerRecord, len(record)),
+			}, nil
+		}
+		if errors.Is(err, io.EOF) {
+			return importRow{}, io.EOF
+		}
+		return importRow{}, fmt.Errorf("failed to read csv: %w", err)
+	}
+
+	line, _ := cr.reader.FieldPos(0)
+	return importRow{
+		line: line,
+		req: CreateUserRequest{
+			Username: strings.TrimSpace(record[cr.columns["username"]]),
+			Email:    strings.TrimSpace(record[cr.columns["email"]]),
+			FullName: strings.TrimSpace(record[cr.columns["full_name"]]),
+			Password: record[cr.columns["password"]],
+		},
+	}, nil
+}
+
+// ndjsonRowReader membaca file NDJSON, satu objek CreateUserRequest per baris
+type ndjsonRowReader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+// Helper function untuk membuat reader NDJSON
+func newNDJSONRowReader(r io.Reader) *ndjsonRowReader {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 4096), maxNDJSONLineSize)
+	return &ndjsonRowReader{scanner: scanner}
+}
+
+// Next membaca baris data berikutnya, baris kosong dilewati
+func (nr *ndjsonRowReader) Next() (importRow, error) {
+	for nr.scanner.Scan() {
+		nr.line++
+		text := bytes.TrimSpace(nr.scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+
+		row := importRow{line: nr.line}
+		decoder := json.NewDecoder(bytes.NewReader(text))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&row.req); err != nil {
+			row.err = "invalid JSON: " + err.Error()
+		} else if decoder.More() {
+			row.err = "invalid JSON: each line must contain a single object"
+		}
+		return row, nil
+	}
+
+	if err := nr.scanner.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			return importRow{}, fmt.Errorf("line %d is longer than %d bytes", nr.line+1, maxNDJSONLineSize)
+		}
+		return importRow{}, fmt.Errorf("failed to read ndjson: %w", err)
+	}
+	return importRow{}, io.EOF
+}
+
+// UserBulkHandler untuk HTTP handlers bulk import dan export user
+type UserBulkHandler struct {
+	importer *UserImporter
+	users    *UserService
+	logger   *logrus.Logger
+}
+
+// NewUserBulkHandler membuat instance baru UserBulkHandler
+func NewUserBulkHandler(importer *UserImporter, users *UserService, logger *logrus.Logger) *UserBulkHandler {
+	return &UserBulkHandler{
+		importer: importer,
+		users:    users,
+		logger:   logger,
+	}
+}
+
+// Import handler untuk POST /user-imports. Body berupa file CSV atau NDJSON (bukan multipart),
+// format dari parameter format atau Content-Type. dry_run=true hanya memvalidasi tanpa membuat user.
+func (bh *UserBulkHandler) Import(c *gin.Context) {
+	format, ok := bulkFormatParam(c, true)
+	if !ok {
+		return
+	}
+
+	dryRun := false
+	if value := c.Query("dry_run"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			utils.BadRequestResponse(c, "Invalid dry_run parameter", "dry_run must be true or false")
+			return
+		}
+		dryRun = parsed
+	}
+
+	if c.Request.ContentLength > bh.importer.maxSize {
+		importTooLargeResponse(c, bh.importer.maxSize)
+		return
+	}
+
+	// Upload file besar bisa lebih lama dari SERVER_TIMEOUT
+	http.NewResponseController(c.Writer).SetReadDeadline(time.Time{})
+
+	job, err := bh.importer.StartImport(format, dryRun, currentActorID(c), c.Request.Body)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrImportTooLarge):
+			importTooLargeResponse(c, bh.importer.maxSize)
+		case errors.Is(err, ErrImportEmpty):
+			utils.BadRequestResponse(c, "Import file is empty", "Send the CSV or NDJSON file as the request body")
+		default:
+			bh.logger.WithError(err).Error("Failed to start user import")
+			utils.InternalServerErrorResponse(c, "Failed to start user import")
+		}
+		return
+	}
+
+	c.Header("Location", fmt.Sprintf("/api/v1/user-imports/%d", job.ID))
+	utils.AcceptedResponse(c, "User import started", job)
+}
+
+// GetJob handler untuk GET /user-imports/:id
+func (bh *UserBulkHandler) GetJob(c *gin.Context) {
+	id, ok := parseImportJobID(c)
+	if !ok {
+		return
+	}
+
+	job, err := bh.importer.GetJob(id)
+	if err != nil {
+		if errors.Is(err, ErrImportJobNotFound) {
+			utils.NotFoundResponse(c, "Import job not found")
+			return
+		}
+		bh.logger.WithError(err).WithField("job_id", id).Error("Failed to get import job")
+		utils.InternalServerErrorResponse(c, "Failed to get import job")
+		return
+	}
+
+	utils.SuccessResponse(c, "Import job retrieved successfully", job)
+}
+
+// GetErrors handler untuk GET /user-imports/:id/errors, error per baris diurutkan berdasarkan nomor baris
+func (bh *UserBulkHandler) GetErrors(c *gin.Context) {
+	id, ok := parseImportJobID(c)
+	if !ok {
+		return
+	}
+	page, limit := utils.GetPaginationParams(c)
+
+	importErrors, total, err := bh.importer.GetErrors(id, page, limit)
+	if err != nil {
+		if errors.Is(err, ErrImportJobNotFound) {
+			utils.NotFoundResponse(c, "Import job not found")
+			return
+		}
+		bh.logger.WithError(err).WithField("job_id", id).Error("Failed to get import errors")
+		utils.InternalServerErrorResponse(c, "Failed to get import errors")
+		return
+	}
+
+	utils.PaginatedResponse(c, "Import errors retrieved successfully", importErrors, page, limit, total)
+}
+
+// Export handler untuk GET /user-exports. Filter sama dengan GET /users, hasil ditulis langsung
+// ke response satu per satu sehingga jumlah user tidak dibatasi memory.
+func (bh *UserBulkHandler) Export(c *gin.Context) {
+	format, ok := bulkFormatParam(c, false)
+	if !ok {
+		return
+	}
+
+	filter, violations := ParseUserListFilter(c)
+	if len(violations) > 0 {
+		utils.ValidationErrorResponse(c, violations)
+		return
+	}
+	includeDeleted, ok := includeDeletedParam(c)
+	if !ok {
+		return
+	}
+	filter.IncludeDeleted = includeDeleted
+
+	// Export besar bisa lebih lama dari SERVER_TIMEOUT
+	http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+
+	contentType := "application/x-ndjson"
+	if format == BulkFormatCSV {
+		contentType = "text/csv; charset=utf-8"
+	}
+	filename := fmt.Sprintf("users-%s.%s", time.Now().UTC().Format("20060102-150405"), format)
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Header("Cache-Control", "no-store")
+	c.Status(http.StatusOK)
+
+	writeUser, flush := newUserExportWriter(format, c.Writer)
+	count := 0
+	err := bh.users.ExportUsers(c.Request.Context(), filter, func(user *User) error {
+		if err := writeUser(user); err != nil {
+			return err
+		}
+		count++
+		if count%exportFlushInterval == 0 {
+			return flush()
+		}
+		return nil
+	})
+	if err == nil {
+		err = flush()
+	}
+
+	if err != nil && !c.Writer.Written() {
+		bh.logger.WithError(err).Error("Failed to export users")
+		c.Writer.Header().Del("Content-Type")
+		c.Writer.Header().Del("Content-Disposition")
+		utils.InternalServerErrorResponse(c, "Failed to export users")
+		return
+	}
+
+	// Sebagian data sudah terkirim, error hanya bisa dicatat dan response dihentikan
+	if err != nil {
+		bh.logger.WithError(err).WithField("exported", count).Error("User export aborted")
+		c.Abort()
+		return
+	}
+	bh.logger.WithFields(logrus.Fields{"format": format, "exported": count}).Info("Users exported")
+}
+
+// Helper function untuk membuat penulis baris export sesuai format
+func newUserExportWriter(format string, w gin.ResponseWriter) (func(*User) error, func() error) {
+	if format == BulkFormatNDJSON {
+		encoder := json.NewEncoder(w)
+		writeUser := func(user *User) error {
+			return encoder.Encode(user)
+		}
+		flush := func() error {
+			w.Flush()
+			return nil
+		}
+		return writeUser, flush
+	}
+
+	writer := csv.NewWriter(w)
+	headerWritten := false
+	writeUser := func(user *User) error {
+		if !headerWritten {
+			if err := writer.Write(exportCSVColumns); err != nil {
+				return err
+			}
+			headerWritten = true
+		}
+		return writer.Write([]string{
+			strconv.Itoa(user.ID),
+			user.Username,
+			user.Email,
+			user.FullName,
+			strings.Join(user.Roles, ";"),
+			strconv.FormatBool(user.IsActive),
+			formatExportTime(user.EmailVerifiedAt),
+			formatExportTime(&user.CreatedAt),
+			formatExportTime(&user.UpdatedAt),
+			formatExportTime(user.DeletedAt),
+		})
+	}
+	flush := func() error {
+		// File kosong tetap berisi header
+		if !headerWritten {
+			if err := writer.Write(exportCSVColumns); err != nil {
+				return err
+			}
+			headerWritten = true
+		}
+		writer.Flush()
+		w.Flush()
+		return writer.Error()
+	}
+	return writeUser, flush
+}
+
+// Helper function untuk membaca format dari parameter format, atau dari Content-Type untuk import
+func bulkFormatParam(c *gin.Context, fromContentType bool) (string, bool) {
+	format := strings.ToLower(c.Query("format"))
+	if format == "" && fromContentType {
+		mediaType, _, _ := mime.ParseMediaType(c.GetHeader("Content-Type"))
+		switch mediaType {
+		case "text/csv":
+			format = BulkFormatCSV
+		case "application/x-ndjson", "application/ndjson":
+			format = BulkFormatNDJSON
+		}
+	}
+	if format == "" && !fromContentType {
+		format = BulkFormatCSV
+	}
+
+	if format != BulkFormatCSV && format != BulkFormatNDJSON {
+		utils.BadRequestResponse(c, "Invalid format", ErrUnsupportedBulkFormat.Error())
+		return "", false
+	}
+	return format, true
+}
+
+// Helper function untuk response file import yang terlalu besar
+func importTooLargeResponse(c *gin.Context, maxSize int64) {
+	utils.ErrorResponse(c, http.StatusRequestEntityTooLarge, "PAYLOAD_TOO_LARGE", "Import file is too large",
+		fmt.Sprintf("Maximum import size is %d bytes", maxSize))
+}
+
+// Helper function untuk parsing ID job import dari URL, response error sudah dikirim jika gagal
+func parseImportJobID(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.BadRequestResponse(c, "Invalid import job ID format", "Import job ID must be a number")
+		return 0, false
+	}
+	return id, true
+}
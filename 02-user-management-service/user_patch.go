@@ -0,0 +1,208 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/jizak1/Microservices-Golang/shared/utils"
+)
+
+// mergePatchContentType media type JSON Merge Patch (RFC 7396)
+const mergePatchContentType = "application/merge-patch+json"
+
+// maxPatchBodySize batas ukuran body PATCH /users/:id
+const maxPatchBodySize = 64 << 10
+
+// UserUpdate perubahan yang diterapkan ke user. ClearFullName dipakai merge patch untuk
+// mengosongkan full_name (nilai null), karena string kosong di Fields berarti tidak diubah.
+type UserUpdate struct {
+	Fields        UpdateUserRequest
+	ClearFullName bool
+}
+
+// ParseUserMergePatch membaca dokumen JSON Merge Patch untuk user. Hanya field yang bisa diubah
+// lewat PUT yang boleh ada di patch, semua error validasi dikumpulkan.
+func ParseUserMergePatch(body []byte) (UserUpdate, []string) {
+	var update UserUpdate
+	var document map[string]json.RawMessage
+	if err := json.Unmarshal(body, &document); err != nil || document == nil {
+		return update, []string{"merge patch must be a JSON object"}
+	}
+
+	// Urutkan key supaya pesan error konsisten
+	keys := make([]string, 0, len(document))
+	for key := range document {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var violations []string
+	for _, key := range keys {
+		value := document[key]
+		isNull := string(value) == "null"
+
+		switch key {
+		case "username", "email":
+			if isNull {
+				violations = append(violations, fmt.Sprintf("%s cannot be removed", key))
+				continue
+			}
+			var s string
+			if err := json.Unmarshal(value, &s); err != nil {
+				violations = append(violations, fmt.Sprintf("%s must be a string", key))
+				continue
+			}
+			if strings.TrimSpace(s) == "" {
+				violations = append(violations, fmt.Sprintf("%s cannot be empty", key))
+				continue
+			}
+			if key == "username" {
+				update.Fields.Username = s
+			} else {
+				update.Fields.Email = s
+			}
+		case "full_name":
+			if isNull {
+				update.ClearFullName = true
+				continue
+			}
+			if err := json.Unmarshal(value, &update.Fields.FullName); err != nil {
+				violations = append(violations, "full_name must be a string or null")
+				continue
+			}
+			// String kosong diperlakukan sama dengan null
+			update.ClearFullName = update.Fields.FullName == ""
+		case "is_active":
+			if isNull {
+				violations = append(violations, "is_active cannot be removed")
+				continue
+			}
+			var isActive bool
+			if err := json.Unmarshal(value, &isActive); err != nil {
+				violations = append(violations, "is_active must be a boolean")
+				continue
+			}
+			update.Fields.IsActive = &isActive
+		default:
+			violations = append(violations, fmt.Sprintf("field %q cannot be changed", key))
+		}
+	}
+
+	if len(violations) > 0 {
+		return update, violations
+	}
+
+	if err := binding.Validator.ValidateStruct(&update.Fields); err != nil {
+		return update, []string{err.Error()}
+	}
+	return update, nil
+}
+
+// PatchUser handler untuk PATCH /users/:id dengan semantik JSON Merge Patch (RFC 7396).
+// Header If-Match wajib diisi dengan ETag dari GET /users/:id supaya perubahan user lain tidak tertimpa.
+func (uh *UserHandler) PatchUser(c *gin.Context) {
+	id, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+
+	mediaType, _, err := mime.ParseMediaType(c.GetHeader("Content-Type"))
+	if err != nil || (mediaType != mergePatchContentType && mediaType != "application/json") {
+		utils.ErrorResponse(c, http.StatusUnsupportedMediaType, "UNSUPPORTED_MEDIA_TYPE", "Unsupported content type",
+			"Use Content-Type: "+mergePatchContentType)
+		return
+	}
+
+	if c.GetHeader("If-Match") == "" {
+		utils.ErrorResponse(c, http.StatusPreconditionRequired, "PRECONDITION_REQUIRED", "If-Match header is required",
+			"Send the ETag from GET /users/:id in the If-Match header")
+		return
+	}
+	expectedVersion, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxPatchBodySize+1))
+	if err != nil {
+		utils.BadRequestResponse(c, "Failed to read request body", err.Error())
+		return
+	}
+	if len(body) > maxPatchBodySize {
+		utils.BadRequestResponse(c, "Request body too large", fmt.Sprintf("Merge patch must be at most %d bytes", maxPatchBodySize))
+		return
+	}
+
+	update, violations := ParseUserMergePatch(body)
+	if len(violations) > 0 {
+		utils.ValidationErrorResponse(c, violations)
+		return
+	}
+
+	if !uh.canChangeIsActive(c, update.Fields) {
+		return
+	}
+
+	user, err := uh.service.UpdateUser(id, update, expectedVersion)
+	if err != nil {
+		uh.handleUpdateError(c, id, err)
+		return
+	}
+
+	setUserETag(c, user)
+	utils.SuccessResponse(c, "User updated successfully", user)
+}
+
+// userETag membuat ETag dari version user
+func userETag(user *User) string {
+	return strconv.Quote(strconv.Itoa(user.Version))
+}
+
+// Helper function untuk menambahkan header ETag ke response
+func setUserETag(c *gin.Context, user *User) {
+	c.Header("ETag", userETag(user))
+}
+
+// parseIfMatch membaca version dari header If-Match. Header kosong atau "*" berarti tanpa pengecekan
+// version. Hanya satu strong ETag yang didukung karena satu user hanya punya satu version.
+func parseIfMatch(header string) (*int, error) {
+	header = strings.TrimSpace(header)
+	if header == "" || header == "*" {
+		return nil, nil
+	}
+
+	if strings.HasPrefix(header, "W/") {
+		return nil, fmt.Errorf("If-Match must be a strong ETag")
+	}
+	if strings.Contains(header, ",") {
+		return nil, fmt.Errorf("If-Match must contain a single ETag")
+	}
+
+	value, err := strconv.Unquote(header)
+	if err != nil || !strings.HasPrefix(header, `"`) {
+		return nil, fmt.Errorf("If-Match must be a quoted ETag, e.g. \"3\"")
+	}
+	version, err := strconv.Atoi(value)
+	if err != nil || version < 1 {
+		return nil, fmt.Errorf("If-Match does not contain a valid user ETag")
+	}
+	return &version, nil
+}
+
+// Helper function untuk membaca If-Match dari request, response error sudah dikirim jika gagal
+func ifMatchVersion(c *gin.Context) (*int, bool) {
+	version, err := parseIfMatch(c.GetHeader("If-Match"))
+	if err != nil {
+		utils.BadRequestResponse(c, "Invalid If-Match header", err.Error())
+		return nil, false
+	}
+	return version, true
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseUserMergePatch(t *testing.T) {
+	active := true
+	inactive := false
+
+	tests := []struct {
+		name           string
+		body           string
+		want           UserUpdate
+		wantViolations []string
+		wantInvalid    bool // Ditolak oleh validator struct, isi pesannya tidak dicek
+	}{
+		{
+			name: "empty patch",
+			body: `{}`,
+			want: UserUpdate{},
+		},
+		{
+			name: "all fields",
+			body: `{"username": "johndoe", "email": "john@example.com", "full_name": "John Doe", "is_active": false}`,
+			want: UserUpdate{Fields: UpdateUserRequest{Username: "johndoe", Email: "john@example.com", FullName: "John Doe", IsActive: &inactive}},
+		},
+		{
+			name: "activate user",
+			body: `{"is_active": true}`,
+			want: UserUpdate{Fields: UpdateUserRequest{IsActive: &active}},
+		},
+		{
+			name: "null full_name clears it",
+			body: `{"full_name": null}`,
+			want: UserUpdate{ClearFullName: true},
+		},
+		{
+			name: "empty full_name clears it",
+			body: `{"full_name": ""}`,
+			want: UserUpdate{ClearFullName: true},
+		},
+		{
+			name:           "not an object",
+			body:           `["username"]`,
+			wantViolations: []string{"merge patch must be a JSON object"},
+		},
+		{
+			name:           "null document",
+			body:           `null`,
+			wantViolations: []string{"merge patch must be a JSON object"},
+		},
+		{
+			name:           "invalid JSON",
+			body:           `{"username": `,
+			wantViolations: []string{"merge patch must be a JSON object"},
+		},
+		{
+			name:           "violations are collected in key order",
+			body:           `{"username": null, "password": "secret", "email": 5}`,
+			wantViolations: []string{"email must be a string", `field "password" cannot be changed`, "username cannot be removed"},
+		},
+		{
+			name:           "blank username",
+			body:           `{"username": "   "}`,
+			wantViolations: []string{"username cannot be empty"},
+		},
+		{
+			name:           "email cannot be removed",
+			body:           `{"email": null}`,
+			wantViolations: []string{"email cannot be removed"},
+		},
+		{
+			name:           "full_name wrong type",
+			body:           `{"full_name": 3}`,
+			wantViolations: []string{"full_name must be a string or null"},
+		},
+		{
+			name:           "is_active cannot be removed",
+			body:           `{"is_active": null}`,
+			wantViolations: []string{"is_active cannot be removed"},
+		},
+		{
+			name:           "is_active wrong type",
+			body:           `{"is_active": "yes"}`,
+			wantViolations: []string{"is_active must be a boolean"},
+		},
+		{
+			name:           "read-only fields",
+			body:           `{"id": 2, "roles": ["admin"]}`,
+			wantViolations: []string{`field "id" cannot be changed`, `field "roles" cannot be changed`},
+		},
+		{
+			name:        "username too short",
+			body:        `{"username": "ab"}`,
+			wantInvalid: true,
+		},
+		{
+			name:        "invalid email",
+			body:        `{"email": "not-an-email"}`,
+			wantInvalid: true,
+		},
+		{
+			name:        "full_name too short",
+			body:        `{"full_name": "J"}`,
+			wantInvalid: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, violations := ParseUserMergePatch([]byte(tt.body))
+
+			if tt.wantInvalid {
+				if len(violations) != 1 {
+					t.Fatalf("ParseUserMergePatch() violations = %v, want one validation error", violations)
+				}
+				return
+			}
+
+			if !reflect.DeepEqual(violations, tt.wantViolations) {
+				t.Fatalf("ParseUserMergePatch() violations = %q, want %q", violations, tt.wantViolations)
+			}
+			if tt.wantViolations == nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseUserMergePatch() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseIfMatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  string
+		want    int // 0 berarti tanpa pengecekan version
+		wantErr bool
+	}{
+		{name: "empty", header: ""},
+		{name: "whitespace only", header: "   "},
+		{name: "wildcard", header: "*"},
+		{name: "strong ETag", header: `"3"`, want: 3},
+		{name: "surrounding whitespace", header: ` "12" `, want: 12},
+		{name: "weak ETag", header: `W/"3"`, wantErr: true},
+		{name: "multiple ETags", header: `"3", "4"`, wantErr: true},
+		{name: "unquoted", header: `3`, wantErr: true},
+		{name: "single quoted", header: `'3'`, wantErr: true},
+		{name: "backquoted", header: "`3`", wantErr: true},
+		{name: "unterminated quote", header: `"3`, wantErr: true},
+		{name: "not a number", header: `"abc"`, wantErr: true},
+		{name: "zero version", header: `"0"`, wantErr: true},
+		{name: "negative version", header: `"-1"`, wantErr: true},
+		{name: "empty ETag", header: `""`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseIfMatch(tt.header)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseIfMatch(%q) error = %v, wantErr %v", tt.header, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if tt.want == 0 {
+				if got != nil {
+					t.Errorf("parseIfMatch(%q) = %d, want nil", tt.header, *got)
+				}
+				return
+			}
+			if got == nil || *got != tt.want {
+				t.Errorf("parseIfMatch(%q) = %v, want %d", tt.header, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUserETagRoundTrip(t *testing.T) {
+	for _, version := range []int{1, 7, 1000} {
+		etag := userETag(&User{Version: version})
+
+		got, err := parseIfMatch(etag)
+		if err != nil || got == nil || *got != version {
+			t.Errorf("parseIfMatch(userETag(%d)) = %v, %v, want %d", version, got, err, version)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// DeletedIdentityPolicy menentukan apakah username/email user yang dihapus boleh dipakai user lain
+type DeletedIdentityPolicy string
+
+// Policy username/email user yang dihapus
+const (
+	// DeletedIdentityReserve username/email tetap dipesan sampai user di-purge, supaya user bisa di-restore
+	DeletedIdentityReserve DeletedIdentityPolicy = "reserve"
+	// DeletedIdentityRelease username/email langsung bebas dipakai, restore gagal jika sudah diambil user lain
+	DeletedIdentityRelease DeletedIdentityPolicy = "release"
+)
+
+// purgeBatchSize jumlah user yang dihapus permanen per query, supaya lock tabel tidak terlalu lama
+const purgeBatchSize = 500
+
+// ParseDeletedIdentityPolicy memvalidasi nilai USER_DELETED_IDENTITY_POLICY
+func ParseDeletedIdentityPolicy(value string) (DeletedIdentityPolicy, error) {
+	switch policy := DeletedIdentityPolicy(value); policy {
+	case DeletedIdentityReserve, DeletedIdentityRelease:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unknown deleted identity policy %q (expected %q or %q)",
+			value, DeletedIdentityReserve, DeletedIdentityRelease)
+	}
+}
+
+// UserPurger job background untuk menghapus permanen user yang sudah melewati masa retensi
+type UserPurger struct {
+	repo      *UserRepository
+	retention time.Duration
+	logger    *logrus.Logger
+}
+
+// NewUserPurger membuat instance baru UserPurger
+func NewUserPurger(repo *UserRepository, retention time.Duration, logger *logrus.Logger) *UserPurger {
+	return &UserPurger{
+		repo:      repo,
+		retention: retention,
+		logger:    logger,
+	}
+}
+
+// PurgeExpired menghapus permanen semua user yang dihapus lebih lama dari masa retensi.
+// Aman dijalankan bersamaan di beberapa instance karena tiap batch hanya menghapus baris yang masih ada.
+func (up *UserPurger) PurgeExpired() (int64, error) {
+	deletedBefore := time.Now().Add(-up.retention)
+
+	var total int64
+	for {
+		purged, err := up.repo.PurgeDeletedUsers(deletedBefore, purgeBatchSize)
+		if err != nil {
+			return total, err
+		}
+		total += purged
+
+		if purged < purgeBatchSize {
+			return total, nil
+		}
+	}
+}
+
+// Run menjalankan PurgeExpired setiap interval sampai context dibatalkan
+func (up *UserPurger) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		up.purge()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Helper function untuk satu kali purge dengan logging
+func (up *UserPurger) purge() {
+	purged, err := up.PurgeExpired()
+	if err != nil {
+		up.logger.WithError(err).Error("Failed to purge deleted users")
+		return
+	}
+
+	if purged > 0 {
+		up.logger.WithFields(logrus.Fields{
+			"purged":    purged,
+			"retention": up.retention.String(),
+		}).Info("Deleted users purged")
+	}
+}
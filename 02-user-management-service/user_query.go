@@ -0,0 +1,244 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Mode pencarian untuk parameter q
+const (
+	SearchModeContains = "contains"
+	SearchModePrefix   = "prefix"
+)
+
+// maxSearchLength batas panjang parameter q
+const maxSearchLength = 100
+
+// userSortFields allowlist field yang boleh dipakai di parameter sort, key adalah nama di API
+var userSortFields = map[string]string{
+	"id":         "id",
+	"username":   "username",
+	"email":      "email",
+	"full_name":  "full_name",
+	"created_at": "created_at",
+	"updated_at": "updated_at",
+}
+
+// UserSort satu field sorting
+type UserSort struct {
+	Field      string
+	Descending bool
+}
+
+// UserListFilter filter, pencarian dan sorting untuk GET /users
+type UserListFilter struct {
+	IsActive       *bool
+	Role           string
+	CreatedFrom    *time.Time // Inklusif
+	CreatedTo      *time.Time // Eksklusif
+	Search         string
+	SearchMode     string
+	Sort           []UserSort
+	IncludeDeleted bool
+}
+
+// DefaultUserSort urutan default jika parameter sort tidak diisi
+var DefaultUserSort = []UserSort{{Field: "created_at", Descending: true}}
+
+// ParseUserListFilter membaca filter dari query string. Semua error validasi dikumpulkan supaya
+// client bisa memperbaiki semuanya sekaligus.
+//
+// Contoh: ?is_active=true&role=admin&created_from=2024-01-01&created_to=2024-01-31&q=john&sort=-created_at,username
+func ParseUserListFilter(c *gin.Context) (UserListFilter, []string) {
+	filter := UserListFilter{SearchMode: SearchModeContains, Sort: DefaultUserSort}
+	var violations []string
+
+	if value := c.Query("is_active"); value != "" {
+		isActive, err := strconv.ParseBool(value)
+		if err != nil {
+			violations = append(violations, "is_active must be true or false")
+		} else {
+			filter.IsActive = &isActive
+		}
+	}
+
+	if value := strings.TrimSpace(c.Query("role")); value != "" {
+		if !roleNamePattern.MatchString(value) {
+			violations = append(violations, "role is not a valid role name")
+		} else {
+			filter.Role = value
+		}
+	}
+
+	if value := c.Query("created_from"); value != "" {
+		from, _, err := parseDateParam(value)
+		if err != nil {
+			violations = append(violations, "created_from must be a date (2006-01-02) or RFC3339 timestamp")
+		} else {
+			filter.CreatedFrom = &from
+		}
+	}
+
+	if value := c.Query("created_to"); value != "" {
+		to, dateOnly, err := parseDateParam(value)
+		if err != nil {
+			violations = append(violations, "created_to must be a date (2006-01-02) or RFC3339 timestamp")
+		} else {
+			// Tanggal tanpa jam berarti sampai akhir hari tersebut
+			if dateOnly {
+				to = to.AddDate(0, 0, 1)
+			}
+			filter.CreatedTo = &to
+		}
+	}
+
+	if filter.CreatedFrom != nil && filter.CreatedTo != nil && !filter.CreatedFrom.Before(*filter.CreatedTo) {
+		violations = append(violations, "created_from must be before created_to")
+	}
+
+	filter.Search = strings.TrimSpace(c.Query("q"))
+	if len(filter.Search) > maxSearchLength {
+		violations = append(violations, fmt.Sprintf("q must be at most %d characters", maxSearchLength))
+	}
+
+	if value := c.Query("match"); value != "" {
+		if value != SearchModeContains && value != SearchModePrefix {
+			violations = append(violations, fmt.Sprintf("match must be %q or %q", SearchModeContains, SearchModePrefix))
+		} else {
+			filter.SearchMode = value
+		}
+	}
+
+	if value := c.Query("sort"); value != "" {
+		sort, err := parseUserSort(value)
+		if err != nil {
+			violations = append(violations, err.Error())
+		} else {
+			filter.Sort = sort
+		}
+	}
+
+	return filter, violations
+}
+
+// whereClause membuat kondisi WHERE dan argumen query dari filter
+func (f UserListFilter) whereClause() (string, []interface{}) {
+	conditions := []string{}
+	args := []interface{}{}
+	addArg := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if !f.IncludeDeleted {
+		conditions = append(conditions, "deleted_at IS NULL")
+	}
+
+	if f.IsActive != nil {
+		conditions = append(conditions, "is_active = "+addArg(*f.IsActive))
+	}
+
+	if f.Role != "" {
+		conditions = append(conditions, `EXISTS (SELECT 1 FROM user_roles ur JOIN roles r ON r.id = ur.role_id
+			WHERE ur.user_id = users.id AND r.name = `+addArg(f.Role)+`)`)
+	}
+
+	if f.CreatedFrom != nil {
+		conditions = append(conditions, "created_at >= "+addArg(*f.CreatedFrom))
+	}
+	if f.CreatedTo != nil {
+		conditions = append(conditions, "created_at < "+addArg(*f.CreatedTo))
+	}
+
+	// ILIKE memakai trigram index (pg_trgm) untuk pola prefix maupun substring
+	if f.Search != "" {
+		pattern := escapeLikePattern(f.Search) + "%"
+		if f.SearchMode == SearchModeContains {
+			pattern = "%" + pattern
+		}
+		placeholder := addArg(pattern)
+		conditions = append(conditions, fmt.Sprintf("(username ILIKE %[1]s OR email ILIKE %[1]s OR full_name ILIKE %[1]s)", placeholder))
+	}
+
+	if len(conditions) == 0 {
+		return "", args
+	}
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// orderClause membuat ORDER BY dari sort, id selalu ditambahkan supaya pagination stabil
+func (f UserListFilter) orderClause() string {
+	sort := f.Sort
+	if len(sort) == 0 {
+		sort = DefaultUserSort
+	}
+
+	parts := []string{}
+	hasID := false
+	for _, s := range sort {
+		direction := "ASC"
+		if s.Descending {
+			direction = "DESC"
+		}
+		parts = append(parts, userSortFields[s.Field]+" "+direction)
+		hasID = hasID || s.Field == "id"
+	}
+	if !hasID {
+		parts = append(parts, "id ASC")
+	}
+
+	return " ORDER BY " + strings.Join(parts, ", ")
+}
+
+// Helper function untuk parsing sort=-created_at,username
+func parseUserSort(value string) ([]UserSort, error) {
+	sort := []UserSort{}
+	seen := map[string]bool{}
+
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		s := UserSort{Field: part}
+		if strings.HasPrefix(part, "-") {
+			s = UserSort{Field: part[1:], Descending: true}
+		} else if strings.HasPrefix(part, "+") {
+			s.Field = part[1:]
+		}
+
+		if _, ok := userSortFields[s.Field]; !ok {
+			return nil, fmt.Errorf("sort field %q is not allowed (allowed: id, username, email, full_name, created_at, updated_at)", s.Field)
+		}
+		if seen[s.Field] {
+			return nil, fmt.Errorf("sort field %q is specified more than once", s.Field)
+		}
+		seen[s.Field] = true
+		sort = append(sort, s)
+	}
+
+	if len(sort) == 0 {
+		return nil, fmt.Errorf("sort must contain at least one field")
+	}
+	return sort, nil
+}
+
+// Helper function untuk parsing tanggal (2006-01-02) atau timestamp RFC3339
+func parseDateParam(value string) (time.Time, bool, error) {
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, true, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	return t, false, err
+}
+
+// Helper function untuk escape karakter wildcard LIKE dari input user
+func escapeLikePattern(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return replacer.Replace(value)
+}
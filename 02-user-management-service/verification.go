@@ -0,0 +1,278 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jizak1/Microservices-Golang/shared/database"
+	"github.com/jizak1/Microservices-Golang/shared/utils"
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+// ErrVerificationTokenInvalid token tidak ada, sudah dipakai, expired, atau email user sudah berubah
+var ErrVerificationTokenInvalid = errors.New("verification token is invalid or expired")
+
+// VerifyEmailRequest untuk request body verifikasi email
+type VerifyEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// ResendVerificationRequest untuk request body kirim ulang email verifikasi
+type ResendVerificationRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// EmailVerificationRepository untuk database operations token verifikasi email
+type EmailVerificationRepository struct {
+	db     *sqlx.DB
+	logger *logrus.Logger
+}
+
+// NewEmailVerificationRepository membuat instance baru EmailVerificationRepository
+func NewEmailVerificationRepository(db *sqlx.DB, logger *logrus.Logger) *EmailVerificationRepository {
+	return &EmailVerificationRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// CreateToken menyimpan hash token baru. Token lama yang belum dipakai langsung tidak berlaku.
+func (vr *EmailVerificationRepository) CreateToken(userID int, email, tokenHash string, expiresAt time.Time) error {
+	tx, err := vr.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`UPDATE email_verification_tokens SET used_at = $1
+		WHERE user_id = $2 AND used_at IS NULL`, time.Now(), userID); err != nil {
+		return fmt.Errorf("failed to invalidate verification tokens: %w", err)
+	}
+
+	if _, err := tx.Exec(`INSERT INTO email_verification_tokens (user_id, email, token_hash, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5)`, userID, email, tokenHash, expiresAt, time.Now()); err != nil {
+		vr.logger.WithError(err).WithField("user_id", userID).Error("Failed to create verification token")
+		return fmt.Errorf("failed to create verification token: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// ConsumeToken memakai token (sekali pakai) dan menandai email user sebagai terverifikasi
+func (vr *EmailVerificationRepository) ConsumeToken(tokenHash string) (int, error) {
+	tx, err := vr.db.Beginx()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var token struct {
+		ID        int64      `db:"id"`
+		UserID    int        `db:"user_id"`
+		Email     string     `db:"email"`
+		ExpiresAt time.Time  `db:"expires_at"`
+		UsedAt    *time.Time `db:"used_at"`
+	}
+
+	query := `SELECT id, user_id, email, expires_at, used_at
+			  FROM email_verification_tokens WHERE token_hash = $1 FOR UPDATE`
+	if err := tx.Get(&token, query, tokenHash); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, ErrVerificationTokenInvalid
+		}
+		return 0, fmt.Errorf("failed to get verification token: %w", err)
+	}
+
+	now := time.Now()
+	if token.UsedAt != nil || now.After(token.ExpiresAt) {
+		return 0, ErrVerificationTokenInvalid
+	}
+
+	if _, err := tx.Exec(`UPDATE email_verification_tokens SET used_at = $1 WHERE id = $2`, now, token.ID); err != nil {
+		return 0, fmt.Errorf("failed to consume verification token: %w", err)
+	}
+
+	// Token hanya berlaku untuk email saat token dibuat
+	result, err := tx.Exec(`UPDATE users SET email_verified_at = $1, updated_at = $1, version = version + 1
+		WHERE id = $2 AND email = $3`, now, token.UserID, token.Email)
+	if err != nil {
+		return 0, fmt.Errorf("failed to mark email as verified: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		return 0, ErrVerificationTokenInvalid
+	}
+
+	return token.UserID, nil
+}
+
+// EmailVerificationService untuk business logic verifikasi email
+type EmailVerificationService struct {
+	repo           *EmailVerificationRepository
+	userRepo       *UserRepository
+	mailer         utils.Mailer
+	redis          *database.RedisClient
+	baseURL        string
+	tokenTTL       time.Duration
+	resendCooldown time.Duration
+	logger         *logrus.Logger
+}
+
+// NewEmailVerificationService membuat instance baru EmailVerificationService
+func NewEmailVerificationService(repo *EmailVerificationRepository, userRepo *UserRepository, mailer utils.Mailer,
+	redis *database.RedisClient, baseURL string, tokenTTL, resendCooldown time.Duration, logger *logrus.Logger) *EmailVerificationService {
+	return &EmailVerificationService{
+		repo:           repo,
+		userRepo:       userRepo,
+		mailer:         mailer,
+		redis:          redis,
+		baseURL:        baseURL,
+		tokenTTL:       tokenTTL,
+		resendCooldown: resendCooldown,
+		logger:         logger,
+	}
+}
+
+// SendVerification membuat token baru dan mengirim link verifikasi ke email user
+func (vs *EmailVerificationService) SendVerification(user *User) error {
+	token, err := utils.GenerateSecureToken(32)
+	if err != nil {
+		return fmt.Errorf("failed to generate verification token: %w", err)
+	}
+
+	if err := vs.repo.CreateToken(user.ID, user.Email, utils.HashToken(token), time.Now().Add(vs.tokenTTL)); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	message := utils.EmailMessage{
+		To:      []string{user.Email},
+		Subject: "Verify your email address",
+		TextBody: fmt.Sprintf("Hi %s,\n\nPlease verify your email address by opening the link below:\n\n%s/verify-email?token=%s\n\n"+
+			"This link expires in %s. If you did not create an account, you can ignore this email.\n",
+			user.FullName, vs.baseURL, token, vs.tokenTTL),
+	}
+
+	if err := vs.mailer.Send(ctx, message); err != nil {
+		vs.logger.WithError(err).WithField("user_id", user.ID).Error("Failed to send verification email")
+		return err
+	}
+
+	vs.logger.WithField("user_id", user.ID).Info("Verification email sent")
+	return nil
+}
+
+// Verify memverifikasi email dengan token dari link
+func (vs *EmailVerificationService) Verify(token string) (*User, error) {
+	userID, err := vs.repo.ConsumeToken(utils.HashToken(token))
+	if err != nil {
+		return nil, err
+	}
+
+	return vs.userRepo.GetUserByID(userID)
+}
+
+// Resend mengirim ulang email verifikasi di background dan langsung return, supaya waktu respon sama
+// untuk email yang tidak terdaftar, sudah terverifikasi, atau sedang cooldown (endpoint tidak bisa
+// dipakai untuk mengecek email). Error hanya di-log.
+func (vs *EmailVerificationService) Resend(email string) {
+	go func() {
+		if err := vs.resend(email); err != nil {
+			vs.logger.WithError(err).Error("Failed to resend verification email")
+		}
+	}()
+}
+
+// Helper function untuk cek status user dan cooldown sebelum mengirim ulang email verifikasi
+func (vs *EmailVerificationService) resend(email string) error {
+	user, err := vs.userRepo.GetUserByEmail(email)
+	if err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	if user.EmailVerifiedAt != nil || !user.IsActive {
+		return nil
+	}
+
+	// Cooldown per user supaya inbox user tidak dibanjiri email
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cooldownKey := "email_verification:cooldown:" + strconv.Itoa(user.ID)
+	acquired, err := vs.redis.Client.SetNX(ctx, cooldownKey, "1", vs.resendCooldown).Result()
+	if err != nil {
+		return fmt.Errorf("failed to check resend cooldown: %w", err)
+	}
+	if !acquired {
+		vs.logger.WithField("user_id", user.ID).Info("Verification email resend skipped, cooldown active")
+		return nil
+	}
+
+	return vs.SendVerification(user)
+}
+
+// VerificationHandler untuk HTTP handlers verifikasi email
+type VerificationHandler struct {
+	service *EmailVerificationService
+	logger  *logrus.Logger
+}
+
+// NewVerificationHandler membuat instance baru VerificationHandler
+func NewVerificationHandler(service *EmailVerificationService, logger *logrus.Logger) *VerificationHandler {
+	return &VerificationHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// VerifyEmail handler untuk POST /auth/verify-email
+func (vh *VerificationHandler) VerifyEmail(c *gin.Context) {
+	var req VerifyEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestResponse(c, "Invalid JSON format", err.Error())
+		return
+	}
+
+	user, err := vh.service.Verify(req.Token)
+	if err != nil {
+		if errors.Is(err, ErrVerificationTokenInvalid) {
+			utils.BadRequestResponse(c, "Invalid verification token", err.Error())
+			return
+		}
+		vh.logger.WithError(err).Error("Failed to verify email")
+		utils.InternalServerErrorResponse(c, "Failed to verify email")
+		return
+	}
+
+	utils.SuccessResponse(c, "Email verified successfully", user)
+}
+
+// ResendVerification handler untuk POST /auth/verify-email/resend
+func (vh *VerificationHandler) ResendVerification(c *gin.Context) {
+	var req ResendVerificationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestResponse(c, "Invalid JSON format", err.Error())
+		return
+	}
+
+	vh.service.Resend(req.Email)
+	utils.SuccessResponse(c, "If the email is registered and not yet verified, a verification link has been sent", nil)
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -34,10 +35,101 @@ type AppConfig struct {
 	RedisPort     string
 	RedisPassword string
 	RedisDB       int
+	RedisCodec    string
+	RedisCompression          string
+	RedisCompressionThreshold int
+	RedisMaxDecompressedSize  int // Byte
 	
 	// JWT settings
 	JWTSecret     string
 	JWTExpiration time.Duration
+	JWTIssuer     string
+	JWTAudience   []string
+	JWTLeeway     time.Duration
+	RefreshTokenExpiration time.Duration
+	// Kosongkan private key path untuk memakai HS256 dengan JWTSecret
+	JWTPrivateKeyPath       string
+	JWTVerificationKeyPaths []string
+	
+	// Service-to-service auth (client credentials)
+	// Format registry: "client_id|secret_sha256|scope1 scope2|audience1 audience2;..."
+	ServiceClients          string
+	ServiceTokenExpiration  time.Duration
+	
+	// Authorization settings, format: "admin=*;user=users:read,users:update"
+	RolePermissions map[string][]string
+	
+	// Session settings (cookie session untuk web client)
+	SessionIdleTimeout     time.Duration
+	SessionAbsoluteTimeout time.Duration
+	SessionCookieSecure    bool
+	
+	// Email settings, driver: smtp, file (outbox lokal) atau memory
+	MailerDriver  string
+	MailFrom      string
+	SMTPHost      string
+	SMTPPort      string
+	SMTPUsername  string
+	SMTPPassword  string
+	MailOutboxDir string
+	AppBaseURL    string // Base URL frontend untuk link di email
+	EmailVerificationTTL            time.Duration
+	EmailVerificationResendCooldown time.Duration
+	PasswordResetTTL                time.Duration
+	
+	// Password settings, algoritma hash: argon2id atau bcrypt
+	PasswordMinLength         int
+	PasswordMaxLength         int
+	PasswordMinCharClasses    int
+	PasswordCommonListPath    string
+	PasswordHashAlgorithm     string
+	PasswordBcryptCost        int
+	PasswordArgon2Memory      int // Dalam KiB
+	PasswordArgon2Iterations  int
+	PasswordArgon2Parallelism int
+	
+	// MFA (TOTP) settings
+	MFAIssuer        string
+	MFAEncryptionKey string // Passphrase untuk enkripsi secret TOTP di database
+	MFAChallengeTTL  time.Duration
+	
+	// Brute-force protection login
+	LoginMaxFailures     int
+	LoginIPMaxFailures   int
+	LoginFailureWindow   time.Duration
+	LoginLockoutDuration time.Duration
+	LoginDelayBase       time.Duration
+	LoginDelayMax        time.Duration
+	
+	// Soft delete user
+	UserDeletedRetention      time.Duration // Lama user yang dihapus disimpan sebelum di-purge permanen
+	UserPurgeInterval         time.Duration
+	UserDeletedIdentityPolicy string // "reserve" atau "release" username/email user yang dihapus
+	
+	// Domain event, driver: redis (stream) atau memory
+	EventPublisherDriver   string
+	EventStream            string
+	EventStreamMaxLength   int
+	EventOutboxInterval    time.Duration // Interval pengiriman event dari tabel outbox
+	EventOutboxMaxAttempts int           // Percobaan kirim sebelum event dipindah ke dead letter
+	
+	// Bulk import user
+	UserImportMaxSize   int // Ukuran maksimal file import dalam byte
+	UserImportBatchSize int // Jumlah baris per transaksi import
+	
+	// API key milik user
+	APIKeyMaxLifetime time.Duration // Batas masa berlaku API key, juga dipakai jika expires_at tidak diisi
+	APIKeyMaxPerUser  int           // Jumlah maksimal API key aktif per user
+	
+	// Rate limit settings
+	RateLimitRequestsPerSecond       int
+	RateLimitBurst                   int
+	RateLimitUserRequestsPerSecond   int // Limit per user login, dicek setelah authentication
+	RateLimitUserBurst               int
+	RateLimitAPIKeyRequestsPerSecond int // Limit per API key, dicek setelah authentication
+	RateLimitAPIKeyBurst             int
+	RateLimitAllowlist               []string // IP/CIDR yang tidak dibatasi, dicocokkan dengan alamat koneksi langsung
+	TrustedProxies                   []string // IP/CIDR reverse proxy yang header X-Forwarded-For-nya dipercaya
 	
 	// External services
 	UserServiceURL    string
@@ -87,10 +179,102 @@ func LoadConfig() (*AppConfig, error) {
 		RedisPort:     getEnvOrDefault("REDIS_PORT", "6379"),
 		RedisPassword: getEnvOrDefault("REDIS_PASSWORD", ""),
 		RedisDB:       getIntOrDefault("REDIS_DB", 0),
+		RedisCodec:    getEnvOrDefault("REDIS_CODEC", "json"),
+		RedisCompression:          getEnvOrDefault("REDIS_COMPRESSION", "none"),
+		RedisCompressionThreshold: getIntOrDefault("REDIS_COMPRESSION_THRESHOLD", 1024),
+		RedisMaxDecompressedSize:  getIntOrDefault("REDIS_MAX_DECOMPRESSED_SIZE", 16<<20),
 		
 		// JWT defaults
 		JWTSecret:     getEnvOrDefault("JWT_SECRET", "your-super-secret-key-change-in-production"),
 		JWTExpiration: getDurationOrDefault("JWT_EXPIRATION", "24h"),
+		JWTIssuer:     getEnvOrDefault("JWT_ISSUER", "microservices-golang"),
+		JWTAudience:   getStringSliceOrDefault("JWT_AUDIENCE", []string{"microservices-golang"}),
+		JWTLeeway:     getDurationOrDefault("JWT_LEEWAY", "30s"),
+		RefreshTokenExpiration: getDurationOrDefault("REFRESH_TOKEN_EXPIRATION", "720h"),
+		JWTPrivateKeyPath:       getEnvOrDefault("JWT_PRIVATE_KEY_PATH", ""),
+		JWTVerificationKeyPaths: getStringSliceOrDefault("JWT_VERIFICATION_KEY_PATHS", []string{}),
+		
+		// Service-to-service defaults
+		ServiceClients:         getEnvOrDefault("SERVICE_CLIENTS", ""),
+		ServiceTokenExpiration: getDurationOrDefault("SERVICE_TOKEN_EXPIRATION", "5m"),
+		
+		// Authorization defaults
+		RolePermissions: getRolePermissionsOrDefault("RBAC_ROLE_PERMISSIONS", map[string][]string{
+			"admin": {"*"},
+			"user":  {"users:read"},
+		}),
+		
+		// Session defaults
+		SessionIdleTimeout:     getDurationOrDefault("SESSION_IDLE_TIMEOUT", "30m"),
+		SessionAbsoluteTimeout: getDurationOrDefault("SESSION_ABSOLUTE_TIMEOUT", "12h"),
+		SessionCookieSecure:    getBoolOrDefault("SESSION_COOKIE_SECURE", true),
+		
+		// Email defaults
+		MailerDriver:  getEnvOrDefault("MAILER_DRIVER", "file"),
+		MailFrom:      getEnvOrDefault("MAIL_FROM", "no-reply@microservices.local"),
+		SMTPHost:      getEnvOrDefault("SMTP_HOST", "localhost"),
+		SMTPPort:      getEnvOrDefault("SMTP_PORT", "1025"),
+		SMTPUsername:  getEnvOrDefault("SMTP_USERNAME", ""),
+		SMTPPassword:  getEnvOrDefault("SMTP_PASSWORD", ""),
+		MailOutboxDir: getEnvOrDefault("MAIL_OUTBOX_DIR", "./tmp/mail"),
+		AppBaseURL:    getEnvOrDefault("APP_BASE_URL", "http://localhost:3000"),
+		EmailVerificationTTL:            getDurationOrDefault("EMAIL_VERIFICATION_TTL", "24h"),
+		EmailVerificationResendCooldown: getDurationOrDefault("EMAIL_VERIFICATION_RESEND_COOLDOWN", "1m"),
+		PasswordResetTTL:                getDurationOrDefault("PASSWORD_RESET_TTL", "1h"),
+		
+		// Password defaults
+		PasswordMinLength:         getIntOrDefault("PASSWORD_MIN_LENGTH", 10),
+		PasswordMaxLength:         getIntOrDefault("PASSWORD_MAX_LENGTH", 128),
+		PasswordMinCharClasses:    getIntOrDefault("PASSWORD_MIN_CHAR_CLASSES", 3),
+		PasswordCommonListPath:    getEnvOrDefault("PASSWORD_COMMON_LIST_PATH", "./data/common-passwords.txt"),
+		PasswordHashAlgorithm:     getEnvOrDefault("PASSWORD_HASH_ALGORITHM", "argon2id"),
+		PasswordBcryptCost:        getIntOrDefault("PASSWORD_BCRYPT_COST", 12),
+		PasswordArgon2Memory:      getIntOrDefault("PASSWORD_ARGON2_MEMORY", 64*1024),
+		PasswordArgon2Iterations:  getIntOrDefault("PASSWORD_ARGON2_ITERATIONS", 3),
+		PasswordArgon2Parallelism: getIntOrDefault("PASSWORD_ARGON2_PARALLELISM", 2),
+		
+		// MFA defaults
+		MFAIssuer:        getEnvOrDefault("MFA_ISSUER", "Microservices Golang"),
+		MFAEncryptionKey: getEnvOrDefault("MFA_ENCRYPTION_KEY", "your-mfa-encryption-key-change-in-production"),
+		MFAChallengeTTL:  getDurationOrDefault("MFA_CHALLENGE_TTL", "5m"),
+		
+		// Login throttle defaults
+		LoginMaxFailures:     getIntOrDefault("LOGIN_MAX_FAILURES", 5),
+		LoginIPMaxFailures:   getIntOrDefault("LOGIN_IP_MAX_FAILURES", 50),
+		LoginFailureWindow:   getDurationOrDefault("LOGIN_FAILURE_WINDOW", "15m"),
+		LoginLockoutDuration: getDurationOrDefault("LOGIN_LOCKOUT_DURATION", "15m"),
+		LoginDelayBase:       getDurationOrDefault("LOGIN_DELAY_BASE", "1s"),
+		LoginDelayMax:        getDurationOrDefault("LOGIN_DELAY_MAX", "30s"),
+		
+		// Soft delete defaults
+		UserDeletedRetention:      getDurationOrDefault("USER_DELETED_RETENTION", "720h"),
+		UserPurgeInterval:         getDurationOrDefault("USER_PURGE_INTERVAL", "1h"),
+		UserDeletedIdentityPolicy: getEnvOrDefault("USER_DELETED_IDENTITY_POLICY", "reserve"),
+		
+		// Domain event defaults
+		EventPublisherDriver:   getEnvOrDefault("EVENT_PUBLISHER_DRIVER", "redis"),
+		EventStream:            getEnvOrDefault("EVENT_STREAM", "events:users"),
+		EventStreamMaxLength:   getIntOrDefault("EVENT_STREAM_MAX_LENGTH", 100000),
+		EventOutboxInterval:    getDurationOrDefault("EVENT_OUTBOX_INTERVAL", "5s"),
+		EventOutboxMaxAttempts: getIntOrDefault("EVENT_OUTBOX_MAX_ATTEMPTS", 10),
+		
+		// Bulk import defaults
+		UserImportMaxSize:   getIntOrDefault("USER_IMPORT_MAX_SIZE", 50<<20),
+		UserImportBatchSize: getIntOrDefault("USER_IMPORT_BATCH_SIZE", 500),
+		
+		// API key defaults
+		APIKeyMaxLifetime: getDurationOrDefault("API_KEY_MAX_LIFETIME", "8760h"),
+		APIKeyMaxPerUser:  getIntOrDefault("API_KEY_MAX_PER_USER", 25),
+		
+		// Rate limit defaults
+		RateLimitRequestsPerSecond:       getIntOrDefault("RATE_LIMIT_RPS", 20),
+		RateLimitBurst:                   getIntOrDefault("RATE_LIMIT_BURST", 40),
+		RateLimitUserRequestsPerSecond:   getIntOrDefault("RATE_LIMIT_USER_RPS", 10),
+		RateLimitUserBurst:               getIntOrDefault("RATE_LIMIT_USER_BURST", 20),
+		RateLimitAPIKeyRequestsPerSecond: getIntOrDefault("RATE_LIMIT_API_KEY_RPS", 5),
+		RateLimitAPIKeyBurst:             getIntOrDefault("RATE_LIMIT_API_KEY_BURST", 10),
+		RateLimitAllowlist:               getStringSliceOrDefault("RATE_LIMIT_ALLOWLIST", []string{"127.0.0.1", "::1"}),
+		TrustedProxies:                   getStringSliceOrDefault("TRUSTED_PROXIES", nil),
 		
 		// Service URLs
 		UserServiceURL:    getEnvOrDefault("USER_SERVICE_URL", "http://localhost:8081"),
@@ -166,12 +350,45 @@ func getDurationOrDefault(key string, defaultValue string) time.Duration {
 
 func getStringSliceOrDefault(key string, defaultValue []string) []string {
 	if value := os.Getenv(key); value != "" {
-		// Simple split by comma - bisa diperbaiki dengan parser yang lebih sophisticated
-		return []string{value}
+		// Split by comma dan buang entry kosong
+		result := []string{}
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				result = append(result, item)
+			}
+		}
+		return result
 	}
 	return defaultValue
 }
 
+func getRolePermissionsOrDefault(key string, defaultValue map[string][]string) map[string][]string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	// Format: role=perm1,perm2;role2=perm3
+	result := make(map[string][]string)
+	for _, entry := range strings.Split(value, ";") {
+		parts := strings.SplitN(entry, "=", 2)
+		role := strings.TrimSpace(parts[0])
+		if role == "" {
+			continue
+		}
+
+		result[role] = []string{}
+		if len(parts) == 2 {
+			for _, permission := range strings.Split(parts[1], ",") {
+				if permission = strings.TrimSpace(permission); permission != "" {
+					result[role] = append(result[role], permission)
+				}
+			}
+		}
+	}
+	return result
+}
+
 // IsProduction mengecek apakah aplikasi berjalan di production
 func (c *AppConfig) IsProduction() bool {
 	return c.Environment == "production"
@@ -0,0 +1,328 @@
+package database
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// Header untuk value yang disimpan di Redis.
+// Format: [magic][version][codec ID][compression ID][payload...]
+// Byte magic 0xC0 tidak pernah muncul di awal JSON valid (bukan UTF-8 yang sah),
+// jadi value lama tanpa header tetap bisa dikenali sebagai JSON biasa.
+const (
+	valueHeaderMagic   byte = 0xC0
+	valueHeaderVersion byte = 1
+	valueHeaderSize         = 4
+)
+
+// Codec ID yang tercatat di header. Jangan pernah mengubah nilai yang sudah dipakai,
+// karena instance lain membaca value berdasarkan ID ini.
+const (
+	CodecIDJSON    byte = 1
+	CodecIDMsgPack byte = 2
+)
+
+// Compression ID yang tercatat di header
+const (
+	CompressionNone byte = 0
+	CompressionGzip byte = 1
+	CompressionZstd byte = 2
+)
+
+// DefaultMaxDecompressedSize batas default ukuran value setelah dekompresi
+const DefaultMaxDecompressedSize int64 = 16 << 20
+
+// ErrValueTooLarge value hasil dekompresi melebihi batas, misal karena payload di Redis dimanipulasi (decompression bomb)
+var ErrValueTooLarge = errors.New("decompressed value exceeds maximum size")
+
+// Codec interface untuk serialisasi value cache
+type Codec interface {
+	ID() byte
+	Name() string
+	Marshal(value interface{}) ([]byte, error)
+	Unmarshal(data []byte, dest interface{}) error
+}
+
+// JSONCodec codec default yang kompatibel dengan format lama
+type JSONCodec struct{}
+
+// ID mengembalikan codec ID untuk header
+func (JSONCodec) ID() byte { return CodecIDJSON }
+
+// Name mengembalikan nama codec
+func (JSONCodec) Name() string { return "json" }
+
+// Marshal encode value ke JSON
+func (JSONCodec) Marshal(value interface{}) ([]byte, error) { return json.Marshal(value) }
+
+// Unmarshal decode JSON ke dest
+func (JSONCodec) Unmarshal(data []byte, dest interface{}) error { return json.Unmarshal(data, dest) }
+
+// MsgPackCodec codec binary yang lebih kecil dan cepat dari JSON
+type MsgPackCodec struct{}
+
+// ID mengembalikan codec ID untuk header
+func (MsgPackCodec) ID() byte { return CodecIDMsgPack }
+
+// Name mengembalikan nama codec
+func (MsgPackCodec) Name() string { return "msgpack" }
+
+// Marshal encode value ke MessagePack, memakai tag json supaya field name konsisten
+func (MsgPackCodec) Marshal(value interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := msgpack.NewEncoder(&buf)
+	encoder.SetCustomStructTag("json")
+	if err := encoder.Encode(value); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Unmarshal decode MessagePack ke dest
+func (MsgPackCodec) Unmarshal(data []byte, dest interface{}) error {
+	decoder := msgpack.NewDecoder(bytes.NewReader(data))
+	decoder.SetCustomStructTag("json")
+	return decoder.Decode(dest)
+}
+
+// registeredCodecs daftar codec yang bisa dibaca, apapun codec yang dipakai untuk menulis.
+// Dijaga codecsMu karena RegisterCodec bisa dipanggil saat Decode berjalan di goroutine lain.
+var (
+	codecsMu         sync.RWMutex
+	registeredCodecs = map[byte]Codec{
+		CodecIDJSON:    JSONCodec{},
+		CodecIDMsgPack: MsgPackCodec{},
+	}
+)
+
+// RegisterCodec mendaftarkan codec tambahan supaya value-nya bisa di-decode
+func RegisterCodec(codec Codec) {
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+	registeredCodecs[codec.ID()] = codec
+}
+
+// Helper function untuk mencari codec berdasarkan ID di header
+func codecByID(id byte) (Codec, bool) {
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+	codec, ok := registeredCodecs[id]
+	return codec, ok
+}
+
+// CodecByName mencari codec berdasarkan nama (untuk konfigurasi)
+func CodecByName(name string) (Codec, error) {
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+	for _, codec := range registeredCodecs {
+		if codec.Name() == strings.ToLower(name) {
+			return codec, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown codec: %s", name)
+}
+
+// CompressionByName mengubah nama compression dari konfigurasi menjadi ID
+func CompressionByName(name string) (byte, error) {
+	switch strings.ToLower(name) {
+	case "", "none":
+		return CompressionNone, nil
+	case "gzip":
+		return CompressionGzip, nil
+	case "zstd":
+		return CompressionZstd, nil
+	default:
+		return 0, fmt.Errorf("unknown compression: %s", name)
+	}
+}
+
+// ValueEncoder menggabungkan codec dan compression dengan threshold ukuran
+type ValueEncoder struct {
+	Codec                Codec
+	Compression          byte
+	CompressionThreshold int   // Payload lebih kecil dari ini tidak dikompres
+	MaxDecompressedSize  int64 // Value yang lebih besar dari ini setelah dekompresi ditolak
+}
+
+// DefaultValueEncoder encoder JSON tanpa compression, sama dengan perilaku lama
+func DefaultValueEncoder() *ValueEncoder {
+	return &ValueEncoder{
+		Codec:               JSONCodec{},
+		Compression:         CompressionNone,
+		MaxDecompressedSize: DefaultMaxDecompressedSize,
+	}
+}
+
+// NewValueEncoder membuat encoder dari nama codec dan compression.
+// maxDecompressedSize <= 0 memakai DefaultMaxDecompressedSize.
+func NewValueEncoder(codecName, compressionName string, threshold int, maxDecompressedSize int64) (*ValueEncoder, error) {
+	if codecName == "" {
+		codecName = "json"
+	}
+	if maxDecompressedSize <= 0 {
+		maxDecompressedSize = DefaultMaxDecompressedSize
+	}
+
+	codec, err := CodecByName(codecName)
+	if err != nil {
+		return nil, err
+	}
+
+	compression, err := CompressionByName(compressionName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ValueEncoder{
+		Codec:                codec,
+		Compression:          compression,
+		CompressionThreshold: threshold,
+		MaxDecompressedSize:  maxDecompressedSize,
+	}, nil
+}
+
+// Encode serialisasi value lengkap dengan header
+func (e *ValueEncoder) Encode(value interface{}) ([]byte, error) {
+	payload, err := e.Codec.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode value with %s: %w", e.Codec.Name(), err)
+	}
+
+	compression := CompressionNone
+	if e.Compression != CompressionNone && len(payload) >= e.CompressionThreshold {
+		compressed, err := compress(e.Compression, payload)
+		if err != nil {
+			return nil, err
+		}
+		// Hanya pakai hasil kompresi kalau memang lebih kecil
+		if len(compressed) < len(payload) {
+			payload = compressed
+			compression = e.Compression
+		}
+	}
+
+	// JSON tanpa kompresi ditulis tanpa header supaya instance lama tetap bisa membacanya
+	if e.Codec.ID() == CodecIDJSON && compression == CompressionNone {
+		return payload, nil
+	}
+
+	data := make([]byte, 0, valueHeaderSize+len(payload))
+	data = append(data, valueHeaderMagic, valueHeaderVersion, e.Codec.ID(), compression)
+	data = append(data, payload...)
+	return data, nil
+}
+
+// Decode membaca header dan decode value, termasuk value lama tanpa header
+func (e *ValueEncoder) Decode(data []byte, dest interface{}) error {
+	if len(data) < valueHeaderSize || data[0] != valueHeaderMagic {
+		// Value lama (atau JSON tanpa kompresi) selalu berupa JSON mentah
+		return json.Unmarshal(data, dest)
+	}
+
+	if data[1] > valueHeaderVersion {
+		return fmt.Errorf("unsupported value header version: %d", data[1])
+	}
+
+	codec, ok := codecByID(data[2])
+	if !ok {
+		return fmt.Errorf("unsupported codec id: %d", data[2])
+	}
+
+	maxSize := e.MaxDecompressedSize
+	if maxSize <= 0 {
+		maxSize = DefaultMaxDecompressedSize
+	}
+
+	payload, err := decompress(data[3], data[valueHeaderSize:], maxSize)
+	if err != nil {
+		return err
+	}
+
+	if err := codec.Unmarshal(payload, dest); err != nil {
+		return fmt.Errorf("failed to decode value with %s: %w", codec.Name(), err)
+	}
+	return nil
+}
+
+// Helper function untuk kompresi payload
+func compress(compression byte, payload []byte) ([]byte, error) {
+	var buf bytes.Buffer
+
+	switch compression {
+	case CompressionGzip:
+		writer := gzip.NewWriter(&buf)
+		if _, err := writer.Write(payload); err != nil {
+			return nil, fmt.Errorf("failed to gzip value: %w", err)
+		}
+		if err := writer.Close(); err != nil {
+			return nil, fmt.Errorf("failed to gzip value: %w", err)
+		}
+	case CompressionZstd:
+		writer, err := zstd.NewWriter(&buf)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create zstd writer: %w", err)
+		}
+		if _, err := writer.Write(payload); err != nil {
+			writer.Close()
+			return nil, fmt.Errorf("failed to zstd value: %w", err)
+		}
+		if err := writer.Close(); err != nil {
+			return nil, fmt.Errorf("failed to zstd value: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported compression id: %d", compression)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Helper function untuk dekompresi payload, hasilnya dibatasi maxSize byte
+func decompress(compression byte, payload []byte, maxSize int64) ([]byte, error) {
+	switch compression {
+	case CompressionNone:
+		return payload, nil
+	case CompressionGzip:
+		reader, err := gzip.NewReader(bytes.NewReader(payload))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read gzip value: %w", err)
+		}
+		defer reader.Close()
+		return readLimited(reader, maxSize)
+	case CompressionZstd:
+		reader, err := zstd.NewReader(bytes.NewReader(payload), zstd.WithDecoderMaxMemory(uint64(maxSize)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read zstd value: %w", err)
+		}
+		defer reader.Close()
+		data, err := readLimited(reader, maxSize)
+		// Decoder zstd menolak frame yang window atau ukurannya melebihi batas sebelum LimitReader tercapai
+		if errors.Is(err, zstd.ErrDecoderSizeExceeded) || errors.Is(err, zstd.ErrWindowSizeExceeded) {
+			return nil, ErrValueTooLarge
+		}
+		return data, err
+	default:
+		return nil, fmt.Errorf("unsupported compression id: %d", compression)
+	}
+}
+
+// Helper function untuk membaca hasil dekompresi maksimal maxSize byte.
+// Dibaca satu byte lebih supaya value yang melebihi batas bisa dibedakan dari value yang pas di batas.
+func readLimited(reader io.Reader, maxSize int64) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(reader, maxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress value: %w", err)
+	}
+	if int64(len(data)) > maxSize {
+		return nil, ErrValueTooLarge
+	}
+	return data, nil
+}
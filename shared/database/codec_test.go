@@ -0,0 +1,186 @@
+package database
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type testCacheValue struct {
+	ID    int      `json:"id"`
+	Name  string   `json:"name"`
+	Tags  []string `json:"tags"`
+	Notes string   `json:"notes"`
+}
+
+func TestValueEncoderRoundTrip(t *testing.T) {
+	small := testCacheValue{ID: 1, Name: "john", Tags: []string{"admin"}}
+	// Payload berulang supaya gzip dan zstd pasti menghasilkan data yang lebih kecil
+	large := testCacheValue{ID: 2, Name: "jane", Tags: []string{"user"}, Notes: strings.Repeat("lorem ipsum ", 100)}
+
+	tests := []struct {
+		name            string
+		codec           string
+		compression     string
+		value           testCacheValue
+		wantHeader      bool
+		wantCodec       byte
+		wantCompression byte
+	}{
+		{name: "json below threshold is raw JSON", codec: "json", compression: "gzip", value: small},
+		{name: "json without compression is raw JSON", codec: "json", compression: "none", value: large},
+		{name: "json gzip above threshold", codec: "json", compression: "gzip", value: large, wantHeader: true, wantCodec: CodecIDJSON, wantCompression: CompressionGzip},
+		{name: "json zstd above threshold", codec: "json", compression: "zstd", value: large, wantHeader: true, wantCodec: CodecIDJSON, wantCompression: CompressionZstd},
+		{name: "msgpack without compression", codec: "msgpack", compression: "none", value: large, wantHeader: true, wantCodec: CodecIDMsgPack, wantCompression: CompressionNone},
+		{name: "msgpack below threshold", codec: "msgpack", compression: "zstd", value: small, wantHeader: true, wantCodec: CodecIDMsgPack, wantCompression: CompressionNone},
+		{name: "msgpack gzip above threshold", codec: "msgpack", compression: "gzip", value: large, wantHeader: true, wantCodec: CodecIDMsgPack, wantCompression: CompressionGzip},
+		{name: "msgpack zstd above threshold", codec: "msgpack", compression: "zstd", value: large, wantHeader: true, wantCodec: CodecIDMsgPack, wantCompression: CompressionZstd},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoder, err := NewValueEncoder(tt.codec, tt.compression, 256, 0)
+			if err != nil {
+				t.Fatalf("NewValueEncoder() error = %v", err)
+			}
+
+			data, err := encoder.Encode(tt.value)
+			if err != nil {
+				t.Fatalf("Encode() error = %v", err)
+			}
+
+			hasHeader := len(data) >= valueHeaderSize && data[0] == valueHeaderMagic
+			if hasHeader != tt.wantHeader {
+				t.Fatalf("Encode() header present = %v, want %v", hasHeader, tt.wantHeader)
+			}
+			if tt.wantHeader {
+				if data[1] != valueHeaderVersion || data[2] != tt.wantCodec || data[3] != tt.wantCompression {
+					t.Errorf("header = % x, want version %d codec %d compression %d", data[:valueHeaderSize], valueHeaderVersion, tt.wantCodec, tt.wantCompression)
+				}
+			}
+
+			// Instance dengan konfigurasi default tetap bisa membaca value apapun dari header-nya
+			var got testCacheValue
+			if err := DefaultValueEncoder().Decode(data, &got); err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.value) {
+				t.Errorf("Decode() = %+v, want %+v", got, tt.value)
+			}
+		})
+	}
+}
+
+func TestValueEncoderDecodesLegacyJSON(t *testing.T) {
+	legacy := []byte(`{"id":7,"name":"legacy","tags":["a","b"],"notes":""}`)
+	want := testCacheValue{ID: 7, Name: "legacy", Tags: []string{"a", "b"}}
+
+	for _, codec := range []string{"json", "msgpack"} {
+		t.Run(codec, func(t *testing.T) {
+			encoder, err := NewValueEncoder(codec, "zstd", 0, 0)
+			if err != nil {
+				t.Fatalf("NewValueEncoder() error = %v", err)
+			}
+
+			var got testCacheValue
+			if err := encoder.Decode(legacy, &got); err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Decode() = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestValueEncoderSkipsIncompressiblePayload(t *testing.T) {
+	encoder, err := NewValueEncoder("msgpack", "gzip", 0, 0)
+	if err != nil {
+		t.Fatalf("NewValueEncoder() error = %v", err)
+	}
+
+	// Payload sekecil ini justru membesar setelah gzip, jadi harus disimpan tanpa kompresi
+	data, err := encoder.Encode("x")
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	if data[3] != CompressionNone {
+		t.Errorf("compression = %d, want %d", data[3], CompressionNone)
+	}
+}
+
+func TestValueEncoderRejectsOversizedValue(t *testing.T) {
+	value := strings.Repeat("a", 64<<10)
+
+	for _, compression := range []string{"gzip", "zstd"} {
+		t.Run(compression, func(t *testing.T) {
+			writer, err := NewValueEncoder("json", compression, 0, 0)
+			if err != nil {
+				t.Fatalf("NewValueEncoder() error = %v", err)
+			}
+			data, err := writer.Encode(value)
+			if err != nil {
+				t.Fatalf("Encode() error = %v", err)
+			}
+			payloadSize := int64(len(value) + 2) // String JSON termasuk dua tanda kutip
+
+			tests := []struct {
+				name    string
+				maxSize int64
+				wantErr error
+			}{
+				{name: "exactly at limit", maxSize: payloadSize},
+				{name: "one byte over limit", maxSize: payloadSize - 1, wantErr: ErrValueTooLarge},
+				{name: "far over limit", maxSize: 1024, wantErr: ErrValueTooLarge},
+			}
+
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					reader := &ValueEncoder{Codec: JSONCodec{}, MaxDecompressedSize: tt.maxSize}
+
+					var got string
+					err := reader.Decode(data, &got)
+					if !errors.Is(err, tt.wantErr) {
+						t.Fatalf("Decode() error = %v, want %v", err, tt.wantErr)
+					}
+					if tt.wantErr == nil && got != value {
+						t.Errorf("Decode() returned %d bytes, want %d", len(got), len(value))
+					}
+				})
+			}
+		})
+	}
+}
+
+func TestValueEncoderDecodeErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{name: "unsupported header version", data: []byte{valueHeaderMagic, valueHeaderVersion + 1, CodecIDJSON, CompressionNone, '1'}},
+		{name: "unknown codec id", data: []byte{valueHeaderMagic, valueHeaderVersion, 99, CompressionNone, '1'}},
+		{name: "unknown compression id", data: []byte{valueHeaderMagic, valueHeaderVersion, CodecIDJSON, 99, '1'}},
+		{name: "corrupt gzip payload", data: append([]byte{valueHeaderMagic, valueHeaderVersion, CodecIDJSON, CompressionGzip}, bytes.Repeat([]byte{0xFF}, 16)...)},
+		{name: "invalid legacy JSON", data: []byte(`{"id":`)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got testCacheValue
+			if err := DefaultValueEncoder().Decode(tt.data, &got); err == nil {
+				t.Error("Decode() error = nil, want error")
+			}
+		})
+	}
+}
+
+func TestNewValueEncoderUnknownNames(t *testing.T) {
+	if _, err := NewValueEncoder("xml", "none", 0, 0); err == nil {
+		t.Error("NewValueEncoder() error = nil, want error for unknown codec")
+	}
+	if _, err := NewValueEncoder("json", "brotli", 0, 0); err == nil {
+		t.Error("NewValueEncoder() error = nil, want error for unknown compression")
+	}
+}
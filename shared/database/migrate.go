@@ -0,0 +1,121 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Migration satu langkah perubahan schema dengan versi yang berurutan
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+}
+
+// migrationLockID key advisory lock supaya hanya satu instance yang menjalankan migration
+const migrationLockID = 727274001
+
+// Migrate menjalankan migration yang belum pernah dijalankan secara berurutan.
+// Versi yang sudah dijalankan dicatat di tabel schema_migrations, dan setiap migration
+// berjalan dalam transaction sendiri sehingga kegagalan tidak meninggalkan schema setengah jadi.
+func (db *PostgresDB) Migrate(migrations []Migration) error {
+	sorted, err := sortMigrations(migrations)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	// Advisory lock dan transaction harus memakai koneksi yang sama
+	conn, err := db.Connection.Connx(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get database connection: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, migrationLockID); err != nil {
+		return fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	defer conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, migrationLockID)
+
+	createTableQuery := `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY,
+			name VARCHAR(255) NOT NULL,
+			applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)`
+	if _, err := conn.ExecContext(ctx, createTableQuery); err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+
+	var appliedVersions []int
+	if err := conn.SelectContext(ctx, &appliedVersions, `SELECT version FROM schema_migrations`); err != nil {
+		return fmt.Errorf("failed to get applied migrations: %w", err)
+	}
+
+	applied := make(map[int]bool, len(appliedVersions))
+	for _, version := range appliedVersions {
+		applied[version] = true
+	}
+
+	pending := 0
+	for _, migration := range sorted {
+		if applied[migration.Version] {
+			continue
+		}
+
+		logger := db.logger.WithFields(logrus.Fields{
+			"version": migration.Version,
+			"name":    migration.Name,
+		})
+		logger.Info("Applying migration")
+
+		tx, err := conn.BeginTxx(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("failed to begin transaction: %w", err)
+		}
+
+		if _, err := tx.ExecContext(ctx, migration.Up); err != nil {
+			tx.Rollback()
+			logger.WithError(err).Error("Migration failed")
+			return fmt.Errorf("migration %d (%s) failed: %w", migration.Version, migration.Name, err)
+		}
+
+		if _, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`,
+			migration.Version, migration.Name); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to record migration %d: %w", migration.Version, err)
+		}
+
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("failed to commit migration %d: %w", migration.Version, err)
+		}
+		pending++
+	}
+
+	db.logger.WithField("applied", pending).Info("Database migration completed successfully")
+	return nil
+}
+
+// Helper function untuk mengurutkan migration dan memastikan versi tidak duplikat
+func sortMigrations(migrations []Migration) ([]Migration, error) {
+	sorted := make([]Migration, len(migrations))
+	copy(sorted, migrations)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Version < sorted[j].Version })
+
+	for i, migration := range sorted {
+		if migration.Version <= 0 {
+			return nil, fmt.Errorf("migration %q has invalid version %d", migration.Name, migration.Version)
+		}
+		if i > 0 && sorted[i-1].Version == migration.Version {
+			return nil, fmt.Errorf("duplicate migration version %d", migration.Version)
+		}
+	}
+
+	return sorted, nil
+}
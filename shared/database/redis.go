@@ -2,7 +2,7 @@ package database
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
@@ -10,10 +10,14 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+// ErrKeyNotFound dikembalikan ketika key tidak ada di Redis
+var ErrKeyNotFound = errors.New("key not found")
+
 // RedisClient wrapper untuk Redis connection yang mudah digunakan
 type RedisClient struct {
-	Client *redis.Client
-	logger *logrus.Logger
+	Client  *redis.Client
+	logger  *logrus.Logger
+	encoder *ValueEncoder
 }
 
 // RedisConfig konfigurasi Redis yang user-friendly
@@ -22,16 +26,30 @@ type RedisConfig struct {
 	Port     string
 	Password string
 	Database int
+
+	// Codec untuk value cache: "json" (default) atau "msgpack"
+	Codec string
+	// Compression untuk value besar: "none" (default), "gzip" atau "zstd"
+	Compression          string
+	CompressionThreshold int
+	// Batas ukuran value setelah dekompresi dalam byte, 0 memakai DefaultMaxDecompressedSize
+	MaxDecompressedSize int64
 }
 
 // NewRedisConnection membuat koneksi baru ke Redis
 func NewRedisConnection(config RedisConfig, logger *logrus.Logger) (*RedisClient, error) {
 	address := fmt.Sprintf("%s:%s", config.Host, config.Port)
+
+	encoder, err := NewValueEncoder(config.Codec, config.Compression, config.CompressionThreshold, config.MaxDecompressedSize)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Redis codec config: %w", err)
+	}
 	
 	logger.WithFields(logrus.Fields{
 		"host":     config.Host,
 		"port":     config.Port,
 		"database": config.Database,
+		"codec":    encoder.Codec.Name(),
 	}).Info("Connecting to Redis...")
 
 	// Buat Redis client
@@ -53,11 +71,25 @@ func NewRedisConnection(config RedisConfig, logger *logrus.Logger) (*RedisClient
 	logger.Info("Successfully connected to Redis")
 
 	return &RedisClient{
-		Client: client,
-		logger: logger,
+		Client:  client,
+		logger:  logger,
+		encoder: encoder,
 	}, nil
 }
 
+// SetEncoder mengganti encoder yang dipakai untuk menulis value
+func (r *RedisClient) SetEncoder(encoder *ValueEncoder) {
+	r.encoder = encoder
+}
+
+// Helper function untuk mendapatkan encoder, fallback ke JSON
+func (r *RedisClient) valueEncoder() *ValueEncoder {
+	if r.encoder == nil {
+		return DefaultValueEncoder()
+	}
+	return r.encoder
+}
+
 // Close menutup koneksi Redis
 func (r *RedisClient) Close() error {
 	if r.Client != nil {
@@ -80,18 +112,9 @@ func (r *RedisClient) SetWithExpiration(key string, value interface{}, expiratio
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	// Convert value ke JSON jika bukan string
-	var data string
-	switch v := value.(type) {
-	case string:
-		data = v
-	default:
-		jsonData, err := json.Marshal(value)
-		if err != nil {
-			r.logger.WithError(err).WithField("key", key).Error("Failed to marshal value to JSON")
-			return fmt.Errorf("failed to marshal value: %w", err)
-		}
-		data = string(jsonData)
+	data, err := r.encodeValue(key, value)
+	if err != nil {
+		return err
 	}
 
 	if err := r.Client.Set(ctx, key, data, expiration).Err(); err != nil {
@@ -102,6 +125,41 @@ func (r *RedisClient) SetWithExpiration(key string, value interface{}, expiratio
 	return nil
 }
 
+// UpdateWithExpiration menimpa data dan expiration hanya jika key masih ada (SET XX),
+// sehingga key yang sudah dihapus di antara baca dan tulis tidak dibuat ulang.
+// Mengembalikan false jika key sudah tidak ada.
+func (r *RedisClient) UpdateWithExpiration(key string, value interface{}, expiration time.Duration) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	data, err := r.encodeValue(key, value)
+	if err != nil {
+		return false, err
+	}
+
+	updated, err := r.Client.SetXX(ctx, key, data, expiration).Result()
+	if err != nil {
+		r.logger.WithError(err).WithField("key", key).Error("Failed to update value in Redis")
+		return false, fmt.Errorf("failed to update value: %w", err)
+	}
+
+	return updated, nil
+}
+
+// Helper function untuk encode value dengan codec jika bukan string
+func (r *RedisClient) encodeValue(key string, value interface{}) (interface{}, error) {
+	if v, ok := value.(string); ok {
+		return v, nil
+	}
+
+	encoded, err := r.valueEncoder().Encode(value)
+	if err != nil {
+		r.logger.WithError(err).WithField("key", key).Error("Failed to encode value")
+		return nil, fmt.Errorf("failed to marshal value: %w", err)
+	}
+	return encoded, nil
+}
+
 // Get mengambil data dari Redis
 func (r *RedisClient) Get(key string) (string, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -110,7 +168,7 @@ func (r *RedisClient) Get(key string) (string, error) {
 	value, err := r.Client.Get(ctx, key).Result()
 	if err != nil {
 		if err == redis.Nil {
-			return "", fmt.Errorf("key not found: %s", key)
+			return "", fmt.Errorf("%w: %s", ErrKeyNotFound, key)
 		}
 		r.logger.WithError(err).WithField("key", key).Error("Failed to get value from Redis")
 		return "", fmt.Errorf("failed to get value: %w", err)
@@ -119,16 +177,16 @@ func (r *RedisClient) Get(key string) (string, error) {
 	return value, nil
 }
 
-// GetAndUnmarshal mengambil data dan unmarshal ke struct
+// GetAndUnmarshal mengambil data dan unmarshal ke struct sesuai codec di header
 func (r *RedisClient) GetAndUnmarshal(key string, dest interface{}) error {
 	value, err := r.Get(key)
 	if err != nil {
 		return err
 	}
 
-	if err := json.Unmarshal([]byte(value), dest); err != nil {
-		r.logger.WithError(err).WithField("key", key).Error("Failed to unmarshal JSON")
-		return fmt.Errorf("failed to unmarshal JSON: %w", err)
+	if err := r.valueEncoder().Decode([]byte(value), dest); err != nil {
+		r.logger.WithError(err).WithField("key", key).Error("Failed to decode value")
+		return fmt.Errorf("failed to unmarshal value: %w", err)
 	}
 
 	return nil
@@ -0,0 +1,150 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jizak1/Microservices-Golang/shared/utils"
+)
+
+// APIKeyScopes scope API key yang dibutuhkan untuk request read (GET, HEAD, OPTIONS) dan write (method lain).
+// Scope kosong berarti API key tidak boleh dipakai untuk jenis request tersebut.
+type APIKeyScopes struct {
+	Read  string
+	Write string
+}
+
+// APIKeyAuth middleware untuk authentication hanya dengan "Authorization: ApiKey <key>"
+func APIKeyAuth(verifier utils.APIKeyVerifier, scopes APIKeyScopes) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		scheme, credentials := parseAuthorization(c.GetHeader("Authorization"))
+		if !strings.EqualFold(scheme, utils.APIKeyScheme) || credentials == "" {
+			utils.ErrorResponse(c, http.StatusUnauthorized, "UNAUTHORIZED", "API key required", "Authorization header must be in format: ApiKey <key>")
+			c.Abort()
+			return
+		}
+
+		if !authenticateAPIKey(c, verifier, scopes, credentials) {
+			return
+		}
+		c.Next()
+	}
+}
+
+// Authenticate middleware yang menerima JWT ("Bearer <token>") maupun API key ("ApiKey <key>").
+// Keduanya mengisi user context yang sama seperti JWTAuth, sehingga RequireRole dan handler tidak perlu membedakan.
+func Authenticate(tokenVerifier utils.TokenVerifier, apiKeyVerifier utils.APIKeyVerifier, scopes APIKeyScopes) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authHeader := c.GetHeader("Authorization")
+		if authHeader == "" {
+			utils.ErrorResponse(c, http.StatusUnauthorized, "UNAUTHORIZED", "Authorization header required", "Please provide Authorization header with Bearer token or ApiKey")
+			c.Abort()
+			return
+		}
+
+		scheme, credentials := parseAuthorization(authHeader)
+		switch {
+		case strings.EqualFold(scheme, "Bearer") && credentials != "":
+			claims, err := tokenVerifier.ValidateToken(credentials)
+			if err != nil {
+				utils.ErrorResponse(c, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid token", "Token is invalid or expired")
+				c.Abort()
+				return
+			}
+			SetUserContext(c, claims)
+		case strings.EqualFold(scheme, utils.APIKeyScheme) && credentials != "":
+			if !authenticateAPIKey(c, apiKeyVerifier, scopes, credentials) {
+				return
+			}
+		default:
+			utils.ErrorResponse(c, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid authorization format", "Authorization header must be in format: Bearer <token> or ApiKey <key>")
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// OptionalAuthenticate seperti Authenticate untuk endpoint publik yang punya tampilan tambahan untuk user login.
+// Request tanpa kredensial, atau dengan kredensial yang tidak valid, tetap diteruskan sebagai anonymous (sama seperti OptionalJWTAuth).
+func OptionalAuthenticate(tokenVerifier utils.TokenVerifier, apiKeyVerifier utils.APIKeyVerifier, scopes APIKeyScopes) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		scheme, credentials := parseAuthorization(c.GetHeader("Authorization"))
+		switch {
+		case credentials == "":
+		case strings.EqualFold(scheme, "Bearer"):
+			if claims, err := tokenVerifier.ValidateToken(credentials); err == nil {
+				SetUserContext(c, claims)
+			}
+		case strings.EqualFold(scheme, utils.APIKeyScheme):
+			claims, err := apiKeyVerifier.VerifyAPIKey(c.Request.Context(), credentials)
+			if required := requiredAPIKeyScope(c.Request.Method, scopes); err == nil && required != "" && claims.HasAPIKeyScope(required) {
+				SetUserContext(c, claims)
+			}
+		}
+
+		c.Next()
+	}
+}
+
+// DenyAPIKey menolak request yang diautentikasi dengan API key, untuk endpoint yang butuh login interaktif
+// (misal membuat API key baru atau mengganti password)
+func DenyAPIKey() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if claims, ok := CurrentUser(c); ok && claims.IsAPIKey() {
+			utils.ForbiddenResponse(c, "This endpoint cannot be accessed with an API key")
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// Helper function untuk verifikasi API key dan pengecekan scope, response error sudah dikirim jika gagal
+func authenticateAPIKey(c *gin.Context, verifier utils.APIKeyVerifier, scopes APIKeyScopes, key string) bool {
+	claims, err := verifier.VerifyAPIKey(c.Request.Context(), key)
+	if err != nil {
+		if errors.Is(err, utils.ErrInvalidAPIKey) {
+			utils.ErrorResponse(c, http.StatusUnauthorized, utils.APIKeyInvalidCode, "Invalid API key", "API key is invalid, revoked or expired")
+		} else {
+			utils.ErrorResponse(c, http.StatusServiceUnavailable, "SERVICE_UNAVAILABLE", "Unable to verify API key", "Please try again later")
+		}
+		c.Abort()
+		return false
+	}
+
+	required := requiredAPIKeyScope(c.Request.Method, scopes)
+	if required == "" {
+		utils.ForbiddenResponse(c, "This request cannot be made with an API key")
+		c.Abort()
+		return false
+	}
+	if !claims.HasAPIKeyScope(required) {
+		utils.ForbiddenResponse(c, "API key does not have the required scope: "+required)
+		c.Abort()
+		return false
+	}
+
+	SetUserContext(c, claims)
+	return true
+}
+
+// Helper function untuk memilih scope API key yang dibutuhkan berdasarkan HTTP method
+func requiredAPIKeyScope(method string, scopes APIKeyScopes) string {
+	if method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions {
+		return scopes.Read
+	}
+	return scopes.Write
+}
+
+// Helper function untuk memisahkan scheme dan credentials dari header Authorization
+func parseAuthorization(header string) (string, string) {
+	parts := strings.SplitN(strings.TrimSpace(header), " ", 2)
+	if len(parts) != 2 {
+		return parts[0], ""
+	}
+	return parts[0], strings.TrimSpace(parts[1])
+}
@@ -0,0 +1,224 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jizak1/Microservices-Golang/shared/utils"
+)
+
+// ContextKeyClaims key untuk menyimpan typed claims di gin context
+const ContextKeyClaims = "claims"
+
+// CurrentUser mengambil typed claims user yang sedang login dari context
+func CurrentUser(c *gin.Context) (*utils.JWTClaims, bool) {
+	value, exists := c.Get(ContextKeyClaims)
+	if !exists {
+		return nil, false
+	}
+
+	claims, ok := value.(*utils.JWTClaims)
+	return claims, ok && claims != nil
+}
+
+// RequireRole middleware untuk membatasi akses hanya ke role tertentu.
+// Harus dipasang setelah JWTAuth.
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := CurrentUser(c)
+		if !ok {
+			utils.UnauthorizedResponse(c, "Authentication required")
+			c.Abort()
+			return
+		}
+
+		if !hasAnyRole(claims, roles) {
+			utils.ForbiddenResponse(c, "You do not have the required role to access this resource")
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RequirePermission middleware untuk membatasi akses berdasarkan permission dari role user
+func RequirePermission(authorizer *utils.Authorizer, permissions ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := CurrentUser(c)
+		if !ok {
+			utils.UnauthorizedResponse(c, "Authentication required")
+			c.Abort()
+			return
+		}
+
+		for _, permission := range permissions {
+			if !HasPermission(authorizer, claims, permission) {
+				utils.ForbiddenResponse(c, "You do not have permission to perform this action")
+				c.Abort()
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
+
+// RequireOwnerOrRole middleware yang mengizinkan pemilik resource (berdasarkan URL param)
+// atau user dengan role tertentu, contoh: user boleh update dirinya sendiri, admin boleh semua.
+func RequireOwnerOrRole(paramName string, roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := CurrentUser(c)
+		if !ok {
+			utils.UnauthorizedResponse(c, "Authentication required")
+			c.Abort()
+			return
+		}
+
+		if claims.UserID != "" && claims.UserID == c.Param(paramName) {
+			c.Next()
+			return
+		}
+
+		if !hasAnyRole(claims, roles) {
+			utils.ForbiddenResponse(c, "You can only access your own resources")
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RequireOwnerOrPermission seperti RequireOwnerOrRole, tapi user lain harus punya permission tertentu
+// dari role-nya, contoh: user boleh update dirinya sendiri, user dengan users:update boleh semua.
+func RequireOwnerOrPermission(authorizer *utils.Authorizer, paramName string, permissions ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := CurrentUser(c)
+		if !ok {
+			utils.UnauthorizedResponse(c, "Authentication required")
+			c.Abort()
+			return
+		}
+
+		if claims.UserID != "" && claims.UserID == c.Param(paramName) {
+			c.Next()
+			return
+		}
+
+		for _, permission := range permissions {
+			if !HasPermission(authorizer, claims, permission) {
+				utils.ForbiddenResponse(c, "You can only access your own resources")
+				c.Abort()
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
+
+// RequireOwnerOrAdmin seperti RequireOwnerOrPermission, tapi akses ke resource user lain diperlakukan
+// sama seperti endpoint admin: harus login interaktif (bukan API key) yang sudah melewati MFA.
+// Pemilik resource tetap boleh mengakses dengan kredensial apapun.
+func RequireOwnerOrAdmin(authorizer *utils.Authorizer, paramName string, permissions ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := CurrentUser(c)
+		if !ok {
+			utils.UnauthorizedResponse(c, "Authentication required")
+			c.Abort()
+			return
+		}
+
+		if claims.UserID != "" && claims.UserID == c.Param(paramName) {
+			c.Next()
+			return
+		}
+
+		if claims.IsAPIKey() {
+			utils.ForbiddenResponse(c, "This endpoint cannot be accessed with an API key")
+			c.Abort()
+			return
+		}
+
+		for _, permission := range permissions {
+			if !HasPermission(authorizer, claims, permission) {
+				utils.ForbiddenResponse(c, "You can only access your own resources")
+				c.Abort()
+				return
+			}
+		}
+
+		if !claims.HasMFA() {
+			utils.ErrorResponse(c, http.StatusForbidden, "MFA_REQUIRED", "MFA required", "Enable MFA and login again with your MFA code to access this endpoint")
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RequireMFA middleware untuk endpoint sensitif (misal endpoint admin) yang hanya boleh diakses
+// dari login yang sudah melewati MFA (claim amr berisi mfa)
+func RequireMFA() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := CurrentUser(c)
+		if !ok {
+			utils.UnauthorizedResponse(c, "Authentication required")
+			c.Abort()
+			return
+		}
+
+		if !claims.HasMFA() {
+			utils.ErrorResponse(c, http.StatusForbidden, "MFA_REQUIRED", "MFA required", "Enable MFA and login again with your MFA code to access this endpoint")
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RequireScope middleware untuk token service-to-service, token harus punya semua scope yang diminta
+func RequireScope(scopes ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := CurrentUser(c)
+		if !ok {
+			utils.UnauthorizedResponse(c, "Authentication required")
+			c.Abort()
+			return
+		}
+
+		for _, scope := range scopes {
+			if !claims.HasScope(scope) {
+				utils.ForbiddenResponse(c, "Token does not have the required scope: "+scope)
+				c.Abort()
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
+
+// Helper function untuk mengecek role user (claim role maupun roles)
+func hasAnyRole(claims *utils.JWTClaims, roles []string) bool {
+	for _, role := range roles {
+		if claims.HasRole(role) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasPermission mengecek permission dari semua role user, untuk pengecekan di handler
+// yang bergantung pada isi request (misal field tertentu hanya boleh diubah admin)
+func HasPermission(authorizer *utils.Authorizer, claims *utils.JWTClaims, permission string) bool {
+	for _, role := range claims.AllRoles() {
+		if authorizer.HasPermission(role, permission) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jizak1/Microservices-Golang/shared/utils"
+)
+
+func TestRequireOwnerOrAdmin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	authorizer := utils.NewAuthorizer(map[string][]string{
+		"admin":   {utils.PermissionWildcard},
+		"support": {"users:update"},
+		"user":    {"users:read"},
+	})
+	mfa := utils.AuthMethods(true)
+	password := utils.AuthMethods(false)
+
+	tests := []struct {
+		name       string
+		claims     *utils.JWTClaims
+		wantStatus int
+	}{
+		{name: "owner with password login", claims: &utils.JWTClaims{UserID: "1", Roles: []string{"user"}, AMR: password}, wantStatus: http.StatusOK},
+		{name: "owner with API key", claims: &utils.JWTClaims{UserID: "1", Roles: []string{"user"}, APIKeyID: "key-1"}, wantStatus: http.StatusOK},
+		{name: "admin with MFA", claims: &utils.JWTClaims{UserID: "2", Roles: []string{"admin"}, AMR: mfa}, wantStatus: http.StatusOK},
+		{name: "custom role with permission and MFA", claims: &utils.JWTClaims{UserID: "2", Roles: []string{"support"}, AMR: mfa}, wantStatus: http.StatusOK},
+		{name: "admin without MFA", claims: &utils.JWTClaims{UserID: "2", Roles: []string{"admin"}, AMR: password}, wantStatus: http.StatusForbidden},
+		{name: "admin API key", claims: &utils.JWTClaims{UserID: "2", Roles: []string{"admin"}, APIKeyID: "key-2"}, wantStatus: http.StatusForbidden},
+		{name: "other user without permission", claims: &utils.JWTClaims{UserID: "3", Roles: []string{"user"}, AMR: mfa}, wantStatus: http.StatusForbidden},
+		{name: "anonymous", wantStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				if tt.claims != nil {
+					SetUserContext(c, tt.claims)
+				}
+				c.Next()
+			})
+			router.GET("/users/:id/sessions", RequireOwnerOrAdmin(authorizer, "id", "users:update"), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/users/1/sessions", nil))
+
+			if recorder.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", recorder.Code, tt.wantStatus)
+			}
+		})
+	}
+}
@@ -0,0 +1,263 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	"github.com/jizak1/Microservices-Golang/shared/database"
+	"github.com/jizak1/Microservices-Golang/shared/utils"
+)
+
+const (
+	idempotencyStatusInProgress = "in_progress"
+	idempotencyStatusCompleted  = "completed"
+)
+
+// IdempotencyConfig konfigurasi untuk idempotency middleware
+type IdempotencyConfig struct {
+	HeaderName  string        // Default: Idempotency-Key
+	TTL         time.Duration // Berapa lama response disimpan untuk di-replay
+	LockTTL     time.Duration // Batas waktu request yang sedang diproses
+	MaxBodySize int64         // Body lebih besar dari ini tidak di-fingerprint
+}
+
+// DefaultIdempotencyConfig konfigurasi default yang masuk akal
+func DefaultIdempotencyConfig() IdempotencyConfig {
+	return IdempotencyConfig{
+		HeaderName:  "Idempotency-Key",
+		TTL:         24 * time.Hour,
+		LockTTL:     time.Minute,
+		MaxBodySize: 1 << 20,
+	}
+}
+
+// hopByHopHeaders header yang hanya berlaku untuk satu koneksi (RFC 7230 section 6.1) sehingga tidak ikut di-replay.
+// Content-Length juga dilewati karena dihitung ulang dari body yang di-replay.
+var hopByHopHeaders = map[string]bool{
+	"Connection":          true,
+	"Keep-Alive":          true,
+	"Proxy-Authenticate":  true,
+	"Proxy-Authorization": true,
+	"Proxy-Connection":    true,
+	"Te":                  true,
+	"Trailer":             true,
+	"Transfer-Encoding":   true,
+	"Upgrade":             true,
+	"Content-Length":      true,
+}
+
+// idempotencyRecord data yang disimpan di Redis untuk setiap key
+type idempotencyRecord struct {
+	Status      string      `json:"status"`
+	Fingerprint string      `json:"fingerprint"`
+	StatusCode  int         `json:"status_code,omitempty"`
+	Header      http.Header `json:"header,omitempty"`
+	Body        []byte      `json:"body,omitempty"`
+}
+
+// idempotencyWriter menyalin response body supaya bisa disimpan
+type idempotencyWriter struct {
+	gin.ResponseWriter
+	body *bytes.Buffer
+}
+
+func (w *idempotencyWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *idempotencyWriter) WriteString(data string) (int, error) {
+	w.body.WriteString(data)
+	return w.ResponseWriter.WriteString(data)
+}
+
+// Idempotency middleware untuk mencegah duplikasi request yang di-retry.
+// Request dengan key yang sama akan mendapat response yang sama,
+// request paralel dengan key yang sama mendapat 409, dan payload berbeda dengan key sama mendapat 422.
+func Idempotency(redisClient *database.RedisClient, config IdempotencyConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		idempotencyKey := c.GetHeader(config.HeaderName)
+		if idempotencyKey == "" || isSafeMethod(c.Request.Method) {
+			c.Next()
+			return
+		}
+
+		if len(idempotencyKey) > 255 {
+			utils.ErrorResponse(c, http.StatusBadRequest, "BAD_REQUEST", "Invalid idempotency key", fmt.Sprintf("%s must be at most 255 characters", config.HeaderName))
+			c.Abort()
+			return
+		}
+
+		fingerprint, err := requestFingerprint(c, config.MaxBodySize)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusRequestEntityTooLarge, "REQUEST_TOO_LARGE", "Request too large", err.Error())
+			c.Abort()
+			return
+		}
+
+		// Key di-scope per user dan route supaya client berbeda tidak saling bentrok.
+		// Route tanpa authentication (misal POST /users) di-scope per IP client,
+		// supaya client anonim tidak bisa me-replay atau memblokir key milik client lain.
+		scope := "ip:" + c.ClientIP()
+		if userID, exists := c.Get("user_id"); exists {
+			scope = fmt.Sprintf("user:%v", userID)
+		}
+		storageKey := "idempotency:" + hashString(fmt.Sprintf("%s|%s|%s|%s", scope, c.Request.Method, c.FullPath(), idempotencyKey))
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+		defer cancel()
+
+		lock, _ := json.Marshal(idempotencyRecord{
+			Status:      idempotencyStatusInProgress,
+			Fingerprint: fingerprint,
+		})
+
+		acquired, err := redisClient.Client.SetNX(ctx, storageKey, lock, config.LockTTL).Result()
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusServiceUnavailable, "SERVICE_UNAVAILABLE", "Idempotency store unavailable", "Please retry the request later")
+			c.Abort()
+			return
+		}
+
+		if !acquired {
+			handleExistingIdempotencyRecord(c, redisClient, storageKey, fingerprint)
+			return
+		}
+
+		writer := &idempotencyWriter{ResponseWriter: c.Writer, body: &bytes.Buffer{}}
+		c.Writer = writer
+
+		// Handler yang panic tidak boleh meninggalkan lock sampai LockTTL habis,
+		// lock dilepas lalu panic diteruskan ke middleware Recovery
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				releaseCtx, releaseCancel := context.WithTimeout(context.Background(), 5*time.Second)
+				redisClient.Client.Del(releaseCtx, storageKey)
+				releaseCancel()
+				panic(recovered)
+			}
+		}()
+
+		c.Next()
+
+		saveCtx, saveCancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer saveCancel()
+
+		// Error server tidak disimpan supaya client bisa retry dengan key yang sama
+		if writer.Status() >= http.StatusInternalServerError {
+			redisClient.Client.Del(saveCtx, storageKey)
+			return
+		}
+
+		record, err := json.Marshal(idempotencyRecord{
+			Status:      idempotencyStatusCompleted,
+			Fingerprint: fingerprint,
+			StatusCode:  writer.Status(),
+			Header:      replayableHeaders(writer.Header()),
+			Body:        writer.body.Bytes(),
+		})
+		if err != nil {
+			redisClient.Client.Del(saveCtx, storageKey)
+			return
+		}
+
+		redisClient.Client.Set(saveCtx, storageKey, record, config.TTL)
+	}
+}
+
+// Helper function untuk menangani key yang sudah pernah dipakai
+func handleExistingIdempotencyRecord(c *gin.Context, redisClient *database.RedisClient, storageKey, fingerprint string) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	data, err := redisClient.Client.Get(ctx, storageKey).Bytes()
+	if err == redis.Nil {
+		// Lock baru saja expired, minta client retry
+		utils.ErrorResponse(c, http.StatusConflict, "CONFLICT", "Request in progress", "A request with this idempotency key is being processed, please retry")
+		c.Abort()
+		return
+	}
+
+	var record idempotencyRecord
+	if err != nil || json.Unmarshal(data, &record) != nil {
+		utils.ErrorResponse(c, http.StatusServiceUnavailable, "SERVICE_UNAVAILABLE", "Idempotency store unavailable", "Please retry the request later")
+		c.Abort()
+		return
+	}
+
+	if record.Fingerprint != fingerprint {
+		utils.ErrorResponse(c, http.StatusUnprocessableEntity, "IDEMPOTENCY_KEY_REUSED", "Idempotency key reused", "This idempotency key was already used with a different request payload")
+		c.Abort()
+		return
+	}
+
+	if record.Status == idempotencyStatusInProgress {
+		utils.ErrorResponse(c, http.StatusConflict, "CONFLICT", "Request in progress", "A request with this idempotency key is still being processed")
+		c.Abort()
+		return
+	}
+
+	// Replay response yang tersimpan. Header yang sudah di-set middleware untuk request ini
+	// (misal X-Request-ID, CORS dan rate limit) tidak ditimpa dengan nilai dari request pertama.
+	header := c.Writer.Header()
+	for name, values := range record.Header {
+		if _, exists := header[name]; !exists {
+			header[name] = values
+		}
+	}
+	c.Header("Idempotent-Replayed", "true")
+	c.Data(record.StatusCode, header.Get("Content-Type"), record.Body)
+	c.Abort()
+}
+
+// Helper function untuk menyalin response header yang boleh di-replay, tanpa header hop-by-hop
+// termasuk header yang disebut di header Connection
+func replayableHeaders(header http.Header) http.Header {
+	skip := map[string]bool{}
+	for _, value := range header.Values("Connection") {
+		for _, name := range strings.Split(value, ",") {
+			skip[http.CanonicalHeaderKey(strings.TrimSpace(name))] = true
+		}
+	}
+
+	replayable := http.Header{}
+	for name, values := range header {
+		if hopByHopHeaders[name] || skip[name] {
+			continue
+		}
+		replayable[name] = append([]string(nil), values...)
+	}
+	return replayable
+}
+
+// Helper function untuk membuat fingerprint dari method, path dan body
+func requestFingerprint(c *gin.Context, maxBodySize int64) (string, error) {
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxBodySize+1))
+	if err != nil {
+		return "", fmt.Errorf("failed to read request body: %w", err)
+	}
+	if int64(len(body)) > maxBodySize {
+		return "", fmt.Errorf("request body must be smaller than %d bytes", maxBodySize)
+	}
+
+	// Kembalikan body supaya handler tetap bisa membacanya
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+	return hashString(c.Request.Method + "|" + c.Request.URL.RequestURI() + "|" + string(body)), nil
+}
+
+// Helper function untuk SHA-256 hex
+func hashString(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
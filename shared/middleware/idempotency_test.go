@@ -0,0 +1,228 @@
+package middleware
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/jizak1/Microservices-Golang/shared/database"
+	"github.com/sirupsen/logrus"
+)
+
+// idempotencyTestRouter router dengan Idempotency di depan handler yang bisa diatur per test.
+// Header X-Test-User mensimulasikan middleware authentication yang men-set user_id.
+type idempotencyTestRouter struct {
+	router  *gin.Engine
+	calls   int32
+	handler func(c *gin.Context, call int32)
+}
+
+// Helper function untuk membuat router test di atas miniredis
+func newIdempotencyTestRouter(t *testing.T) *idempotencyTestRouter {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	server := miniredis.RunT(t)
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	client, err := database.NewRedisConnection(database.RedisConfig{Host: server.Host(), Port: server.Port()}, logger)
+	if err != nil {
+		t.Fatalf("NewRedisConnection() error = %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+
+	r := &idempotencyTestRouter{router: gin.New()}
+	r.handler = func(c *gin.Context, call int32) {
+		c.Header("Location", fmt.Sprintf("/orders/%d", call))
+		c.JSON(http.StatusCreated, gin.H{"call": call})
+	}
+
+	r.router.Use(gin.CustomRecovery(func(c *gin.Context, _ interface{}) {
+		c.AbortWithStatus(http.StatusInternalServerError)
+	}))
+	r.router.Use(func(c *gin.Context) {
+		if userID := c.GetHeader("X-Test-User"); userID != "" {
+			c.Set("user_id", userID)
+		}
+		c.Next()
+	})
+	r.router.Use(Idempotency(client, DefaultIdempotencyConfig()))
+	r.router.POST("/orders", func(c *gin.Context) {
+		r.handler(c, atomic.AddInt32(&r.calls, 1))
+	})
+
+	return r
+}
+
+// Helper function untuk mengirim POST /orders
+func (r *idempotencyTestRouter) post(key, body, remoteAddr, userID string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(body))
+	request.RemoteAddr = remoteAddr
+	if key != "" {
+		request.Header.Set("Idempotency-Key", key)
+	}
+	if userID != "" {
+		request.Header.Set("X-Test-User", userID)
+	}
+
+	recorder := httptest.NewRecorder()
+	r.router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestIdempotencyReplaysCompletedResponse(t *testing.T) {
+	r := newIdempotencyTestRouter(t)
+
+	first := r.post("key-1", `{"item":"book"}`, "203.0.113.1:1000", "1")
+	second := r.post("key-1", `{"item":"book"}`, "203.0.113.1:1000", "1")
+
+	if calls := atomic.LoadInt32(&r.calls); calls != 1 {
+		t.Fatalf("handler called %d times, want 1", calls)
+	}
+	if second.Code != http.StatusCreated || second.Body.String() != first.Body.String() {
+		t.Errorf("replay = %d %s, want %d %s", second.Code, second.Body.String(), first.Code, first.Body.String())
+	}
+	if second.Header().Get("Idempotent-Replayed") != "true" {
+		t.Error("replayed response is missing Idempotent-Replayed header")
+	}
+	if got := second.Header().Get("Location"); got != "/orders/1" {
+		t.Errorf("replayed Location = %q, want /orders/1", got)
+	}
+	if first.Header().Get("Idempotent-Replayed") != "" {
+		t.Error("first response must not be marked as replayed")
+	}
+}
+
+func TestIdempotencyRequestsWithoutKeyAreNotDeduplicated(t *testing.T) {
+	r := newIdempotencyTestRouter(t)
+
+	r.post("", `{"item":"book"}`, "203.0.113.1:1000", "1")
+	r.post("", `{"item":"book"}`, "203.0.113.1:1000", "1")
+
+	if calls := atomic.LoadInt32(&r.calls); calls != 2 {
+		t.Errorf("handler called %d times, want 2", calls)
+	}
+}
+
+func TestIdempotencyRejectsDifferentPayload(t *testing.T) {
+	r := newIdempotencyTestRouter(t)
+
+	r.post("key-1", `{"item":"book"}`, "203.0.113.1:1000", "1")
+	second := r.post("key-1", `{"item":"pen"}`, "203.0.113.1:1000", "1")
+
+	if second.Code != http.StatusUnprocessableEntity {
+		t.Errorf("status = %d, want %d", second.Code, http.StatusUnprocessableEntity)
+	}
+	if calls := atomic.LoadInt32(&r.calls); calls != 1 {
+		t.Errorf("handler called %d times, want 1", calls)
+	}
+}
+
+func TestIdempotencyRejectsConcurrentRequest(t *testing.T) {
+	r := newIdempotencyTestRouter(t)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	r.handler = func(c *gin.Context, call int32) {
+		if call == 1 {
+			close(started)
+			<-release
+		}
+		c.JSON(http.StatusCreated, gin.H{"call": call})
+	}
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- r.post("key-1", `{"item":"book"}`, "203.0.113.1:1000", "1") }()
+	<-started
+
+	concurrent := r.post("key-1", `{"item":"book"}`, "203.0.113.1:1000", "1")
+	close(release)
+	first := <-done
+
+	if concurrent.Code != http.StatusConflict {
+		t.Errorf("concurrent status = %d, want %d", concurrent.Code, http.StatusConflict)
+	}
+	if first.Code != http.StatusCreated {
+		t.Errorf("first status = %d, want %d", first.Code, http.StatusCreated)
+	}
+}
+
+func TestIdempotencyDoesNotStoreFailures(t *testing.T) {
+	tests := []struct {
+		name   string
+		handle func(c *gin.Context)
+	}{
+		{name: "server error", handle: func(c *gin.Context) { c.JSON(http.StatusServiceUnavailable, gin.H{"error": "unavailable"}) }},
+		{name: "panic", handle: func(c *gin.Context) { panic("handler failed") }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newIdempotencyTestRouter(t)
+			r.handler = func(c *gin.Context, call int32) {
+				if call == 1 {
+					tt.handle(c)
+					return
+				}
+				c.JSON(http.StatusCreated, gin.H{"call": call})
+			}
+
+			first := r.post("key-1", `{"item":"book"}`, "203.0.113.1:1000", "1")
+			if first.Code < http.StatusInternalServerError {
+				t.Fatalf("first status = %d, want 5xx", first.Code)
+			}
+
+			// Retry dengan key yang sama harus diproses ulang, bukan 409 karena lock tertinggal
+			retry := r.post("key-1", `{"item":"book"}`, "203.0.113.1:1000", "1")
+			if retry.Code != http.StatusCreated || retry.Header().Get("Idempotent-Replayed") != "" {
+				t.Errorf("retry status = %d replayed = %q, want fresh %d", retry.Code, retry.Header().Get("Idempotent-Replayed"), http.StatusCreated)
+			}
+			if calls := atomic.LoadInt32(&r.calls); calls != 2 {
+				t.Errorf("handler called %d times, want 2", calls)
+			}
+		})
+	}
+}
+
+func TestIdempotencyKeyScope(t *testing.T) {
+	tests := []struct {
+		name       string
+		firstAddr  string
+		firstUser  string
+		secondAddr string
+		secondUser string
+		secondBody string
+		wantStatus int
+		wantCalls  int32
+	}{
+		{name: "same anonymous client is replayed", firstAddr: "203.0.113.1:1000", secondAddr: "203.0.113.1:2000", secondBody: `{"item":"book"}`, wantStatus: http.StatusCreated, wantCalls: 1},
+		{name: "other anonymous client is not replayed", firstAddr: "203.0.113.1:1000", secondAddr: "203.0.113.2:1000", secondBody: `{"item":"book"}`, wantStatus: http.StatusCreated, wantCalls: 2},
+		{name: "other anonymous client can use different payload", firstAddr: "203.0.113.1:1000", secondAddr: "203.0.113.2:1000", secondBody: `{"item":"pen"}`, wantStatus: http.StatusCreated, wantCalls: 2},
+		{name: "same user from another IP is replayed", firstAddr: "203.0.113.1:1000", firstUser: "1", secondAddr: "203.0.113.2:1000", secondUser: "1", secondBody: `{"item":"book"}`, wantStatus: http.StatusCreated, wantCalls: 1},
+		{name: "other user is not replayed", firstAddr: "203.0.113.1:1000", firstUser: "1", secondAddr: "203.0.113.1:1000", secondUser: "2", secondBody: `{"item":"book"}`, wantStatus: http.StatusCreated, wantCalls: 2},
+		{name: "user does not share anonymous keys", firstAddr: "203.0.113.1:1000", secondAddr: "203.0.113.1:1000", secondUser: "1", secondBody: `{"item":"pen"}`, wantStatus: http.StatusCreated, wantCalls: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newIdempotencyTestRouter(t)
+
+			r.post("key-1", `{"item":"book"}`, tt.firstAddr, tt.firstUser)
+			second := r.post("key-1", tt.secondBody, tt.secondAddr, tt.secondUser)
+
+			if second.Code != tt.wantStatus {
+				t.Errorf("second status = %d, want %d", second.Code, tt.wantStatus)
+			}
+			if calls := atomic.LoadInt32(&r.calls); calls != tt.wantCalls {
+				t.Errorf("handler called %d times, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
@@ -4,13 +4,13 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v4"
+	"github.com/jizak1/Microservices-Golang/shared/utils"
 	"github.com/sirupsen/logrus"
-	"golang.org/x/time/rate"
 )
 
 // Logger middleware untuk logging request yang mudah dibaca
@@ -33,9 +33,10 @@ func Logger(logger *logrus.Logger) gin.HandlerFunc {
 func CORS() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Header("Access-Control-Allow-Origin", "*")
-		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization")
-		c.Header("Access-Control-Expose-Headers", "Content-Length")
+		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+		c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, If-Match, Idempotency-Key")
+		// Header response yang dibutuhkan client: ETag untuk If-Match, Location untuk resource baru, info rate limit dan replay idempotency
+		c.Header("Access-Control-Expose-Headers", "Content-Length, ETag, Location, Retry-After, RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset, Idempotent-Replayed")
 		c.Header("Access-Control-Allow-Credentials", "true")
 
 		if c.Request.Method == "OPTIONS" {
@@ -47,32 +48,13 @@ func CORS() gin.HandlerFunc {
 	}
 }
 
-// RateLimiter middleware untuk membatasi request rate
-func RateLimiter(requestsPerSecond int, burstSize int) gin.HandlerFunc {
-	limiter := rate.NewLimiter(rate.Limit(requestsPerSecond), burstSize)
-
-	return func(c *gin.Context) {
-		if !limiter.Allow() {
-			c.JSON(http.StatusTooManyRequests, gin.H{
-				"error":   "Rate limit exceeded",
-				"message": fmt.Sprintf("Maximum %d requests per second allowed", requestsPerSecond),
-			})
-			c.Abort()
-			return
-		}
-		c.Next()
-	}
-}
-
-// JWTAuth middleware untuk authentication dengan JWT
-func JWTAuth(secretKey string) gin.HandlerFunc {
+// JWTAuth middleware untuk authentication dengan JWT.
+// Verifier default adalah utils.JWTManager, yang sudah menerapkan policy issuer, audience dan leeway.
+func JWTAuth(verifier utils.TokenVerifier) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error":   "Authorization header required",
-				"message": "Please provide Authorization header with Bearer token",
-			})
+			utils.ErrorResponse(c, http.StatusUnauthorized, "UNAUTHORIZED", "Authorization header required", "Please provide Authorization header with Bearer token")
 			c.Abort()
 			return
 		}
@@ -80,42 +62,50 @@ func JWTAuth(secretKey string) gin.HandlerFunc {
 		// Extract token dari "Bearer <token>"
 		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
 		if tokenString == authHeader {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error":   "Invalid authorization format",
-				"message": "Authorization header must be in format: Bearer <token>",
-			})
+			utils.ErrorResponse(c, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid authorization format", "Authorization header must be in format: Bearer <token>")
 			c.Abort()
 			return
 		}
 
-		// Parse dan validate token
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-			}
-			return []byte(secretKey), nil
-		})
-
-		if err != nil || !token.Valid {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error":   "Invalid token",
-				"message": "Token is invalid or expired",
-			})
+		// Validate token dengan verifier
+		claims, err := verifier.ValidateToken(tokenString)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid token", "Token is invalid or expired")
 			c.Abort()
 			return
 		}
 
-		// Extract claims dan simpan ke context
-		if claims, ok := token.Claims.(jwt.MapClaims); ok {
-			c.Set("user_id", claims["user_id"])
-			c.Set("username", claims["username"])
-			c.Set("email", claims["email"])
+		SetUserContext(c, claims)
+		c.Next()
+	}
+}
+
+// OptionalJWTAuth middleware untuk endpoint publik yang punya tampilan tambahan untuk user login.
+// Token yang valid disimpan ke context, request tanpa token atau dengan token invalid tetap diteruskan.
+func OptionalJWTAuth(verifier utils.TokenVerifier) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authHeader := c.GetHeader("Authorization")
+		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
+		if authHeader != "" && tokenString != authHeader {
+			if claims, err := verifier.ValidateToken(tokenString); err == nil {
+				SetUserContext(c, claims)
+			}
 		}
 
 		c.Next()
 	}
 }
 
+// SetUserContext menyimpan typed claims dan field-field user ke context
+func SetUserContext(c *gin.Context, claims *utils.JWTClaims) {
+	c.Set(ContextKeyClaims, claims)
+	c.Set("user_id", claims.UserID)
+	c.Set("username", claims.Username)
+	c.Set("email", claims.Email)
+	c.Set("role", claims.Role)
+	c.Set("roles", claims.AllRoles())
+}
+
 // RequestID middleware untuk menambahkan unique request ID
 func RequestID() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -153,10 +143,7 @@ func Recovery(logger *logrus.Logger) gin.HandlerFunc {
 			"user_agent": c.Request.UserAgent(),
 		}).Error("Panic recovered")
 
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Internal server error",
-			"message": "Something went wrong, please try again later",
-		})
+		utils.ErrorResponse(c, http.StatusInternalServerError, "INTERNAL_SERVER_ERROR", "Internal server error", "Something went wrong, please try again later")
 	})
 }
 
@@ -192,10 +179,7 @@ func SecurityHeaders() gin.HandlerFunc {
 func RequestSize(maxSize int64) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		if c.Request.ContentLength > maxSize {
-			c.JSON(http.StatusRequestEntityTooLarge, gin.H{
-				"error":   "Request too large",
-				"message": fmt.Sprintf("Request body must be smaller than %d bytes", maxSize),
-			})
+			utils.ErrorResponse(c, http.StatusRequestEntityTooLarge, "REQUEST_TOO_LARGE", "Request too large", fmt.Sprintf("Request body must be smaller than %d bytes", maxSize))
 			c.Abort()
 			return
 		}
@@ -210,6 +194,10 @@ func generateRequestID() string {
 
 // GetUserFromContext helper untuk mengambil user info dari context
 func GetUserFromContext(c *gin.Context) (userID string, username string, email string, exists bool) {
+	if claims, ok := CurrentUser(c); ok {
+		return claims.UserID, claims.Username, claims.Email, true
+	}
+
 	userIDInterface, exists1 := c.Get("user_id")
 	usernameInterface, exists2 := c.Get("username")
 	emailInterface, exists3 := c.Get("email")
@@ -218,9 +206,10 @@ func GetUserFromContext(c *gin.Context) (userID string, username string, email s
 		return "", "", "", false
 	}
 
-	userID, ok1 := userIDInterface.(string)
-	username, ok2 := usernameInterface.(string)
-	email, ok3 := emailInterface.(string)
+	// user_id bisa berupa angka (misal dari session atau claims lama), jadi dikonversi ke string
+	userID, ok1 := contextString(userIDInterface)
+	username, ok2 := contextString(usernameInterface)
+	email, ok3 := contextString(emailInterface)
 
 	if !ok1 || !ok2 || !ok3 {
 		return "", "", "", false
@@ -228,3 +217,25 @@ func GetUserFromContext(c *gin.Context) (userID string, username string, email s
 
 	return userID, username, email, true
 }
+
+// Helper function untuk konversi value context ke string
+func contextString(value interface{}) (string, bool) {
+	switch v := value.(type) {
+	case string:
+		return v, true
+	case int:
+		return strconv.Itoa(v), true
+	case int64:
+		return strconv.FormatInt(v, 10), true
+	case float64:
+		// Angka dari JSON selalu float64
+		if v == float64(int64(v)) {
+			return strconv.FormatInt(int64(v), 10), true
+		}
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case fmt.Stringer:
+		return v.String(), true
+	default:
+		return "", false
+	}
+}
@@ -0,0 +1,409 @@
+package middleware
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	"github.com/jizak1/Microservices-Golang/shared/utils"
+)
+
+// RateLimitKeyFunc menentukan key limiter untuk request.
+// Return false jika policy tidak berlaku untuk request ini (misal user belum login).
+type RateLimitKeyFunc func(c *gin.Context) (string, bool)
+
+// RateLimitPolicy aturan rate limit dengan limit dan burst masing-masing
+type RateLimitPolicy struct {
+	Name       string
+	Limit      int           // Jumlah request per Period
+	Period     time.Duration // Default: 1 detik
+	Burst      int           // Jumlah request yang boleh dikirim sekaligus
+	PathPrefix string        // Kosong berarti berlaku untuk semua route
+	KeyFunc    RateLimitKeyFunc
+}
+
+// RateLimitResult hasil pengecekan limiter untuk satu policy
+type RateLimitResult struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	ResetAfter time.Duration
+	RetryAfter time.Duration
+}
+
+// RateLimitBackend interface untuk penyimpanan state limiter (in-memory atau distributed)
+type RateLimitBackend interface {
+	Allow(ctx context.Context, key string, policy RateLimitPolicy) (RateLimitResult, error)
+}
+
+// RateLimitConfig konfigurasi middleware rate limit
+type RateLimitConfig struct {
+	Policies  []RateLimitPolicy
+	Backend   RateLimitBackend
+	Allowlist []string // IP atau CIDR untuk internal caller yang tidak dibatasi, dicocokkan dengan alamat koneksi langsung
+}
+
+// KeyByClientIP membatasi berdasarkan IP client
+func KeyByClientIP() RateLimitKeyFunc {
+	return func(c *gin.Context) (string, bool) {
+		return "ip:" + c.ClientIP(), true
+	}
+}
+
+// KeyByUserID membatasi berdasarkan user yang sudah login.
+// Policy ini hanya berlaku jika RateLimit dipasang setelah middleware authentication.
+func KeyByUserID() RateLimitKeyFunc {
+	return func(c *gin.Context) (string, bool) {
+		userID, exists := c.Get("user_id")
+		if !exists || userID == nil || fmt.Sprint(userID) == "" {
+			return "", false
+		}
+		return fmt.Sprintf("user:%v", userID), true
+	}
+}
+
+// KeyByAPIKey membatasi berdasarkan API key yang sudah diverifikasi (claims dari Authenticate atau APIKeyAuth),
+// bukan header mentah, supaya key palsu tidak bisa dipakai untuk menghabiskan limit key lain.
+// Policy ini hanya berlaku jika RateLimit dipasang setelah middleware authentication.
+func KeyByAPIKey() RateLimitKeyFunc {
+	return func(c *gin.Context) (string, bool) {
+		claims, ok := CurrentUser(c)
+		if !ok || !claims.IsAPIKey() {
+			return "", false
+		}
+		return "apikey:" + claims.APIKeyID, true
+	}
+}
+
+// KeyByRouteGroup membatasi per client untuk satu kelompok route
+func KeyByRouteGroup(group string) RateLimitKeyFunc {
+	return func(c *gin.Context) (string, bool) {
+		return "route:" + group + ":" + c.ClientIP(), true
+	}
+}
+
+// RateLimit middleware untuk membatasi request rate berdasarkan policies.
+// Setiap policy punya limiter per key, jadi satu client yang berisik tidak mengganggu client lain.
+func RateLimit(config RateLimitConfig) gin.HandlerFunc {
+	allowlist := parseAllowlist(config.Allowlist)
+
+	backend := config.Backend
+	if backend == nil {
+		backend = NewMemoryRateLimitBackend(10000, 10*time.Minute)
+	}
+
+	return func(c *gin.Context) {
+		// Allowlist memakai alamat koneksi, bukan X-Forwarded-For yang bisa dipalsukan client
+		if isAllowlisted(allowlist, remoteIP(c.Request)) {
+			c.Next()
+			return
+		}
+
+		var tightest *RateLimitResult
+
+		for _, policy := range config.Policies {
+			if policy.PathPrefix != "" && !strings.HasPrefix(c.Request.URL.Path, policy.PathPrefix) {
+				continue
+			}
+
+			key, ok := policy.KeyFunc(c)
+			if !ok {
+				continue
+			}
+
+			result, err := backend.Allow(c.Request.Context(), "ratelimit:"+policy.Name+":"+key, policy)
+			if err != nil {
+				// Fail open: backend yang error tidak boleh mematikan seluruh API
+				continue
+			}
+
+			if !result.Allowed {
+				setRateLimitHeaders(c, result)
+				c.Header("Retry-After", strconv.Itoa(ceilSeconds(result.RetryAfter)))
+				utils.ErrorResponse(c, http.StatusTooManyRequests, "RATE_LIMIT_EXCEEDED", "Rate limit exceeded", fmt.Sprintf("Maximum %d requests per %s allowed, retry in %d seconds", result.Limit, normalizedPeriod(policy), ceilSeconds(result.RetryAfter)))
+				c.Abort()
+				return
+			}
+
+			if tightest == nil || result.Remaining < tightest.Remaining {
+				r := result
+				tightest = &r
+			}
+		}
+
+		if tightest != nil {
+			setRateLimitHeaders(c, *tightest)
+		}
+
+		c.Next()
+	}
+}
+
+// RateLimiter middleware untuk membatasi request rate per IP client
+func RateLimiter(requestsPerSecond int, burstSize int) gin.HandlerFunc {
+	return RateLimit(RateLimitConfig{
+		Policies: []RateLimitPolicy{
+			{
+				Name:    "client_ip",
+				Limit:   requestsPerSecond,
+				Period:  time.Second,
+				Burst:   burstSize,
+				KeyFunc: KeyByClientIP(),
+			},
+		},
+	})
+}
+
+// gcraState state Generic Cell Rate Algorithm untuk satu key
+type gcraState struct {
+	key      string
+	tat      time.Time // Theoretical arrival time
+	lastSeen time.Time
+}
+
+// MemoryRateLimitBackend backend in-memory dengan LRU eviction untuk key yang idle
+type MemoryRateLimitBackend struct {
+	mu          sync.Mutex
+	maxKeys     int
+	idleTimeout time.Duration
+	entries     map[string]*list.Element
+	lru         *list.List
+}
+
+// NewMemoryRateLimitBackend membuat instance baru MemoryRateLimitBackend
+func NewMemoryRateLimitBackend(maxKeys int, idleTimeout time.Duration) *MemoryRateLimitBackend {
+	return &MemoryRateLimitBackend{
+		maxKeys:     maxKeys,
+		idleTimeout: idleTimeout,
+		entries:     make(map[string]*list.Element),
+		lru:         list.New(),
+	}
+}
+
+// Allow mengecek dan mencatat request untuk key
+func (m *MemoryRateLimitBackend) Allow(ctx context.Context, key string, policy RateLimitPolicy) (RateLimitResult, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	m.evictIdle(now)
+
+	var state *gcraState
+	if element, ok := m.entries[key]; ok {
+		state = element.Value.(*gcraState)
+		m.lru.MoveToFront(element)
+	} else {
+		state = &gcraState{key: key, tat: now}
+		m.entries[key] = m.lru.PushFront(state)
+
+		// Evict key yang paling lama tidak dipakai jika kapasitas penuh
+		for m.maxKeys > 0 && m.lru.Len() > m.maxKeys {
+			oldest := m.lru.Back()
+			m.lru.Remove(oldest)
+			delete(m.entries, oldest.Value.(*gcraState).key)
+		}
+	}
+
+	state.lastSeen = now
+	emission, tolerance := gcraParams(policy)
+
+	tat := state.tat
+	if tat.Before(now) {
+		tat = now
+	}
+
+	newTat := tat.Add(emission)
+	allowAt := newTat.Add(-tolerance)
+
+	if now.Before(allowAt) {
+		return RateLimitResult{
+			Allowed:    false,
+			Limit:      policy.Limit,
+			Remaining:  0,
+			ResetAfter: tat.Sub(now),
+			RetryAfter: allowAt.Sub(now),
+		}, nil
+	}
+
+	state.tat = newTat
+	return RateLimitResult{
+		Allowed:    true,
+		Limit:      policy.Limit,
+		Remaining:  int((tolerance - newTat.Sub(now)) / emission),
+		ResetAfter: newTat.Sub(now),
+	}, nil
+}
+
+// Helper function untuk membuang key yang sudah idle dari belakang LRU
+func (m *MemoryRateLimitBackend) evictIdle(now time.Time) {
+	for {
+		oldest := m.lru.Back()
+		if oldest == nil {
+			return
+		}
+
+		state := oldest.Value.(*gcraState)
+		if now.Sub(state.lastSeen) < m.idleTimeout {
+			return
+		}
+
+		m.lru.Remove(oldest)
+		delete(m.entries, state.key)
+	}
+}
+
+// gcraScript implementasi GCRA atomic di Redis, memakai waktu server Redis
+// supaya semua instance service memakai jam yang sama.
+var gcraScript = redis.NewScript(`
+local emission = tonumber(ARGV[1])
+local tolerance = tonumber(ARGV[2])
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000000 + tonumber(time[2])
+
+local tat = tonumber(redis.call('GET', KEYS[1]) or now)
+if tat < now then
+	tat = now
+end
+
+local new_tat = tat + emission
+local allow_at = new_tat - tolerance
+
+if now < allow_at then
+	return {0, 0, tat - now, allow_at - now}
+end
+
+redis.call('SET', KEYS[1], new_tat, 'PX', math.ceil((new_tat - now) / 1000))
+return {1, math.floor((tolerance - (new_tat - now)) / emission), new_tat - now, 0}
+`)
+
+// RedisRateLimitBackend backend distributed supaya limit berlaku di semua instance
+type RedisRateLimitBackend struct {
+	client *redis.Client
+}
+
+// NewRedisRateLimitBackend membuat instance baru RedisRateLimitBackend
+func NewRedisRateLimitBackend(client *redis.Client) *RedisRateLimitBackend {
+	return &RedisRateLimitBackend{client: client}
+}
+
+// Allow mengecek dan mencatat request untuk key di Redis
+func (r *RedisRateLimitBackend) Allow(ctx context.Context, key string, policy RateLimitPolicy) (RateLimitResult, error) {
+	emission, tolerance := gcraParams(policy)
+
+	values, err := gcraScript.Run(ctx, r.client, []string{key}, emission.Microseconds(), tolerance.Microseconds()).Int64Slice()
+	if err != nil {
+		return RateLimitResult{}, fmt.Errorf("failed to run rate limit script: %w", err)
+	}
+
+	return RateLimitResult{
+		Allowed:    values[0] == 1,
+		Limit:      policy.Limit,
+		Remaining:  int(values[1]),
+		ResetAfter: time.Duration(values[2]) * time.Microsecond,
+		RetryAfter: time.Duration(values[3]) * time.Microsecond,
+	}, nil
+}
+
+// Helper function untuk menghitung parameter GCRA dari policy
+func gcraParams(policy RateLimitPolicy) (emission time.Duration, tolerance time.Duration) {
+	limit := policy.Limit
+	if limit <= 0 {
+		limit = 1
+	}
+
+	burst := policy.Burst
+	if burst <= 0 {
+		burst = 1
+	}
+
+	emission = normalizedPeriod(policy) / time.Duration(limit)
+	if emission <= 0 {
+		emission = time.Microsecond
+	}
+
+	return emission, emission * time.Duration(burst)
+}
+
+// Helper function untuk period default
+func normalizedPeriod(policy RateLimitPolicy) time.Duration {
+	if policy.Period <= 0 {
+		return time.Second
+	}
+	return policy.Period
+}
+
+// Helper function untuk header RateLimit-* (draft IETF)
+func setRateLimitHeaders(c *gin.Context, result RateLimitResult) {
+	c.Header("RateLimit-Limit", strconv.Itoa(result.Limit))
+	c.Header("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	c.Header("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.ResetAfter)))
+}
+
+// Helper function untuk membulatkan durasi ke atas dalam detik
+func ceilSeconds(duration time.Duration) int {
+	if duration <= 0 {
+		return 0
+	}
+	return int(math.Ceil(duration.Seconds()))
+}
+
+// Helper function untuk parsing allowlist IP/CIDR
+func parseAllowlist(entries []string) []*net.IPNet {
+	networks := []*net.IPNet{}
+
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		if !strings.Contains(entry, "/") {
+			if ip := net.ParseIP(entry); ip != nil {
+				bits := 32
+				if ip.To4() == nil {
+					bits = 128
+				}
+				entry = fmt.Sprintf("%s/%d", entry, bits)
+			}
+		}
+
+		if _, network, err := net.ParseCIDR(entry); err == nil {
+			networks = append(networks, network)
+		}
+	}
+
+	return networks
+}
+
+// Helper function untuk mengambil IP dari alamat koneksi langsung (RemoteAddr)
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(strings.TrimSpace(r.RemoteAddr))
+	if err != nil {
+		return strings.TrimSpace(r.RemoteAddr)
+	}
+	return host
+}
+
+// Helper function untuk mengecek apakah IP termasuk allowlist
+func isAllowlisted(networks []*net.IPNet, clientIP string) bool {
+	ip := net.ParseIP(clientIP)
+	if ip == nil {
+		return false
+	}
+
+	for _, network := range networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
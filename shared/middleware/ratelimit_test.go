@@ -0,0 +1,293 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestGCRAParams(t *testing.T) {
+	tests := []struct {
+		name          string
+		policy        RateLimitPolicy
+		wantEmission  time.Duration
+		wantTolerance time.Duration
+	}{
+		{
+			name:          "per second",
+			policy:        RateLimitPolicy{Limit: 10, Period: time.Second, Burst: 20},
+			wantEmission:  100 * time.Millisecond,
+			wantTolerance: 2 * time.Second,
+		},
+		{
+			name:          "per minute",
+			policy:        RateLimitPolicy{Limit: 5, Period: time.Minute, Burst: 5},
+			wantEmission:  12 * time.Second,
+			wantTolerance: time.Minute,
+		},
+		{
+			name:          "default period is one second",
+			policy:        RateLimitPolicy{Limit: 4, Burst: 1},
+			wantEmission:  250 * time.Millisecond,
+			wantTolerance: 250 * time.Millisecond,
+		},
+		{
+			name:          "zero limit and burst fall back to one",
+			policy:        RateLimitPolicy{Period: time.Second},
+			wantEmission:  time.Second,
+			wantTolerance: time.Second,
+		},
+		{
+			name:          "emission never zero",
+			policy:        RateLimitPolicy{Limit: 1 << 40, Period: time.Second, Burst: 3},
+			wantEmission:  time.Microsecond,
+			wantTolerance: 3 * time.Microsecond,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			emission, tolerance := gcraParams(tt.policy)
+			if emission != tt.wantEmission {
+				t.Errorf("emission = %v, want %v", emission, tt.wantEmission)
+			}
+			if tolerance != tt.wantTolerance {
+				t.Errorf("tolerance = %v, want %v", tolerance, tt.wantTolerance)
+			}
+		})
+	}
+}
+
+func TestMemoryRateLimitBackendAllow(t *testing.T) {
+	// Period panjang supaya waktu yang lewat selama test tidak mengisi ulang bucket
+	tests := []struct {
+		name          string
+		policy        RateLimitPolicy
+		requests      int
+		wantAllowed   int
+		wantRemaining []int // Remaining untuk setiap request yang lolos
+	}{
+		{
+			name:          "burst of one",
+			policy:        RateLimitPolicy{Name: "one", Limit: 1, Period: time.Hour, Burst: 1},
+			requests:      3,
+			wantAllowed:   1,
+			wantRemaining: []int{0},
+		},
+		{
+			name:          "burst larger than limit",
+			policy:        RateLimitPolicy{Name: "burst", Limit: 2, Period: time.Hour, Burst: 5},
+			requests:      7,
+			wantAllowed:   5,
+			wantRemaining: []int{4, 3, 2, 1, 0},
+		},
+		{
+			name:          "zero burst allows one request",
+			policy:        RateLimitPolicy{Name: "zero", Limit: 10, Period: time.Hour},
+			requests:      2,
+			wantAllowed:   1,
+			wantRemaining: []int{0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := NewMemoryRateLimitBackend(100, time.Hour)
+			emission, tolerance := gcraParams(tt.policy)
+
+			allowed := 0
+			for i := 0; i < tt.requests; i++ {
+				result, err := backend.Allow(context.Background(), "key", tt.policy)
+				if err != nil {
+					t.Fatalf("Allow() error = %v", err)
+				}
+				if result.Limit != tt.policy.Limit {
+					t.Errorf("request %d: Limit = %d, want %d", i, result.Limit, tt.policy.Limit)
+				}
+
+				if !result.Allowed {
+					// Request berikutnya baru boleh setelah satu emission interval,
+					// dan bucket penuh lagi setelah seluruh tolerance habis
+					if !approxDuration(result.RetryAfter, emission) {
+						t.Errorf("request %d: RetryAfter = %v, want about %v", i, result.RetryAfter, emission)
+					}
+					if !approxDuration(result.ResetAfter, tolerance) {
+						t.Errorf("request %d: ResetAfter = %v, want about %v", i, result.ResetAfter, tolerance)
+					}
+					if result.Remaining != 0 {
+						t.Errorf("request %d: Remaining = %d, want 0", i, result.Remaining)
+					}
+					continue
+				}
+
+				if allowed < len(tt.wantRemaining) && result.Remaining != tt.wantRemaining[allowed] {
+					t.Errorf("request %d: Remaining = %d, want %d", i, result.Remaining, tt.wantRemaining[allowed])
+				}
+				allowed++
+			}
+
+			if allowed != tt.wantAllowed {
+				t.Errorf("allowed = %d, want %d", allowed, tt.wantAllowed)
+			}
+		})
+	}
+}
+
+func TestMemoryRateLimitBackendRefill(t *testing.T) {
+	backend := NewMemoryRateLimitBackend(100, time.Hour)
+	policy := RateLimitPolicy{Name: "refill", Limit: 1, Period: 50 * time.Millisecond, Burst: 1}
+
+	if result, _ := backend.Allow(context.Background(), "key", policy); !result.Allowed {
+		t.Fatal("first request should be allowed")
+	}
+	if result, _ := backend.Allow(context.Background(), "key", policy); result.Allowed {
+		t.Fatal("second request should be limited")
+	}
+
+	time.Sleep(60 * time.Millisecond)
+
+	if result, _ := backend.Allow(context.Background(), "key", policy); !result.Allowed {
+		t.Fatal("request after one emission interval should be allowed")
+	}
+}
+
+func TestMemoryRateLimitBackendKeysAreIndependent(t *testing.T) {
+	backend := NewMemoryRateLimitBackend(100, time.Hour)
+	policy := RateLimitPolicy{Name: "keys", Limit: 1, Period: time.Hour, Burst: 1}
+
+	for _, key := range []string{"a", "b", "c"} {
+		if result, _ := backend.Allow(context.Background(), key, policy); !result.Allowed {
+			t.Errorf("first request for key %q should be allowed", key)
+		}
+	}
+}
+
+func TestMemoryRateLimitBackendEviction(t *testing.T) {
+	backend := NewMemoryRateLimitBackend(2, time.Hour)
+	policy := RateLimitPolicy{Name: "evict", Limit: 1, Period: time.Hour, Burst: 1}
+
+	for _, key := range []string{"a", "b", "c"} {
+		backend.Allow(context.Background(), key, policy)
+	}
+
+	if len(backend.entries) != 2 {
+		t.Fatalf("entries = %d, want 2", len(backend.entries))
+	}
+	// Key paling lama (a) sudah di-evict, jadi state-nya mulai dari awal lagi
+	if result, _ := backend.Allow(context.Background(), "a", policy); !result.Allowed {
+		t.Error("evicted key should start with a fresh bucket")
+	}
+}
+
+func TestCeilSeconds(t *testing.T) {
+	tests := []struct {
+		duration time.Duration
+		want     int
+	}{
+		{duration: -time.Second, want: 0},
+		{duration: 0, want: 0},
+		{duration: time.Microsecond, want: 1},
+		{duration: time.Second, want: 1},
+		{duration: 1500 * time.Millisecond, want: 2},
+		{duration: time.Minute, want: 60},
+	}
+
+	for _, tt := range tests {
+		if got := ceilSeconds(tt.duration); got != tt.want {
+			t.Errorf("ceilSeconds(%v) = %d, want %d", tt.duration, got, tt.want)
+		}
+	}
+}
+
+func TestAllowlist(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []string
+		ip      string
+		want    bool
+	}{
+		{name: "exact IPv4", entries: []string{"127.0.0.1"}, ip: "127.0.0.1", want: true},
+		{name: "different IPv4", entries: []string{"127.0.0.1"}, ip: "127.0.0.2", want: false},
+		{name: "IPv4 CIDR", entries: []string{"10.0.0.0/8"}, ip: "10.20.30.40", want: true},
+		{name: "outside IPv4 CIDR", entries: []string{"10.0.0.0/8"}, ip: "11.0.0.1", want: false},
+		{name: "exact IPv6", entries: []string{"::1"}, ip: "::1", want: true},
+		{name: "IPv6 CIDR", entries: []string{"fd00::/8"}, ip: "fd12:3456::1", want: true},
+		{name: "IPv4-mapped IPv6", entries: []string{"127.0.0.1"}, ip: "::ffff:127.0.0.1", want: true},
+		{name: "entries are trimmed", entries: []string{" 192.168.1.1 "}, ip: "192.168.1.1", want: true},
+		{name: "invalid entry ignored", entries: []string{"not-an-ip", "", "192.168.1.0/24"}, ip: "192.168.1.9", want: true},
+		{name: "invalid client IP", entries: []string{"0.0.0.0/0"}, ip: "not-an-ip", want: false},
+		{name: "empty allowlist", entries: nil, ip: "127.0.0.1", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isAllowlisted(parseAllowlist(tt.entries), tt.ip); got != tt.want {
+				t.Errorf("isAllowlisted(%v, %q) = %v, want %v", tt.entries, tt.ip, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRateLimitAllowlistUsesRemoteAddr(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name          string
+		remoteAddr    string
+		forwardedFor  string
+		wantSecondHit int
+	}{
+		{name: "allowlisted connection", remoteAddr: "127.0.0.1:5000", wantSecondHit: http.StatusOK},
+		{name: "spoofed X-Forwarded-For", remoteAddr: "203.0.113.7:5000", forwardedFor: "127.0.0.1", wantSecondHit: http.StatusTooManyRequests},
+		{name: "not allowlisted", remoteAddr: "203.0.113.8:5000", wantSecondHit: http.StatusTooManyRequests},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(RateLimit(RateLimitConfig{
+				Policies: []RateLimitPolicy{
+					{Name: "test", Limit: 1, Period: time.Hour, Burst: 1, KeyFunc: KeyByClientIP()},
+				},
+				Allowlist: []string{"127.0.0.1"},
+			}))
+			router.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			var recorder *httptest.ResponseRecorder
+			for i := 0; i < 2; i++ {
+				request := httptest.NewRequest(http.MethodGet, "/", nil)
+				request.RemoteAddr = tt.remoteAddr
+				if tt.forwardedFor != "" {
+					request.Header.Set("X-Forwarded-For", tt.forwardedFor)
+				}
+				recorder = httptest.NewRecorder()
+				router.ServeHTTP(recorder, request)
+			}
+
+			if recorder.Code != tt.wantSecondHit {
+				t.Fatalf("second request status = %d, want %d", recorder.Code, tt.wantSecondHit)
+			}
+			if recorder.Code == http.StatusTooManyRequests {
+				if recorder.Header().Get("Retry-After") != "3600" {
+					t.Errorf("Retry-After = %q, want 3600", recorder.Header().Get("Retry-After"))
+				}
+				if recorder.Header().Get("RateLimit-Remaining") != "0" {
+					t.Errorf("RateLimit-Remaining = %q, want 0", recorder.Header().Get("RateLimit-Remaining"))
+				}
+			}
+		})
+	}
+}
+
+// Helper function untuk membandingkan durasi yang dihitung dari time.Now dengan toleransi kecil
+func approxDuration(got, want time.Duration) bool {
+	diff := got - want
+	if diff < 0 {
+		diff = -diff
+	}
+	return diff <= 10*time.Millisecond
+}
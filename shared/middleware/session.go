@@ -0,0 +1,126 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jizak1/Microservices-Golang/shared/utils"
+)
+
+// SessionCookieConfig konfigurasi cookie untuk session
+type SessionCookieConfig struct {
+	Name     string
+	Domain   string
+	Path     string
+	Secure   bool
+	SameSite http.SameSite
+}
+
+// DefaultSessionCookieConfig konfigurasi cookie yang aman sebagai default
+func DefaultSessionCookieConfig() SessionCookieConfig {
+	return SessionCookieConfig{
+		Name:     "session_id",
+		Path:     "/",
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+	}
+}
+
+// SessionAuth middleware untuk authentication dengan cookie session.
+// Untuk method yang mengubah data, header X-CSRF-Token wajib cocok dengan session.
+func SessionAuth(store *utils.SessionStore, cookieConfig SessionCookieConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		sessionID, err := c.Cookie(cookieConfig.Name)
+		if err != nil || sessionID == "" {
+			utils.ErrorResponse(c, http.StatusUnauthorized, "UNAUTHORIZED", "Session required", "Please login to get a session cookie")
+			c.Abort()
+			return
+		}
+
+		session, err := store.Get(sessionID)
+		if err != nil {
+			if !errors.Is(err, utils.ErrSessionNotFound) {
+				utils.ErrorResponse(c, http.StatusServiceUnavailable, "SERVICE_UNAVAILABLE", "Session unavailable", "Failed to load session, please try again later")
+				c.Abort()
+				return
+			}
+
+			ClearSessionCookie(c, cookieConfig)
+			utils.ErrorResponse(c, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid session", "Session is invalid or expired")
+			c.Abort()
+			return
+		}
+
+		if !isSafeMethod(c.Request.Method) && !store.VerifyCSRFToken(session, c.GetHeader("X-CSRF-Token")) {
+			utils.ErrorResponse(c, http.StatusForbidden, "INVALID_CSRF_TOKEN", "Invalid CSRF token", "X-CSRF-Token header is missing or does not match the session")
+			c.Abort()
+			return
+		}
+
+		// Sliding expiration: setiap request memperpanjang session.
+		// ErrSessionNotFound di sini berarti session di-revoke setelah Get.
+		if err := store.Touch(session); err == nil {
+			SetSessionCookie(c, cookieConfig, session.ID, int(store.IdleTimeout().Seconds()))
+		} else if errors.Is(err, utils.ErrSessionNotFound) {
+			ClearSessionCookie(c, cookieConfig)
+			utils.ErrorResponse(c, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid session", "Session is invalid or expired")
+			c.Abort()
+			return
+		}
+
+		// User context sama seperti JWTAuth, sehingga RequireRole, RequirePermission dan handler tidak perlu membedakan
+		c.Set("session", session)
+		SetUserContext(c, session.Claims())
+
+		c.Next()
+	}
+}
+
+// SessionOrAuthenticate memakai SessionAuth untuk request yang membawa cookie session tanpa header Authorization,
+// request lain diteruskan ke middleware auth (misal Authenticate)
+func SessionOrAuthenticate(store *utils.SessionStore, cookieConfig SessionCookieConfig, auth gin.HandlerFunc) gin.HandlerFunc {
+	sessionAuth := SessionAuth(store, cookieConfig)
+	return func(c *gin.Context) {
+		if c.GetHeader("Authorization") == "" {
+			if sessionID, err := c.Cookie(cookieConfig.Name); err == nil && sessionID != "" {
+				sessionAuth(c)
+				return
+			}
+		}
+		auth(c)
+	}
+}
+
+// SetSessionCookie menulis cookie session ke response
+func SetSessionCookie(c *gin.Context, cookieConfig SessionCookieConfig, sessionID string, maxAge int) {
+	c.SetSameSite(cookieConfig.SameSite)
+	c.SetCookie(cookieConfig.Name, sessionID, maxAge, cookieConfig.Path, cookieConfig.Domain, cookieConfig.Secure, true)
+}
+
+// ClearSessionCookie menghapus cookie session dari browser
+func ClearSessionCookie(c *gin.Context, cookieConfig SessionCookieConfig) {
+	c.SetSameSite(cookieConfig.SameSite)
+	c.SetCookie(cookieConfig.Name, "", -1, cookieConfig.Path, cookieConfig.Domain, cookieConfig.Secure, true)
+}
+
+// GetSessionFromContext helper untuk mengambil session dari context
+func GetSessionFromContext(c *gin.Context) (*utils.Session, bool) {
+	value, exists := c.Get("session")
+	if !exists {
+		return nil, false
+	}
+
+	session, ok := value.(*utils.Session)
+	return session, ok
+}
+
+// Helper function untuk method yang tidak mengubah data
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,118 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// APIKeyScheme scheme di header Authorization untuk API key: "Authorization: ApiKey <key>"
+const APIKeyScheme = "ApiKey"
+
+// APIKeyInvalidCode kode error di response ketika API key ditolak
+const APIKeyInvalidCode = "INVALID_API_KEY"
+
+// ErrInvalidAPIKey API key tidak dikenal, sudah di-revoke, expired, atau user-nya tidak aktif
+var ErrInvalidAPIKey = errors.New("invalid api key")
+
+// APIKeyVerifier interface untuk memverifikasi API key dan mengembalikan claims user pemiliknya,
+// dengan bentuk yang sama seperti claims dari JWT
+type APIKeyVerifier interface {
+	VerifyAPIKey(ctx context.Context, key string) (*JWTClaims, error)
+}
+
+// APIKeyIdentity identitas pemilik API key, dikembalikan endpoint verifikasi user service
+type APIKeyIdentity struct {
+	APIKeyID string   `json:"api_key_id"`
+	UserID   string   `json:"user_id"`
+	Username string   `json:"username"`
+	Email    string   `json:"email"`
+	Roles    []string `json:"roles"`
+	Scopes   []string `json:"scopes"`
+}
+
+// Claims mengubah identitas API key menjadi claims, role pertama juga ditulis ke claim role
+func (i APIKeyIdentity) Claims() *JWTClaims {
+	role := ""
+	if len(i.Roles) > 0 {
+		role = i.Roles[0]
+	}
+	return &JWTClaims{
+		UserID:       i.UserID,
+		Username:     i.Username,
+		Email:        i.Email,
+		Role:         role,
+		Roles:        i.Roles,
+		APIKeyID:     i.APIKeyID,
+		APIKeyScopes: i.Scopes,
+	}
+}
+
+// RemoteAPIKeyVerifier memverifikasi API key lewat endpoint internal user service,
+// dipakai service lain (misal product service) yang tidak punya akses ke tabel api_keys.
+// httpClient sebaiknya dibuat dengan NewServiceHTTPClient supaya membawa token service.
+type RemoteAPIKeyVerifier struct {
+	verifyURL  string
+	httpClient *http.Client
+}
+
+// NewRemoteAPIKeyVerifier membuat instance baru RemoteAPIKeyVerifier
+func NewRemoteAPIKeyVerifier(verifyURL string, httpClient *http.Client) *RemoteAPIKeyVerifier {
+	return &RemoteAPIKeyVerifier{
+		verifyURL:  verifyURL,
+		httpClient: httpClient,
+	}
+}
+
+// VerifyAPIKey mengirim API key ke user service dan mengembalikan claims pemiliknya
+func (v *RemoteAPIKeyVerifier) VerifyAPIKey(ctx context.Context, key string) (*JWTClaims, error) {
+	payload, err := json.Marshal(map[string]string{"key": key})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode api key request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, v.verifyURL, bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create api key request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := v.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify api key: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read api key response: %w", err)
+	}
+
+	var result struct {
+		Data  APIKeyIdentity `json:"data"`
+		Error *ErrorInfo     `json:"error"`
+	}
+	decodeErr := json.Unmarshal(body, &result)
+
+	// 401 tanpa kode ini berarti token service yang ditolak, bukan API key-nya
+	if resp.StatusCode == http.StatusUnauthorized && decodeErr == nil && result.Error != nil &&
+		result.Error.Code == APIKeyInvalidCode {
+		return nil, ErrInvalidAPIKey
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("api key endpoint returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	if decodeErr != nil {
+		return nil, fmt.Errorf("failed to decode api key response: %w", decodeErr)
+	}
+	if result.Data.APIKeyID == "" || result.Data.UserID == "" {
+		return nil, fmt.Errorf("api key endpoint returned empty identity")
+	}
+
+	return result.Data.Claims(), nil
+}
@@ -0,0 +1,136 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/jizak1/Microservices-Golang/shared/database"
+)
+
+// Event domain event yang dikirim ke service lain, misal "user.erased"
+type Event struct {
+	ID         string          `json:"id"`
+	Type       string          `json:"type"`
+	Source     string          `json:"source"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Data       json.RawMessage `json:"data"`
+}
+
+// EventPublisher interface untuk mengirim event, implementasinya bisa diganti lewat konfigurasi
+type EventPublisher interface {
+	Publish(ctx context.Context, event Event) error
+}
+
+// EventPublisherConfig konfigurasi untuk membuat EventPublisher
+type EventPublisherConfig struct {
+	Driver    string // redis atau memory
+	Stream    string // Nama Redis stream
+	MaxLength int64  // Panjang maksimal stream (perkiraan), 0 berarti tidak dibatasi
+}
+
+// NewEventPublisher membuat EventPublisher sesuai driver yang dikonfigurasi
+func NewEventPublisher(config EventPublisherConfig, redisClient *database.RedisClient) (EventPublisher, error) {
+	switch config.Driver {
+	case "redis", "":
+		if redisClient == nil {
+			return nil, fmt.Errorf("redis event publisher requires a redis client")
+		}
+		return NewRedisStreamPublisher(redisClient.Client, config.Stream, config.MaxLength), nil
+	case "memory":
+		return NewMemoryEventPublisher(), nil
+	default:
+		return nil, fmt.Errorf("unknown event publisher driver: %s", config.Driver)
+	}
+}
+
+// NewEvent membuat event baru dengan ID random dan data yang di-encode ke JSON
+func NewEvent(eventType, source string, data interface{}) (Event, error) {
+	id, err := GenerateSecureToken(16)
+	if err != nil {
+		return Event{}, fmt.Errorf("failed to generate event id: %w", err)
+	}
+
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return Event{}, fmt.Errorf("failed to encode event data: %w", err)
+	}
+
+	return Event{
+		ID:         id,
+		Type:       eventType,
+		Source:     source,
+		OccurredAt: time.Now().UTC(),
+		Data:       encoded,
+	}, nil
+}
+
+// RedisStreamPublisher mengirim event ke Redis stream, consumer membaca dengan XREADGROUP
+type RedisStreamPublisher struct {
+	client    *redis.Client
+	stream    string
+	maxLength int64
+}
+
+// NewRedisStreamPublisher membuat instance baru RedisStreamPublisher
+func NewRedisStreamPublisher(client *redis.Client, stream string, maxLength int64) *RedisStreamPublisher {
+	return &RedisStreamPublisher{
+		client:    client,
+		stream:    stream,
+		maxLength: maxLength,
+	}
+}
+
+// Publish menambahkan event ke stream
+func (p *RedisStreamPublisher) Publish(ctx context.Context, event Event) error {
+	args := &redis.XAddArgs{
+		Stream: p.stream,
+		MaxLen: p.maxLength,
+		Approx: p.maxLength > 0,
+		Values: map[string]interface{}{
+			"id":          event.ID,
+			"type":        event.Type,
+			"source":      event.Source,
+			"occurred_at": event.OccurredAt.Format(time.RFC3339Nano),
+			"data":        string(event.Data),
+		},
+	}
+
+	if err := p.client.XAdd(ctx, args).Err(); err != nil {
+		return fmt.Errorf("failed to publish event: %w", err)
+	}
+	return nil
+}
+
+// MemoryEventPublisher menyimpan event di memory, untuk testing
+type MemoryEventPublisher struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+// NewMemoryEventPublisher membuat instance baru MemoryEventPublisher
+func NewMemoryEventPublisher() *MemoryEventPublisher {
+	return &MemoryEventPublisher{}
+}
+
+// Publish menyimpan event
+func (p *MemoryEventPublisher) Publish(ctx context.Context, event Event) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.events = append(p.events, event)
+	return nil
+}
+
+// Events mengembalikan salinan semua event yang sudah dikirim
+func (p *MemoryEventPublisher) Events() []Event {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	events := make([]Event, len(p.events))
+	copy(events, p.events)
+	return events
+}
@@ -0,0 +1,172 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// JWKSHandler handler untuk GET /.well-known/jwks.json
+func JWKSHandler(keyRing *KeyRing) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		set, err := keyRing.JWKS()
+		if err != nil {
+			InternalServerErrorResponse(c, "Failed to build JWKS")
+			return
+		}
+
+		// Cache pendek supaya key baru cepat terlihat saat rotasi
+		c.Header("Cache-Control", "public, max-age=300")
+		c.JSON(http.StatusOK, set)
+	}
+}
+
+// RemoteJWKSVerifier memverifikasi token dengan public key dari JWKS service lain.
+// JWKS di-cache dan di-refresh otomatis ketika TTL habis atau ada kid yang belum dikenal.
+type RemoteJWKSVerifier struct {
+	url             string
+	issuer          string
+	options         JWTOptions
+	httpClient      *http.Client
+	cacheTTL        time.Duration
+	minRefreshDelay time.Duration
+
+	mu          sync.RWMutex
+	keyRing     *KeyRing
+	fetchedAt   time.Time
+	lastAttempt time.Time
+}
+
+// NewRemoteJWKSVerifier membuat instance baru RemoteJWKSVerifier
+func NewRemoteJWKSVerifier(url, issuer string, options JWTOptions, cacheTTL time.Duration) *RemoteJWKSVerifier {
+	return &RemoteJWKSVerifier{
+		url:             url,
+		issuer:          issuer,
+		options:         options,
+		httpClient:      &http.Client{Timeout: 5 * time.Second},
+		cacheTTL:        cacheTTL,
+		minRefreshDelay: 10 * time.Second,
+		keyRing:         NewKeyRing(),
+	}
+}
+
+// ValidateToken memvalidasi token dengan public key remote
+func (v *RemoteJWKSVerifier) ValidateToken(tokenString string) (*JWTClaims, error) {
+	if err := v.ensureFresh(false); err != nil && v.isEmpty() {
+		return nil, err
+	}
+
+	parser := jwt.NewParser(jwt.WithoutClaimsValidation())
+	token, err := parser.ParseWithClaims(tokenString, &JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
+		keyID, _ := token.Header["kid"].(string)
+
+		// Kid belum dikenal kemungkinan karena rotasi, coba refresh JWKS sekali
+		if _, ok := v.currentKeyRing().Key(keyID); !ok {
+			_ = v.ensureFresh(true)
+		}
+
+		return KeyRingKeyFunc(v.currentKeyRing())(token)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse token: %w", err)
+	}
+
+	claims, ok := token.Claims.(*JWTClaims)
+	if !ok || !token.Valid {
+		return nil, fmt.Errorf("token is invalid")
+	}
+
+	if err := ValidateClaimsPolicy(claims, v.issuer, v.options); err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
+
+// Refresh mengambil ulang JWKS dari remote
+func (v *RemoteJWKSVerifier) Refresh(ctx context.Context) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, v.url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create JWKS request: %w", err)
+	}
+
+	response, err := v.httpClient.Do(request)
+	if err != nil {
+		return fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to fetch JWKS: unexpected status %d", response.StatusCode)
+	}
+
+	var set JWKSet
+	if err := json.NewDecoder(response.Body).Decode(&set); err != nil {
+		return fmt.Errorf("failed to decode JWKS: %w", err)
+	}
+
+	ring := NewKeyRing()
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+
+		publicKey, err := jwk.PublicKey()
+		if err != nil {
+			// Key yang tidak didukung dilewati, bukan membatalkan seluruh JWKS
+			continue
+		}
+
+		algorithm := jwk.Algorithm
+		if algorithm == "" {
+			if algorithm, err = algorithmForKey(publicKey); err != nil {
+				continue
+			}
+		}
+
+		if err := ring.AddKey(&SigningKey{KeyID: jwk.KeyID, Algorithm: algorithm, PublicKey: publicKey}); err != nil {
+			continue
+		}
+	}
+
+	v.mu.Lock()
+	v.keyRing = ring
+	v.fetchedAt = time.Now()
+	v.mu.Unlock()
+
+	return nil
+}
+
+// Helper function untuk refresh jika cache expired (atau dipaksa karena kid baru)
+func (v *RemoteJWKSVerifier) ensureFresh(force bool) error {
+	v.mu.Lock()
+	stale := time.Since(v.fetchedAt) > v.cacheTTL
+	throttled := time.Since(v.lastAttempt) < v.minRefreshDelay
+	if (!stale && !force) || throttled {
+		v.mu.Unlock()
+		return nil
+	}
+	v.lastAttempt = time.Now()
+	v.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	return v.Refresh(ctx)
+}
+
+func (v *RemoteJWKSVerifier) currentKeyRing() *KeyRing {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.keyRing
+}
+
+func (v *RemoteJWKSVerifier) isEmpty() bool {
+	return len(v.currentKeyRing().Keys()) == 0
+}
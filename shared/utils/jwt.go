@@ -2,6 +2,7 @@ package utils
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v4"
@@ -9,45 +10,237 @@ import (
 
 // JWTClaims struktur claims untuk JWT token
 type JWTClaims struct {
-	UserID   string `json:"user_id"`
-	Username string `json:"username"`
-	Email    string `json:"email"`
-	Role     string `json:"role"`
+	UserID    string   `json:"user_id"`
+	Username  string   `json:"username"`
+	Email     string   `json:"email"`
+	Role      string   `json:"role"`            // Role utama, dipertahankan untuk kompatibilitas
+	Roles     []string `json:"roles,omitempty"` // Semua role efektif user
+	SessionID string   `json:"sid,omitempty"`   // Sesi login (refresh token family), untuk revoke per sesi
+	AMR       []string `json:"amr,omitempty"`   // Metode authentication saat login (RFC 8176), contoh: pwd, mfa
+
+	// Untuk token service-to-service (client credentials)
+	ClientID string `json:"client_id,omitempty"`
+	Scope    string `json:"scope,omitempty"` // Dipisah spasi, sesuai OAuth 2.0
+
+	// Untuk request dengan API key, diisi oleh APIKeyVerifier dan tidak pernah ditulis ke token
+	APIKeyID     string   `json:"-"`
+	APIKeyScopes []string `json:"-"`
 	jwt.RegisteredClaims
 }
 
+// HasMFA mengecek apakah login yang menghasilkan token ini sudah melewati MFA
+func (c *JWTClaims) HasMFA() bool {
+	return containsString(c.AMR, AMRMultiFactor)
+}
+
+// IsAPIKey mengecek apakah claims berasal dari API key, bukan dari JWT
+func (c *JWTClaims) IsAPIKey() bool {
+	return c.APIKeyID != ""
+}
+
+// HasAPIKeyScope mengecek apakah API key punya scope tertentu
+func (c *JWTClaims) HasAPIKeyScope(scope string) bool {
+	return containsString(c.APIKeyScopes, scope)
+}
+
+// Scopes mengembalikan daftar scope dari claim scope
+func (c *JWTClaims) Scopes() []string {
+	return strings.Fields(c.Scope)
+}
+
+// HasScope mengecek apakah token punya scope tertentu
+func (c *JWTClaims) HasScope(scope string) bool {
+	for _, s := range c.Scopes() {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// AllRoles mengembalikan semua role di token, termasuk claim role lama
+func (c *JWTClaims) AllRoles() []string {
+	if c.Role == "" || containsString(c.Roles, c.Role) {
+		return c.Roles
+	}
+	return append([]string{c.Role}, c.Roles...)
+}
+
+// HasRole mengecek apakah token punya role tertentu
+func (c *JWTClaims) HasRole(role string) bool {
+	return c.Role == role || containsString(c.Roles, role)
+}
+
+// Nilai claim amr (RFC 8176)
+const (
+	AMRPassword    = "pwd"
+	AMRMultiFactor = "mfa"
+)
+
+// AuthMethods mengembalikan nilai claim amr untuk login dengan password, ditambah mfa jika kode MFA sudah diverifikasi
+func AuthMethods(mfa bool) []string {
+	if mfa {
+		return []string{AMRPassword, AMRMultiFactor}
+	}
+	return []string{AMRPassword}
+}
+
+// TokenVerifier interface untuk memverifikasi token dan mengembalikan claims
+type TokenVerifier interface {
+	ValidateToken(tokenString string) (*JWTClaims, error)
+}
+
+// JWTOptions policy tambahan untuk pembuatan dan validasi token
+type JWTOptions struct {
+	Audience       []string      // Ditulis ke token, dan token wajib punya salah satunya saat validasi
+	Leeway         time.Duration // Toleransi perbedaan jam antar server untuk exp, nbf dan iat
+	RequiredClaims []string      // Claims yang wajib ada, contoh: "sub", "jti", "user_id"
+}
+
 // JWTManager untuk mengelola JWT tokens
 type JWTManager struct {
 	secretKey string
 	issuer    string
+	options   JWTOptions
+	keyRing   *KeyRing // Jika diisi, token ditandatangani dengan key asymmetric (RS256/ES256/EdDSA)
 }
 
 // NewJWTManager membuat instance baru JWTManager
 func NewJWTManager(secretKey, issuer string) *JWTManager {
+	return NewJWTManagerWithOptions(secretKey, issuer, JWTOptions{})
+}
+
+// NewJWTManagerWithOptions membuat JWTManager dengan policy audience, leeway dan required claims
+func NewJWTManagerWithOptions(secretKey, issuer string, options JWTOptions) *JWTManager {
 	return &JWTManager{
 		secretKey: secretKey,
 		issuer:    issuer,
+		options:   options,
 	}
 }
 
+// NewJWTManagerWithKeyRing membuat JWTManager yang menandatangani token dengan key asymmetric.
+// Service lain cukup memverifikasi dengan public key dari JWKS, tanpa bisa membuat token.
+func NewJWTManagerWithKeyRing(keyRing *KeyRing, issuer string, options JWTOptions) *JWTManager {
+	return &JWTManager{
+		issuer:  issuer,
+		options: options,
+		keyRing: keyRing,
+	}
+}
+
+// KeyRing mengembalikan keyring yang dipakai (nil untuk mode HS256)
+func (j *JWTManager) KeyRing() *KeyRing {
+	return j.keyRing
+}
+
 // GenerateToken membuat JWT token baru
 func (j *JWTManager) GenerateToken(userID, username, email, role string, expiration time.Duration) (string, error) {
+	return j.generateUserToken(userID, username, email, role, nil, "", nil, expiration)
+}
+
+// GenerateTokenWithRoles membuat JWT token untuk user dengan banyak role.
+// Role pertama juga ditulis ke claim role untuk service yang belum membaca claim roles.
+func (j *JWTManager) GenerateTokenWithRoles(userID, username, email string, roles []string, expiration time.Duration) (string, error) {
+	role := ""
+	if len(roles) > 0 {
+		role = roles[0]
+	}
+	return j.generateUserToken(userID, username, email, role, roles, "", nil, expiration)
+}
+
+// GenerateSessionToken membuat JWT token untuk user yang terikat ke satu sesi login (claim sid),
+// supaya semua access token dari sesi tersebut bisa di-revoke sekaligus. amr berisi metode login sesi tersebut.
+func (j *JWTManager) GenerateSessionToken(userID, username, email string, roles []string, sessionID string, amr []string,
+	expiration time.Duration) (string, error) {
+	role := ""
+	if len(roles) > 0 {
+		role = roles[0]
+	}
+	return j.generateUserToken(userID, username, email, role, roles, sessionID, amr, expiration)
+}
+
+// Helper function untuk membuat token user
+func (j *JWTManager) generateUserToken(userID, username, email, role string, roles []string, sessionID string, amr []string,
+	expiration time.Duration) (string, error) {
+	// jti unik supaya token bisa di-revoke satu per satu
+	tokenID, err := GenerateSecureToken(16)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate token ID: %w", err)
+	}
+
 	claims := JWTClaims{
-		UserID:   userID,
-		Username: username,
-		Email:    email,
-		Role:     role,
+		UserID:    userID,
+		Username:  username,
+		Email:     email,
+		Role:      role,
+		Roles:     roles,
+		SessionID: sessionID,
+		AMR:       amr,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiration)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			NotBefore: jwt.NewNumericDate(time.Now()),
 			Issuer:    j.issuer,
 			Subject:   userID,
+			Audience:  j.options.Audience,
+			ID:        tokenID,
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString([]byte(j.secretKey))
+	return j.signClaims(claims)
+}
+
+// GenerateServiceToken membuat token untuk service lain (client credentials) dengan audience dan scope
+func (j *JWTManager) GenerateServiceToken(clientID string, audience []string, scopes []string, expiration time.Duration) (string, error) {
+	tokenID, err := GenerateSecureToken(16)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate token ID: %w", err)
+	}
+
+	now := time.Now()
+	claims := JWTClaims{
+		ClientID: clientID,
+		Scope:    strings.Join(scopes, " "),
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(now.Add(expiration)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			Issuer:    j.issuer,
+			Subject:   clientID,
+			Audience:  audience,
+			ID:        tokenID,
+		},
+	}
+
+	return j.signClaims(claims)
+}
+
+// signClaims menandatangani claims dengan key aktif di keyring atau dengan secret HS256
+func (j *JWTManager) signClaims(claims jwt.Claims) (string, error) {
+	if j.keyRing == nil {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+		tokenString, err := token.SignedString([]byte(j.secretKey))
+		if err != nil {
+			return "", fmt.Errorf("failed to sign token: %w", err)
+		}
+		return tokenString, nil
+	}
+
+	key, err := j.keyRing.ActiveKey()
+	if err != nil {
+		return "", err
+	}
+
+	method, err := key.SigningMethod()
+	if err != nil {
+		return "", err
+	}
+
+	token := jwt.NewWithClaims(method, claims)
+	token.Header["kid"] = key.KeyID
+
+	tokenString, err := token.SignedString(key.PrivateKey)
 	if err != nil {
 		return "", fmt.Errorf("failed to sign token: %w", err)
 	}
@@ -57,12 +250,10 @@ func (j *JWTManager) GenerateToken(userID, username, email, role string, expirat
 
 // ValidateToken memvalidasi JWT token dan mengembalikan claims
 func (j *JWTManager) ValidateToken(tokenString string) (*JWTClaims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(j.secretKey), nil
-	})
+	// Validasi waktu dilakukan manual di validateClaims supaya leeway bisa diterapkan
+	parser := jwt.NewParser(jwt.WithoutClaimsValidation())
+
+	token, err := parser.ParseWithClaims(tokenString, &JWTClaims{}, j.keyFunc)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse token: %w", err)
@@ -77,18 +268,130 @@ func (j *JWTManager) ValidateToken(tokenString string) (*JWTClaims, error) {
 		return nil, fmt.Errorf("failed to parse claims")
 	}
 
+	if err := j.validateClaims(claims); err != nil {
+		return nil, err
+	}
+
 	return claims, nil
 }
 
-// RefreshToken membuat token baru dengan expiration yang diperpanjang
-func (j *JWTManager) RefreshToken(tokenString string, newExpiration time.Duration) (string, error) {
-	claims, err := j.ValidateToken(tokenString)
-	if err != nil {
-		return "", fmt.Errorf("invalid token for refresh: %w", err)
+// keyFunc memilih key verifikasi berdasarkan header token
+func (j *JWTManager) keyFunc(token *jwt.Token) (interface{}, error) {
+	if j.keyRing == nil {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return []byte(j.secretKey), nil
+	}
+
+	return KeyRingKeyFunc(j.keyRing)(token)
+}
+
+// KeyRingKeyFunc membuat jwt.Keyfunc yang mencari public key berdasarkan kid.
+// Algoritma token harus sama dengan algoritma key untuk mencegah algorithm confusion.
+func KeyRingKeyFunc(keyRing *KeyRing) jwt.Keyfunc {
+	return func(token *jwt.Token) (interface{}, error) {
+		keyID, _ := token.Header["kid"].(string)
+		if keyID == "" {
+			return nil, fmt.Errorf("token has no kid header")
+		}
+
+		key, ok := keyRing.Key(keyID)
+		if !ok {
+			return nil, fmt.Errorf("unknown signing key: %s", keyID)
+		}
+
+		if token.Method.Alg() != key.Algorithm {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+
+		return key.PublicKey, nil
 	}
+}
+
+// validateClaims menerapkan policy issuer, audience, leeway dan required claims
+func (j *JWTManager) validateClaims(claims *JWTClaims) error {
+	return ValidateClaimsPolicy(claims, j.issuer, j.options)
+}
+
+// ValidateClaimsPolicy memvalidasi registered claims dengan issuer dan options yang diberikan
+func ValidateClaimsPolicy(claims *JWTClaims, issuer string, options JWTOptions) error {
+	now := time.Now()
+	leeway := options.Leeway
 
-	// Buat token baru dengan expiration yang diperpanjang
-	return j.GenerateToken(claims.UserID, claims.Username, claims.Email, claims.Role, newExpiration)
+	if claims.ExpiresAt == nil {
+		return fmt.Errorf("token has no expiration")
+	}
+	if now.After(claims.ExpiresAt.Time.Add(leeway)) {
+		return fmt.Errorf("token has expired")
+	}
+	if claims.NotBefore != nil && now.Add(leeway).Before(claims.NotBefore.Time) {
+		return fmt.Errorf("token is not valid yet")
+	}
+	if claims.IssuedAt != nil && now.Add(leeway).Before(claims.IssuedAt.Time) {
+		return fmt.Errorf("token was issued in the future")
+	}
+
+	if issuer != "" && claims.Issuer != issuer {
+		return fmt.Errorf("invalid token issuer: %s", claims.Issuer)
+	}
+
+	if len(options.Audience) > 0 {
+		matched := false
+		for _, audience := range options.Audience {
+			if claims.VerifyAudience(audience, true) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return fmt.Errorf("token audience is not accepted")
+		}
+	}
+
+	for _, claim := range options.RequiredClaims {
+		if !hasClaim(claims, claim) {
+			return fmt.Errorf("token is missing required claim: %s", claim)
+		}
+	}
+
+	return nil
+}
+
+// Helper function untuk mengecek apakah claim terisi
+func hasClaim(claims *JWTClaims, name string) bool {
+	switch name {
+	case "sub":
+		return claims.Subject != ""
+	case "iss":
+		return claims.Issuer != ""
+	case "aud":
+		return len(claims.Audience) > 0
+	case "exp":
+		return claims.ExpiresAt != nil
+	case "nbf":
+		return claims.NotBefore != nil
+	case "iat":
+		return claims.IssuedAt != nil
+	case "jti":
+		return claims.ID != ""
+	case "user_id":
+		return claims.UserID != ""
+	case "username":
+		return claims.Username != ""
+	case "email":
+		return claims.Email != ""
+	case "role":
+		return claims.Role != ""
+	case "roles":
+		return len(claims.Roles) > 0
+	case "client_id":
+		return claims.ClientID != ""
+	case "scope":
+		return claims.Scope != ""
+	default:
+		return false
+	}
 }
 
 // ExtractUserID mengambil user ID dari token
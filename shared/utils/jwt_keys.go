@@ -0,0 +1,413 @@
+package utils
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// Algoritma asymmetric yang didukung
+const (
+	AlgorithmRS256 = "RS256"
+	AlgorithmES256 = "ES256"
+	AlgorithmEdDSA = "EdDSA"
+)
+
+// SigningKey satu key di keyring. PrivateKey kosong berarti key hanya untuk verifikasi.
+type SigningKey struct {
+	KeyID      string
+	Algorithm  string
+	PrivateKey crypto.Signer
+	PublicKey  crypto.PublicKey
+}
+
+// SigningMethod mengembalikan jwt.SigningMethod sesuai algoritma key
+func (k *SigningKey) SigningMethod() (jwt.SigningMethod, error) {
+	switch k.Algorithm {
+	case AlgorithmRS256:
+		return jwt.SigningMethodRS256, nil
+	case AlgorithmES256:
+		return jwt.SigningMethodES256, nil
+	case AlgorithmEdDSA:
+		return jwt.SigningMethodEdDSA, nil
+	default:
+		return nil, fmt.Errorf("unsupported signing algorithm: %s", k.Algorithm)
+	}
+}
+
+// KeyRing menyimpan satu key aktif untuk signing dan beberapa key untuk verifikasi.
+// Saat rotasi, key lama tetap ada di keyring sampai semua token yang ditandatanganinya expired.
+type KeyRing struct {
+	mu        sync.RWMutex
+	activeKID string
+	keys      map[string]*SigningKey
+}
+
+// NewKeyRing membuat instance baru KeyRing
+func NewKeyRing() *KeyRing {
+	return &KeyRing{
+		keys: make(map[string]*SigningKey),
+	}
+}
+
+// AddKey menambahkan key ke keyring
+func (k *KeyRing) AddKey(key *SigningKey) error {
+	if key.PublicKey == nil {
+		return fmt.Errorf("key %s has no public key", key.KeyID)
+	}
+	if _, err := key.SigningMethod(); err != nil {
+		return err
+	}
+
+	if key.KeyID == "" {
+		thumbprint, err := JWKThumbprint(key.PublicKey)
+		if err != nil {
+			return err
+		}
+		key.KeyID = thumbprint
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	// Public key yang sama dengan key signing tidak boleh menimpa private key-nya
+	if existing, ok := k.keys[key.KeyID]; ok && existing.PrivateKey != nil && key.PrivateKey == nil {
+		return nil
+	}
+
+	k.keys[key.KeyID] = key
+	return nil
+}
+
+// SetActive memilih key yang dipakai untuk signing token baru
+func (k *KeyRing) SetActive(keyID string) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	key, ok := k.keys[keyID]
+	if !ok {
+		return fmt.Errorf("key not found: %s", keyID)
+	}
+	if key.PrivateKey == nil {
+		return fmt.Errorf("key %s has no private key and cannot sign", keyID)
+	}
+
+	k.activeKID = keyID
+	return nil
+}
+
+// RemoveKey menghapus key yang sudah tidak dipakai untuk verifikasi
+func (k *KeyRing) RemoveKey(keyID string) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if keyID == k.activeKID {
+		return fmt.Errorf("cannot remove active signing key: %s", keyID)
+	}
+
+	delete(k.keys, keyID)
+	return nil
+}
+
+// ActiveKey mengembalikan key yang dipakai untuk signing
+func (k *KeyRing) ActiveKey() (*SigningKey, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	key, ok := k.keys[k.activeKID]
+	if !ok {
+		return nil, fmt.Errorf("no active signing key configured")
+	}
+	return key, nil
+}
+
+// Key mengambil key berdasarkan kid
+func (k *KeyRing) Key(keyID string) (*SigningKey, bool) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	key, ok := k.keys[keyID]
+	return key, ok
+}
+
+// Keys mengembalikan semua key, diurutkan berdasarkan kid
+func (k *KeyRing) Keys() []*SigningKey {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	result := make([]*SigningKey, 0, len(k.keys))
+	for _, key := range k.keys {
+		result = append(result, key)
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].KeyID < result[j].KeyID })
+	return result
+}
+
+// JWKS mengembalikan public keys dalam format JSON Web Key Set
+func (k *KeyRing) JWKS() (*JWKSet, error) {
+	set := &JWKSet{Keys: []JWK{}}
+
+	for _, key := range k.Keys() {
+		jwk, err := PublicKeyToJWK(key.PublicKey, key.KeyID, key.Algorithm)
+		if err != nil {
+			return nil, err
+		}
+		set.Keys = append(set.Keys, *jwk)
+	}
+
+	return set, nil
+}
+
+// LoadPrivateKeyPEM membaca private key (PKCS#1, PKCS#8 atau SEC1) dari file PEM.
+// Kalau keyID kosong, kid dihitung dari JWK thumbprint (RFC 7638).
+func LoadPrivateKeyPEM(path, keyID string) (*SigningKey, error) {
+	block, err := readPEMBlock(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var privateKey interface{}
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		privateKey, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		privateKey, err = x509.ParseECPrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		privateKey, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block type %q in %s", block.Type, path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key %s: %w", path, err)
+	}
+
+	signer, ok := privateKey.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("private key in %s cannot sign", path)
+	}
+
+	algorithm, err := algorithmForKey(signer.Public())
+	if err != nil {
+		return nil, err
+	}
+
+	return &SigningKey{
+		KeyID:      keyID,
+		Algorithm:  algorithm,
+		PrivateKey: signer,
+		PublicKey:  signer.Public(),
+	}, nil
+}
+
+// LoadPublicKeyPEM membaca public key (PKIX) dari file PEM untuk verifikasi saja
+func LoadPublicKeyPEM(path, keyID string) (*SigningKey, error) {
+	block, err := readPEMBlock(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if block.Type != "PUBLIC KEY" {
+		return nil, fmt.Errorf("unsupported PEM block type %q in %s", block.Type, path)
+	}
+
+	publicKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key %s: %w", path, err)
+	}
+
+	algorithm, err := algorithmForKey(publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SigningKey{
+		KeyID:     keyID,
+		Algorithm: algorithm,
+		PublicKey: publicKey,
+	}, nil
+}
+
+// LoadKeyRing membuat keyring dari satu private key aktif dan beberapa public key untuk rotasi
+func LoadKeyRing(privateKeyPath string, verificationKeyPaths []string) (*KeyRing, error) {
+	ring := NewKeyRing()
+
+	activeKey, err := LoadPrivateKeyPEM(privateKeyPath, "")
+	if err != nil {
+		return nil, err
+	}
+	if err := ring.AddKey(activeKey); err != nil {
+		return nil, err
+	}
+	if err := ring.SetActive(activeKey.KeyID); err != nil {
+		return nil, err
+	}
+
+	for _, path := range verificationKeyPaths {
+		key, err := LoadPublicKeyPEM(path, "")
+		if err != nil {
+			return nil, err
+		}
+		if err := ring.AddKey(key); err != nil {
+			return nil, err
+		}
+	}
+
+	return ring, nil
+}
+
+// JWK satu JSON Web Key (hanya public key)
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use,omitempty"`
+	Algorithm string `json:"alg,omitempty"`
+	Curve     string `json:"crv,omitempty"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+	X         string `json:"x,omitempty"`
+	Y         string `json:"y,omitempty"`
+}
+
+// JWKSet kumpulan JWK seperti di /.well-known/jwks.json
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// PublicKeyToJWK mengubah public key menjadi JWK
+func PublicKeyToJWK(publicKey crypto.PublicKey, keyID, algorithm string) (*JWK, error) {
+	jwk := &JWK{KeyID: keyID, Use: "sig", Algorithm: algorithm}
+
+	switch key := publicKey.(type) {
+	case *rsa.PublicKey:
+		jwk.KeyType = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(key.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes())
+	case *ecdsa.PublicKey:
+		if key.Curve != elliptic.P256() {
+			return nil, fmt.Errorf("unsupported EC curve: %s", key.Curve.Params().Name)
+		}
+		jwk.KeyType = "EC"
+		jwk.Curve = "P-256"
+		jwk.X = base64.RawURLEncoding.EncodeToString(key.X.FillBytes(make([]byte, 32)))
+		jwk.Y = base64.RawURLEncoding.EncodeToString(key.Y.FillBytes(make([]byte, 32)))
+	case ed25519.PublicKey:
+		jwk.KeyType = "OKP"
+		jwk.Curve = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(key)
+	default:
+		return nil, fmt.Errorf("unsupported public key type: %T", publicKey)
+	}
+
+	return jwk, nil
+}
+
+// PublicKey mengubah JWK kembali menjadi public key
+func (j *JWK) PublicKey() (crypto.PublicKey, error) {
+	switch j.KeyType {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(j.N)
+		if err != nil {
+			return nil, fmt.Errorf("invalid RSA modulus: %w", err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(j.E)
+		if err != nil {
+			return nil, fmt.Errorf("invalid RSA exponent: %w", err)
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		if j.Curve != "P-256" {
+			return nil, fmt.Errorf("unsupported EC curve: %s", j.Curve)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(j.X)
+		if err != nil {
+			return nil, fmt.Errorf("invalid EC x coordinate: %w", err)
+		}
+		y, err := base64.RawURLEncoding.DecodeString(j.Y)
+		if err != nil {
+			return nil, fmt.Errorf("invalid EC y coordinate: %w", err)
+		}
+		key := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !key.Curve.IsOnCurve(key.X, key.Y) {
+			return nil, fmt.Errorf("EC point is not on curve")
+		}
+		return key, nil
+	case "OKP":
+		if j.Curve != "Ed25519" {
+			return nil, fmt.Errorf("unsupported OKP curve: %s", j.Curve)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(j.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid Ed25519 public key")
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, fmt.Errorf("unsupported key type: %s", j.KeyType)
+	}
+}
+
+// JWKThumbprint menghitung thumbprint RFC 7638 untuk dipakai sebagai kid
+func JWKThumbprint(publicKey crypto.PublicKey) (string, error) {
+	jwk, err := PublicKeyToJWK(publicKey, "", "")
+	if err != nil {
+		return "", err
+	}
+
+	// Member wajib dalam urutan leksikografis, tanpa whitespace
+	var canonical string
+	switch jwk.KeyType {
+	case "RSA":
+		canonical = fmt.Sprintf(`{"e":"%s","kty":"RSA","n":"%s"}`, jwk.E, jwk.N)
+	case "EC":
+		canonical = fmt.Sprintf(`{"crv":"%s","kty":"EC","x":"%s","y":"%s"}`, jwk.Curve, jwk.X, jwk.Y)
+	case "OKP":
+		canonical = fmt.Sprintf(`{"crv":"%s","kty":"OKP","x":"%s"}`, jwk.Curve, jwk.X)
+	}
+
+	sum := sha256.Sum256([]byte(canonical))
+	return base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
+
+// Helper function untuk menentukan algoritma dari tipe key
+func algorithmForKey(publicKey crypto.PublicKey) (string, error) {
+	switch key := publicKey.(type) {
+	case *rsa.PublicKey:
+		return AlgorithmRS256, nil
+	case *ecdsa.PublicKey:
+		if key.Curve != elliptic.P256() {
+			return "", fmt.Errorf("unsupported EC curve: %s", key.Curve.Params().Name)
+		}
+		return AlgorithmES256, nil
+	case ed25519.PublicKey:
+		return AlgorithmEdDSA, nil
+	default:
+		return "", fmt.Errorf("unsupported key type: %T", publicKey)
+	}
+}
+
+// Helper function untuk membaca block PEM pertama dari file
+func readPEMBlock(path string) (*pem.Block, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key file %s: %w", path, err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data found in %s", path)
+	}
+	return block, nil
+}
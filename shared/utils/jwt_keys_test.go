@@ -0,0 +1,279 @@
+package utils
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+)
+
+// Public key dengan method Equal (rsa, ecdsa dan ed25519 semuanya punya)
+type comparablePublicKey interface {
+	Equal(x crypto.PublicKey) bool
+}
+
+func TestJWKRoundTrip(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate RSA key: %v", err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate EC key: %v", err)
+	}
+	edPublic, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate Ed25519 key: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		publicKey crypto.PublicKey
+		algorithm string
+		keyType   string
+	}{
+		{name: "RSA", publicKey: &rsaKey.PublicKey, algorithm: AlgorithmRS256, keyType: "RSA"},
+		{name: "EC P-256", publicKey: &ecKey.PublicKey, algorithm: AlgorithmES256, keyType: "EC"},
+		{name: "Ed25519", publicKey: edPublic, algorithm: AlgorithmEdDSA, keyType: "OKP"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jwk, err := PublicKeyToJWK(tt.publicKey, "kid-1", tt.algorithm)
+			if err != nil {
+				t.Fatalf("PublicKeyToJWK() error = %v", err)
+			}
+			if jwk.KeyType != tt.keyType || jwk.KeyID != "kid-1" || jwk.Algorithm != tt.algorithm || jwk.Use != "sig" {
+				t.Errorf("unexpected JWK header fields: %+v", jwk)
+			}
+
+			// Lewat JSON seperti yang diterima service lain dari /.well-known/jwks.json
+			data, err := json.Marshal(jwk)
+			if err != nil {
+				t.Fatalf("failed to marshal JWK: %v", err)
+			}
+			var decoded JWK
+			if err := json.Unmarshal(data, &decoded); err != nil {
+				t.Fatalf("failed to unmarshal JWK: %v", err)
+			}
+
+			publicKey, err := decoded.PublicKey()
+			if err != nil {
+				t.Fatalf("PublicKey() error = %v", err)
+			}
+			if !tt.publicKey.(comparablePublicKey).Equal(publicKey) {
+				t.Errorf("round-tripped public key does not match original")
+			}
+
+			algorithm, err := algorithmForKey(publicKey)
+			if err != nil || algorithm != tt.algorithm {
+				t.Errorf("algorithmForKey() = %q, %v, want %q", algorithm, err, tt.algorithm)
+			}
+		})
+	}
+}
+
+func TestJWKECCoordinatesArePadded(t *testing.T) {
+	// Koordinat dengan byte awal nol tetap harus 32 byte (RFC 7518 section 6.2.1.2)
+	for i := 0; i < 64; i++ {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatalf("failed to generate EC key: %v", err)
+		}
+
+		jwk, err := PublicKeyToJWK(&key.PublicKey, "", AlgorithmES256)
+		if err != nil {
+			t.Fatalf("PublicKeyToJWK() error = %v", err)
+		}
+
+		for name, coordinate := range map[string]string{"x": jwk.X, "y": jwk.Y} {
+			decoded, err := base64.RawURLEncoding.DecodeString(coordinate)
+			if err != nil || len(decoded) != 32 {
+				t.Fatalf("%s coordinate has %d bytes, want 32 (err %v)", name, len(decoded), err)
+			}
+		}
+	}
+}
+
+func TestJWKPublicKeyErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		jwk  JWK
+	}{
+		{name: "unknown key type", jwk: JWK{KeyType: "oct"}},
+		{name: "unsupported EC curve", jwk: JWK{KeyType: "EC", Curve: "P-384", X: "AA", Y: "AA"}},
+		{name: "EC point not on curve", jwk: JWK{KeyType: "EC", Curve: "P-256", X: "AQ", Y: "Ag"}},
+		{name: "invalid EC encoding", jwk: JWK{KeyType: "EC", Curve: "P-256", X: "!!", Y: "AA"}},
+		{name: "unsupported OKP curve", jwk: JWK{KeyType: "OKP", Curve: "X25519", X: "11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"}},
+		{name: "short Ed25519 key", jwk: JWK{KeyType: "OKP", Curve: "Ed25519", X: "11qYAYKxCrfVS_7TyWQHOg"}},
+		{name: "invalid RSA modulus", jwk: JWK{KeyType: "RSA", N: "!!", E: "AQAB"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.jwk.PublicKey(); err == nil {
+				t.Errorf("PublicKey() error = nil, want error")
+			}
+		})
+	}
+}
+
+func TestPublicKeyToJWKUnsupportedCurve(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate EC key: %v", err)
+	}
+
+	if _, err := PublicKeyToJWK(&key.PublicKey, "", ""); err == nil {
+		t.Error("PublicKeyToJWK() error = nil, want error for P-384")
+	}
+	if _, err := JWKThumbprint(&key.PublicKey); err == nil {
+		t.Error("JWKThumbprint() error = nil, want error for P-384")
+	}
+}
+
+func TestJWKThumbprintVectors(t *testing.T) {
+	tests := []struct {
+		name string
+		jwk  JWK
+		want string
+	}{
+		{
+			// RFC 7638 section 3.1
+			name: "RFC 7638 RSA",
+			jwk: JWK{
+				KeyType: "RSA",
+				N:       "0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw",
+				E:       "AQAB",
+			},
+			want: "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs",
+		},
+		{
+			// RFC 8037 appendix A.3
+			name: "RFC 8037 Ed25519",
+			jwk: JWK{
+				KeyType: "OKP",
+				Curve:   "Ed25519",
+				X:       "11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo",
+			},
+			want: "kPrK_qmxVWaYVA9wwBF6Iuo3vVzz7TxHCTwXBygrS4k",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			publicKey, err := tt.jwk.PublicKey()
+			if err != nil {
+				t.Fatalf("PublicKey() error = %v", err)
+			}
+
+			got, err := JWKThumbprint(publicKey)
+			if err != nil {
+				t.Fatalf("JWKThumbprint() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("JWKThumbprint() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJWKThumbprintMatchesCanonicalJSON(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate RSA key: %v", err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate EC key: %v", err)
+	}
+	edPublic, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate Ed25519 key: %v", err)
+	}
+
+	// Member wajib per key type (RFC 7638 section 3.2), json.Marshal mengurutkan key map secara leksikografis
+	tests := []struct {
+		name      string
+		publicKey crypto.PublicKey
+		members   func(jwk *JWK) map[string]string
+	}{
+		{
+			name:      "RSA",
+			publicKey: &rsaKey.PublicKey,
+			members: func(jwk *JWK) map[string]string {
+				return map[string]string{"e": jwk.E, "kty": jwk.KeyType, "n": jwk.N}
+			},
+		},
+		{
+			name:      "EC",
+			publicKey: &ecKey.PublicKey,
+			members: func(jwk *JWK) map[string]string {
+				return map[string]string{"crv": jwk.Curve, "kty": jwk.KeyType, "x": jwk.X, "y": jwk.Y}
+			},
+		},
+		{
+			name:      "OKP",
+			publicKey: edPublic,
+			members: func(jwk *JWK) map[string]string {
+				return map[string]string{"crv": jwk.Curve, "kty": jwk.KeyType, "x": jwk.X}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jwk, err := PublicKeyToJWK(tt.publicKey, "ignored", "ignored")
+			if err != nil {
+				t.Fatalf("PublicKeyToJWK() error = %v", err)
+			}
+
+			canonical, err := json.Marshal(tt.members(jwk))
+			if err != nil {
+				t.Fatalf("failed to marshal canonical JWK: %v", err)
+			}
+			sum := sha256.Sum256(canonical)
+			want := base64.RawURLEncoding.EncodeToString(sum[:])
+
+			got, err := JWKThumbprint(tt.publicKey)
+			if err != nil {
+				t.Fatalf("JWKThumbprint() error = %v", err)
+			}
+			if got != want {
+				t.Errorf("JWKThumbprint() = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestKeyRingAddKeyUsesThumbprintAsKeyID(t *testing.T) {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate Ed25519 key: %v", err)
+	}
+
+	ring := NewKeyRing()
+	key := &SigningKey{Algorithm: AlgorithmEdDSA, PrivateKey: private, PublicKey: private.Public()}
+	if err := ring.AddKey(key); err != nil {
+		t.Fatalf("AddKey() error = %v", err)
+	}
+
+	want, _ := JWKThumbprint(private.Public())
+	if key.KeyID != want {
+		t.Errorf("KeyID = %q, want thumbprint %q", key.KeyID, want)
+	}
+
+	// Public key yang sama (misal dari daftar verification key) tidak menimpa private key
+	if err := ring.AddKey(&SigningKey{Algorithm: AlgorithmEdDSA, PublicKey: private.Public()}); err != nil {
+		t.Fatalf("AddKey() error = %v", err)
+	}
+	if err := ring.SetActive(want); err != nil {
+		t.Errorf("SetActive() error = %v, signing key was overwritten by public-only key", err)
+	}
+}
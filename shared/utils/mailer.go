@@ -0,0 +1,187 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// EmailMessage email yang akan dikirim
+type EmailMessage struct {
+	To       []string
+	Subject  string
+	TextBody string
+}
+
+// Mailer interface untuk mengirim email, implementasinya bisa diganti lewat konfigurasi
+type Mailer interface {
+	Send(ctx context.Context, message EmailMessage) error
+}
+
+// MailerConfig konfigurasi untuk membuat Mailer
+type MailerConfig struct {
+	Driver    string // smtp, file, atau memory
+	From      string
+	SMTPHost  string
+	SMTPPort  string
+	SMTPUser  string
+	SMTPPass  string
+	OutboxDir string
+}
+
+// NewMailer membuat Mailer sesuai driver yang dikonfigurasi
+func NewMailer(config MailerConfig) (Mailer, error) {
+	switch config.Driver {
+	case "smtp":
+		return NewSMTPMailer(config.SMTPHost, config.SMTPPort, config.SMTPUser, config.SMTPPass, config.From), nil
+	case "file", "":
+		return NewFileMailer(config.OutboxDir, config.From)
+	case "memory":
+		return NewMemoryMailer(), nil
+	default:
+		return nil, fmt.Errorf("unknown mailer driver: %s", config.Driver)
+	}
+}
+
+// SMTPMailer mengirim email lewat SMTP server
+type SMTPMailer struct {
+	host     string
+	port     string
+	username string
+	password string
+	from     string
+}
+
+// NewSMTPMailer membuat instance baru SMTPMailer
+func NewSMTPMailer(host, port, username, password, from string) *SMTPMailer {
+	return &SMTPMailer{
+		host:     host,
+		port:     port,
+		username: username,
+		password: password,
+		from:     from,
+	}
+}
+
+// Send mengirim email lewat SMTP. Auth hanya dipakai jika username di-set (misal MailHog tidak butuh auth).
+func (m *SMTPMailer) Send(ctx context.Context, message EmailMessage) error {
+	var auth smtp.Auth
+	if m.username != "" {
+		auth = smtp.PlainAuth("", m.username, m.password, m.host)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(net.JoinHostPort(m.host, m.port), auth, m.from, message.To, buildEmail(m.from, message))
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			return fmt.Errorf("failed to send email: %w", err)
+		}
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("failed to send email: %w", ctx.Err())
+	}
+}
+
+// FileMailer menyimpan email sebagai file .eml di folder outbox, untuk development lokal
+type FileMailer struct {
+	dir  string
+	from string
+	mu   sync.Mutex
+	seq  int
+}
+
+// NewFileMailer membuat instance baru FileMailer dan membuat folder outbox jika belum ada
+func NewFileMailer(dir, from string) (*FileMailer, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create mail outbox: %w", err)
+	}
+	return &FileMailer{dir: dir, from: from}, nil
+}
+
+// Send menulis email ke file baru di outbox
+func (m *FileMailer) Send(ctx context.Context, message EmailMessage) error {
+	m.mu.Lock()
+	m.seq++
+	name := fmt.Sprintf("%s-%04d.eml", time.Now().UTC().Format("20060102T150405"), m.seq)
+	m.mu.Unlock()
+
+	path := filepath.Join(m.dir, name)
+	if err := os.WriteFile(path, buildEmail(m.from, message), 0o644); err != nil {
+		return fmt.Errorf("failed to write email to outbox: %w", err)
+	}
+	return nil
+}
+
+// MemoryMailer menyimpan email di memory, dipakai untuk testing
+type MemoryMailer struct {
+	mu       sync.Mutex
+	messages []EmailMessage
+}
+
+// NewMemoryMailer membuat instance baru MemoryMailer
+func NewMemoryMailer() *MemoryMailer {
+	return &MemoryMailer{}
+}
+
+// Send menyimpan email ke memory
+func (m *MemoryMailer) Send(ctx context.Context, message EmailMessage) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.messages = append(m.messages, message)
+	return nil
+}
+
+// Messages mengembalikan semua email yang sudah dikirim
+func (m *MemoryMailer) Messages() []EmailMessage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	messages := make([]EmailMessage, len(m.messages))
+	copy(messages, m.messages)
+	return messages
+}
+
+// LastMessageTo mengembalikan email terakhir untuk alamat tertentu
+func (m *MemoryMailer) LastMessageTo(address string) (EmailMessage, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := len(m.messages) - 1; i >= 0; i-- {
+		for _, to := range m.messages[i].To {
+			if strings.EqualFold(to, address) {
+				return m.messages[i], true
+			}
+		}
+	}
+	return EmailMessage{}, false
+}
+
+// Helper function untuk membuat email dalam format RFC 5322 (plain text)
+func buildEmail(from string, message EmailMessage) []byte {
+	var builder strings.Builder
+	builder.WriteString("From: " + sanitizeHeader(from) + "\r\n")
+	builder.WriteString("To: " + sanitizeHeader(strings.Join(message.To, ", ")) + "\r\n")
+	builder.WriteString("Subject: " + sanitizeHeader(message.Subject) + "\r\n")
+	builder.WriteString("Date: " + time.Now().UTC().Format(time.RFC1123Z) + "\r\n")
+	builder.WriteString("MIME-Version: 1.0\r\n")
+	builder.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	builder.WriteString("\r\n")
+	builder.WriteString(strings.ReplaceAll(message.TextBody, "\n", "\r\n"))
+	return []byte(builder.String())
+}
+
+// Helper function untuk mencegah header injection lewat CR/LF
+func sanitizeHeader(value string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(value)
+}
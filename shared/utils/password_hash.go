@@ -0,0 +1,236 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ErrUnknownHashFormat hash password tidak dikenali oleh algoritma manapun
+var ErrUnknownHashFormat = errors.New("unknown password hash format")
+
+// BcryptMaxPasswordBytes batas panjang input bcrypt, password yang lebih panjang ditolak oleh bcrypt.GenerateFromPassword
+const BcryptMaxPasswordBytes = 72
+
+// PasswordHashAlgorithm satu algoritma hash password
+type PasswordHashAlgorithm interface {
+	// Name nama algoritma, contoh: argon2id, bcrypt
+	Name() string
+	Hash(password string) (string, error)
+	Verify(password, encoded string) (bool, error)
+	// Matches mengecek apakah encoded hash dibuat dengan algoritma ini
+	Matches(encoded string) bool
+	// NeedsRehash true jika parameter hash (cost, memory, dll) berbeda dengan konfigurasi sekarang
+	NeedsRehash(encoded string) bool
+}
+
+// PasswordHasher membuat hash dengan algoritma utama dan tetap bisa memverifikasi hash lama,
+// sehingga algoritma bisa di-upgrade tanpa memaksa user reset password.
+type PasswordHasher struct {
+	preferred  PasswordHashAlgorithm
+	algorithms []PasswordHashAlgorithm
+}
+
+// NewPasswordHasher membuat instance baru PasswordHasher
+func NewPasswordHasher(preferred PasswordHashAlgorithm, legacy ...PasswordHashAlgorithm) *PasswordHasher {
+	return &PasswordHasher{
+		preferred:  preferred,
+		algorithms: append([]PasswordHashAlgorithm{preferred}, legacy...),
+	}
+}
+
+// NewPasswordHasherByName membuat PasswordHasher dengan algoritma utama berdasarkan nama.
+// Algoritma lain tetap didaftarkan untuk verifikasi hash lama.
+func NewPasswordHasherByName(name string, argon *Argon2idHasher, bcryptHasher *BcryptHasher) (*PasswordHasher, error) {
+	switch name {
+	case "argon2id":
+		return NewPasswordHasher(argon, bcryptHasher), nil
+	case "bcrypt":
+		return NewPasswordHasher(bcryptHasher, argon), nil
+	default:
+		return nil, fmt.Errorf("unknown password hash algorithm: %s", name)
+	}
+}
+
+// Hash membuat hash dengan algoritma utama
+func (h *PasswordHasher) Hash(password string) (string, error) {
+	return h.preferred.Hash(password)
+}
+
+// MaxPasswordBytes batas panjang password (dalam byte) dari algoritma utama, 0 berarti tidak dibatasi.
+// Dipakai untuk PasswordPolicy.MaxBytes supaya password yang terlalu panjang ditolak saat validasi, bukan gagal saat hash.
+func (h *PasswordHasher) MaxPasswordBytes() int {
+	if h.preferred.Name() == "bcrypt" {
+		return BcryptMaxPasswordBytes
+	}
+	return 0
+}
+
+// Verify mengecek password. needsRehash true jika password benar tapi hash-nya memakai
+// algoritma atau parameter lama, caller sebaiknya menyimpan hash baru dari Hash.
+func (h *PasswordHasher) Verify(password, encoded string) (ok bool, needsRehash bool, err error) {
+	for _, algorithm := range h.algorithms {
+		if !algorithm.Matches(encoded) {
+			continue
+		}
+
+		ok, err := algorithm.Verify(password, encoded)
+		if err != nil || !ok {
+			return false, false, err
+		}
+
+		needsRehash = algorithm.Name() != h.preferred.Name() || algorithm.NeedsRehash(encoded)
+		return true, needsRehash, nil
+	}
+
+	return false, false, ErrUnknownHashFormat
+}
+
+// BcryptHasher algoritma bcrypt dengan cost tertentu
+type BcryptHasher struct {
+	Cost int
+}
+
+// NewBcryptHasher membuat instance baru BcryptHasher
+func NewBcryptHasher(cost int) *BcryptHasher {
+	if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		cost = bcrypt.DefaultCost
+	}
+	return &BcryptHasher{Cost: cost}
+}
+
+// Name nama algoritma
+func (b *BcryptHasher) Name() string { return "bcrypt" }
+
+// Hash membuat hash bcrypt
+func (b *BcryptHasher) Hash(password string) (string, error) {
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), b.Cost)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password: %w", err)
+	}
+	return string(hashed), nil
+}
+
+// Verify membandingkan password dengan hash bcrypt
+func (b *BcryptHasher) Verify(password, encoded string) (bool, error) {
+	err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+	if err == nil {
+		return true, nil
+	}
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return false, nil
+	}
+	return false, fmt.Errorf("failed to verify password: %w", err)
+}
+
+// Matches mengecek prefix hash bcrypt ($2a$, $2b$, $2y$)
+func (b *BcryptHasher) Matches(encoded string) bool {
+	return strings.HasPrefix(encoded, "$2a$") || strings.HasPrefix(encoded, "$2b$") || strings.HasPrefix(encoded, "$2y$")
+}
+
+// NeedsRehash true jika cost hash berbeda dengan cost sekarang
+func (b *BcryptHasher) NeedsRehash(encoded string) bool {
+	cost, err := bcrypt.Cost([]byte(encoded))
+	return err != nil || cost != b.Cost
+}
+
+// Argon2idHasher algoritma argon2id, hash disimpan dalam format PHC:
+// $argon2id$v=19$m=65536,t=3,p=2$<salt>$<hash>
+type Argon2idHasher struct {
+	Memory      uint32 // Dalam KiB
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// NewArgon2idHasher membuat instance baru Argon2idHasher
+func NewArgon2idHasher(memory, iterations uint32, parallelism uint8) *Argon2idHasher {
+	return &Argon2idHasher{
+		Memory:      memory,
+		Iterations:  iterations,
+		Parallelism: parallelism,
+		SaltLength:  16,
+		KeyLength:   32,
+	}
+}
+
+// Name nama algoritma
+func (a *Argon2idHasher) Name() string { return "argon2id" }
+
+// Hash membuat hash argon2id dengan salt random
+func (a *Argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, a.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	key := argon2.IDKey([]byte(password), salt, a.Iterations, a.Memory, a.Parallelism, a.KeyLength)
+
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, a.Memory, a.Iterations, a.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// Verify membandingkan password dengan hash argon2id memakai parameter dari hash itu sendiri
+func (a *Argon2idHasher) Verify(password, encoded string) (bool, error) {
+	params, salt, key, err := decodeArgon2idHash(encoded)
+	if err != nil {
+		return false, err
+	}
+
+	candidate := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, uint32(len(key)))
+	return subtle.ConstantTimeCompare(candidate, key) == 1, nil
+}
+
+// Matches mengecek prefix hash argon2id
+func (a *Argon2idHasher) Matches(encoded string) bool {
+	return strings.HasPrefix(encoded, "$argon2id$")
+}
+
+// NeedsRehash true jika parameter hash berbeda dengan parameter sekarang
+func (a *Argon2idHasher) NeedsRehash(encoded string) bool {
+	params, _, key, err := decodeArgon2idHash(encoded)
+	if err != nil {
+		return true
+	}
+	return params.Memory != a.Memory || params.Iterations != a.Iterations ||
+		params.Parallelism != a.Parallelism || uint32(len(key)) != a.KeyLength
+}
+
+// Helper function untuk parsing hash argon2id format PHC
+func decodeArgon2idHash(encoded string) (*Argon2idHasher, []byte, []byte, error) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return nil, nil, nil, ErrUnknownHashFormat
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return nil, nil, nil, fmt.Errorf("unsupported argon2 version: %s", parts[2])
+	}
+
+	params := &Argon2idHasher{}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return nil, nil, nil, fmt.Errorf("invalid argon2 parameters: %w", err)
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("invalid argon2 salt: %w", err)
+	}
+
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("invalid argon2 hash: %w", err)
+	}
+
+	return params, salt, key, nil
+}
@@ -0,0 +1,216 @@
+package utils
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// Hash argon2id dengan parameter kecil supaya test cepat, dibuat dari golang.org/x/crypto/argon2
+const testArgon2idHash = "$argon2id$v=19$m=64,t=1,p=1$c2FsdHNhbHRzYWx0c2FsdA$TUhCoV6YH8thtZpQqRp0JvQQ5ZYgiLA0LYApKDG6qtM"
+
+const testArgon2idPassword = "correct horse battery staple"
+
+// Contoh hash bcrypt dari dokumentasi PHP password_verify, cost 10
+const testBcryptHash = "$2y$10$.vGA1O9wmRjrwAVXD98HNOgsNpDczlqm3Jq7KnEd1rVAGv3Fykk1a"
+
+const testBcryptPassword = "rasmuslerdorf"
+
+func TestArgon2idHasherVerify(t *testing.T) {
+	hasher := NewArgon2idHasher(64, 1, 1)
+
+	tests := []struct {
+		name    string
+		encoded string
+		want    bool
+		wantErr bool
+	}{
+		{name: "correct password", encoded: testArgon2idHash, want: true},
+		{name: "wrong salt", encoded: strings.Replace(testArgon2idHash, "c2FsdHNhbHRzYWx0c2FsdA", "c2FsdA", 1), want: false},
+		{name: "unsupported version", encoded: strings.Replace(testArgon2idHash, "v=19", "v=16", 1), wantErr: true},
+		{name: "invalid parameters", encoded: strings.Replace(testArgon2idHash, "m=64,t=1,p=1", "m=64", 1), wantErr: true},
+		{name: "invalid salt encoding", encoded: strings.Replace(testArgon2idHash, "c2FsdHNhbHRzYWx0c2FsdA", "!!!", 1), wantErr: true},
+		{name: "missing hash segment", encoded: "$argon2id$v=19$m=64,t=1,p=1$c2FsdHNhbHRzYWx0c2FsdA", wantErr: true},
+		{name: "argon2i hash", encoded: strings.Replace(testArgon2idHash, "argon2id", "argon2i", 1), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := hasher.Verify(testArgon2idPassword, tt.encoded)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Verify() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Verify() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if ok, _ := hasher.Verify("wrong password", testArgon2idHash); ok {
+		t.Error("Verify() accepted a wrong password")
+	}
+}
+
+func TestBcryptHasherVerify(t *testing.T) {
+	hasher := NewBcryptHasher(10)
+
+	tests := []struct {
+		name     string
+		password string
+		encoded  string
+		want     bool
+		wantErr  bool
+	}{
+		{name: "correct password", password: testBcryptPassword, encoded: testBcryptHash, want: true},
+		{name: "wrong password", password: "rasmuslerdorF", encoded: testBcryptHash, want: false},
+		{name: "empty password", password: "", encoded: testBcryptHash, want: false},
+		{name: "truncated hash", password: testBcryptPassword, encoded: testBcryptHash[:20], wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := hasher.Verify(tt.password, tt.encoded)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Verify() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Verify() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNeedsRehash(t *testing.T) {
+	tests := []struct {
+		name      string
+		algorithm PasswordHashAlgorithm
+		encoded   string
+		want      bool
+	}{
+		{name: "argon2id same parameters", algorithm: NewArgon2idHasher(64, 1, 1), encoded: testArgon2idHash, want: false},
+		{name: "argon2id different memory", algorithm: NewArgon2idHasher(128, 1, 1), encoded: testArgon2idHash, want: true},
+		{name: "argon2id different iterations", algorithm: NewArgon2idHasher(64, 2, 1), encoded: testArgon2idHash, want: true},
+		{name: "argon2id different parallelism", algorithm: NewArgon2idHasher(64, 1, 2), encoded: testArgon2idHash, want: true},
+		{name: "argon2id different key length", algorithm: &Argon2idHasher{Memory: 64, Iterations: 1, Parallelism: 1, KeyLength: 16}, encoded: testArgon2idHash, want: true},
+		{name: "argon2id malformed hash", algorithm: NewArgon2idHasher(64, 1, 1), encoded: "$argon2id$garbage", want: true},
+		{name: "bcrypt same cost", algorithm: NewBcryptHasher(10), encoded: testBcryptHash, want: false},
+		{name: "bcrypt different cost", algorithm: NewBcryptHasher(12), encoded: testBcryptHash, want: true},
+		{name: "bcrypt malformed hash", algorithm: NewBcryptHasher(10), encoded: "$2y$", want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.algorithm.NeedsRehash(tt.encoded); got != tt.want {
+				t.Errorf("NeedsRehash() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPasswordHasherVerify(t *testing.T) {
+	argonPreferred := NewPasswordHasher(NewArgon2idHasher(64, 1, 1), NewBcryptHasher(10))
+	bcryptPreferred := NewPasswordHasher(NewBcryptHasher(10), NewArgon2idHasher(64, 1, 1))
+
+	tests := []struct {
+		name            string
+		hasher          *PasswordHasher
+		password        string
+		encoded         string
+		wantOK          bool
+		wantNeedsRehash bool
+		wantErr         error
+	}{
+		{name: "argon2id current parameters", hasher: argonPreferred, password: testArgon2idPassword, encoded: testArgon2idHash, wantOK: true},
+		{name: "argon2id wrong password", hasher: argonPreferred, password: "wrong", encoded: testArgon2idHash},
+		{name: "legacy bcrypt is upgraded", hasher: argonPreferred, password: testBcryptPassword, encoded: testBcryptHash, wantOK: true, wantNeedsRehash: true},
+		{name: "legacy bcrypt wrong password", hasher: argonPreferred, password: "wrong", encoded: testBcryptHash},
+		{name: "bcrypt current cost", hasher: bcryptPreferred, password: testBcryptPassword, encoded: testBcryptHash, wantOK: true},
+		{name: "argon2id is downgraded to bcrypt", hasher: bcryptPreferred, password: testArgon2idPassword, encoded: testArgon2idHash, wantOK: true, wantNeedsRehash: true},
+		{name: "outdated argon2id parameters", hasher: NewPasswordHasher(NewArgon2idHasher(128, 1, 1)), password: testArgon2idPassword, encoded: testArgon2idHash, wantOK: true, wantNeedsRehash: true},
+		{name: "unknown format", hasher: argonPreferred, password: "secret", encoded: "5f4dcc3b5aa765d61d8327deb882cf99", wantErr: ErrUnknownHashFormat},
+		{name: "legacy algorithm not registered", hasher: NewPasswordHasher(NewArgon2idHasher(64, 1, 1)), password: testBcryptPassword, encoded: testBcryptHash, wantErr: ErrUnknownHashFormat},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, needsRehash, err := tt.hasher.Verify(tt.password, tt.encoded)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Verify() error = %v, want %v", err, tt.wantErr)
+			}
+			if ok != tt.wantOK || needsRehash != tt.wantNeedsRehash {
+				t.Errorf("Verify() = (%v, %v), want (%v, %v)", ok, needsRehash, tt.wantOK, tt.wantNeedsRehash)
+			}
+		})
+	}
+}
+
+func TestPasswordHasherByNameRoundTrip(t *testing.T) {
+	argon := NewArgon2idHasher(64, 1, 1)
+	bcryptHasher := NewBcryptHasher(4)
+
+	tests := []struct {
+		name         string
+		wantPrefix   string
+		wantMaxBytes int
+	}{
+		{name: "argon2id", wantPrefix: "$argon2id$v=19$m=64,t=1,p=1$", wantMaxBytes: 0},
+		{name: "bcrypt", wantPrefix: "$2a$04$", wantMaxBytes: BcryptMaxPasswordBytes},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hasher, err := NewPasswordHasherByName(tt.name, argon, bcryptHasher)
+			if err != nil {
+				t.Fatalf("NewPasswordHasherByName() error = %v", err)
+			}
+			if got := hasher.MaxPasswordBytes(); got != tt.wantMaxBytes {
+				t.Errorf("MaxPasswordBytes() = %d, want %d", got, tt.wantMaxBytes)
+			}
+
+			encoded, err := hasher.Hash("s3cret-Passw0rd")
+			if err != nil {
+				t.Fatalf("Hash() error = %v", err)
+			}
+			if !strings.HasPrefix(encoded, tt.wantPrefix) {
+				t.Errorf("Hash() = %q, want prefix %q", encoded, tt.wantPrefix)
+			}
+
+			ok, needsRehash, err := hasher.Verify("s3cret-Passw0rd", encoded)
+			if err != nil || !ok || needsRehash {
+				t.Errorf("Verify() = (%v, %v, %v), want (true, false, nil)", ok, needsRehash, err)
+			}
+		})
+	}
+
+	if _, err := NewPasswordHasherByName("md5", argon, bcryptHasher); err == nil {
+		t.Error("NewPasswordHasherByName() error = nil, want error for unknown algorithm")
+	}
+}
+
+func TestArgon2idHashUsesRandomSalt(t *testing.T) {
+	hasher := NewArgon2idHasher(64, 1, 1)
+
+	first, err := hasher.Hash("same password")
+	if err != nil {
+		t.Fatalf("Hash() error = %v", err)
+	}
+	second, err := hasher.Hash("same password")
+	if err != nil {
+		t.Fatalf("Hash() error = %v", err)
+	}
+
+	if first == second {
+		t.Error("two hashes of the same password are identical, salt is not random")
+	}
+}
+
+func TestBcryptRejectsPasswordOverMaxBytes(t *testing.T) {
+	hasher := NewBcryptHasher(4)
+
+	if _, err := hasher.Hash(strings.Repeat("a", BcryptMaxPasswordBytes)); err != nil {
+		t.Errorf("Hash() with %d bytes error = %v", BcryptMaxPasswordBytes, err)
+	}
+	if _, err := hasher.Hash(strings.Repeat("a", BcryptMaxPasswordBytes+1)); err == nil {
+		t.Errorf("Hash() with %d bytes error = nil, want error", BcryptMaxPasswordBytes+1)
+	}
+}
@@ -0,0 +1,157 @@
+package utils
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// PasswordPolicyError password tidak memenuhi policy, berisi semua pelanggaran sekaligus
+type PasswordPolicyError struct {
+	Violations []string
+}
+
+func (e *PasswordPolicyError) Error() string {
+	return "password policy violated: " + strings.Join(e.Violations, "; ")
+}
+
+// PasswordPolicy aturan password untuk registrasi, reset, dan ganti password
+type PasswordPolicy struct {
+	MinLength       int
+	MaxLength       int
+	MaxBytes        int // Batas panjang dalam byte (UTF-8) dari algoritma hash, misal 72 untuk bcrypt. 0 berarti tidak dibatasi
+	MinCharClasses  int // Jumlah minimal jenis karakter: huruf kecil, huruf besar, angka, simbol
+	commonPasswords map[string]struct{}
+}
+
+// NewPasswordPolicy membuat instance baru PasswordPolicy
+func NewPasswordPolicy(minLength, maxLength, minCharClasses int) *PasswordPolicy {
+	return &PasswordPolicy{
+		MinLength:       minLength,
+		MaxLength:       maxLength,
+		MinCharClasses:  minCharClasses,
+		commonPasswords: make(map[string]struct{}),
+	}
+}
+
+// LoadCommonPasswords membaca daftar password umum dari file, satu password per baris.
+// Baris kosong dan baris yang diawali # diabaikan, perbandingan case-insensitive.
+func (p *PasswordPolicy) LoadCommonPasswords(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open common password list: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		p.commonPasswords[strings.ToLower(line)] = struct{}{}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read common password list: %w", err)
+	}
+	return nil
+}
+
+// CommonPasswordCount jumlah password di daftar password umum
+func (p *PasswordPolicy) CommonPasswordCount() int {
+	return len(p.commonPasswords)
+}
+
+// Validate mengecek password terhadap policy. Username dan email dipakai untuk menolak
+// password yang terlalu mirip dengan identitas user. Return *PasswordPolicyError jika gagal.
+func (p *PasswordPolicy) Validate(password, username, email string) error {
+	var violations []string
+
+	length := len([]rune(password))
+	if length < p.MinLength {
+		violations = append(violations, fmt.Sprintf("password must be at least %d characters long", p.MinLength))
+	}
+	if p.MaxLength > 0 && length > p.MaxLength {
+		violations = append(violations, fmt.Sprintf("password must be at most %d characters long", p.MaxLength))
+	}
+	if p.MaxBytes > 0 && len(password) > p.MaxBytes {
+		violations = append(violations, fmt.Sprintf("password must be at most %d bytes long", p.MaxBytes))
+	}
+
+	if classes := countCharClasses(password); classes < p.MinCharClasses {
+		violations = append(violations, fmt.Sprintf(
+			"password must contain at least %d of: lowercase letters, uppercase letters, digits, symbols", p.MinCharClasses))
+	}
+
+	lowered := strings.ToLower(password)
+	if _, common := p.commonPasswords[lowered]; common {
+		violations = append(violations, "password is too common")
+	}
+
+	if isSimilarToIdentity(lowered, username, email) {
+		violations = append(violations, "password is too similar to the username or email")
+	}
+
+	if len(violations) > 0 {
+		return &PasswordPolicyError{Violations: violations}
+	}
+	return nil
+}
+
+// Helper function untuk menghitung jenis karakter yang dipakai password
+func countCharClasses(password string) int {
+	var lower, upper, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			symbol = true
+		}
+	}
+
+	count := 0
+	for _, present := range []bool{lower, upper, digit, symbol} {
+		if present {
+			count++
+		}
+	}
+	return count
+}
+
+// Helper function untuk mengecek password yang memuat (atau dimuat oleh) username,
+// bagian lokal email, maupun kebalikannya
+func isSimilarToIdentity(lowered, username, email string) bool {
+	identities := []string{strings.ToLower(username)}
+	if local, _, found := strings.Cut(strings.ToLower(email), "@"); found {
+		identities = append(identities, local)
+	}
+
+	reversed := reverseString(lowered)
+	for _, identity := range identities {
+		// Identitas yang sangat pendek tidak dicek supaya tidak terlalu banyak false positive
+		if len([]rune(identity)) < 3 {
+			continue
+		}
+		if strings.Contains(lowered, identity) || strings.Contains(reversed, identity) || strings.Contains(identity, lowered) {
+			return true
+		}
+	}
+	return false
+}
+
+// Helper function untuk membalik string
+func reverseString(value string) string {
+	runes := []rune(value)
+	for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+		runes[i], runes[j] = runes[j], runes[i]
+	}
+	return string(runes)
+}
@@ -0,0 +1,105 @@
+package utils
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// PermissionWildcard memberikan semua permission ke role (biasanya admin)
+const PermissionWildcard = "*"
+
+// PermissionLoader sumber mapping role -> permissions (config, database, dll)
+type PermissionLoader func() (map[string][]string, error)
+
+// Authorizer menyimpan mapping role -> permissions yang bisa di-reload
+type Authorizer struct {
+	mu          sync.RWMutex
+	permissions map[string]map[string]bool
+}
+
+// NewAuthorizer membuat instance baru Authorizer dari mapping awal
+func NewAuthorizer(rolePermissions map[string][]string) *Authorizer {
+	authorizer := &Authorizer{}
+	authorizer.setPermissions(rolePermissions)
+	return authorizer
+}
+
+// Reload memuat ulang mapping dari loader, misal setelah admin mengubah role di database
+func (a *Authorizer) Reload(loader PermissionLoader) error {
+	rolePermissions, err := loader()
+	if err != nil {
+		return fmt.Errorf("failed to load role permissions: %w", err)
+	}
+
+	a.setPermissions(rolePermissions)
+	return nil
+}
+
+// HasPermission mengecek apakah role punya permission tertentu
+func (a *Authorizer) HasPermission(role, permission string) bool {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	permissions, ok := a.permissions[role]
+	if !ok {
+		return false
+	}
+
+	return permissions[PermissionWildcard] || permissions[permission]
+}
+
+// PermissionsForRole mengembalikan semua permission milik role
+func (a *Authorizer) PermissionsForRole(role string) []string {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	result := []string{}
+	for permission := range a.permissions[role] {
+		result = append(result, permission)
+	}
+	return result
+}
+
+// Helper function untuk mengganti mapping secara atomic
+func (a *Authorizer) setPermissions(rolePermissions map[string][]string) {
+	permissions := make(map[string]map[string]bool, len(rolePermissions))
+	for role, rolePerms := range rolePermissions {
+		permissions[role] = make(map[string]bool, len(rolePerms))
+		for _, permission := range rolePerms {
+			permissions[role][permission] = true
+		}
+	}
+
+	a.mu.Lock()
+	a.permissions = permissions
+	a.mu.Unlock()
+}
+
+// StaticPermissionLoader loader dari mapping yang sudah ada (misal dari config)
+func StaticPermissionLoader(rolePermissions map[string][]string) PermissionLoader {
+	return func() (map[string][]string, error) {
+		return rolePermissions, nil
+	}
+}
+
+// DatabasePermissionLoader loader dari database. Query harus mengembalikan kolom role dan permission.
+func DatabasePermissionLoader(db *sqlx.DB, query string) PermissionLoader {
+	return func() (map[string][]string, error) {
+		rows := []struct {
+			Role       string `db:"role"`
+			Permission string `db:"permission"`
+		}{}
+
+		if err := db.Select(&rows, query); err != nil {
+			return nil, fmt.Errorf("failed to query role permissions: %w", err)
+		}
+
+		rolePermissions := make(map[string][]string)
+		for _, row := range rows {
+			rolePermissions[row.Role] = append(rolePermissions[row.Role], row.Permission)
+		}
+		return rolePermissions, nil
+	}
+}
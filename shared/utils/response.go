@@ -55,6 +55,17 @@ func CreatedResponse(c *gin.Context, message string, data interface{}) {
 	c.JSON(http.StatusCreated, response)
 }
 
+// AcceptedResponse mengembalikan response untuk proses yang dijalankan di background
+func AcceptedResponse(c *gin.Context, message string, data interface{}) {
+	response := APIResponse{
+		Success:   true,
+		Message:   message,
+		Data:      data,
+		Timestamp: time.Now().UTC(),
+	}
+	c.JSON(http.StatusAccepted, response)
+}
+
 // ErrorResponse mengembalikan response error
 func ErrorResponse(c *gin.Context, statusCode int, errorCode string, message string, details string) {
 	response := APIResponse{
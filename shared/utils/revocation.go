@@ -0,0 +1,171 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/jizak1/Microservices-Golang/shared/database"
+)
+
+// ErrTokenRevoked dikembalikan ketika token sudah di-revoke sebelum expired
+var ErrTokenRevoked = errors.New("token has been revoked")
+
+// RevocationChecker interface untuk mengecek apakah token sudah di-revoke
+type RevocationChecker interface {
+	IsRevoked(claims *JWTClaims) (bool, error)
+}
+
+// TokenRevocationStore denylist token di Redis berdasarkan jti dan cutoff per user
+type TokenRevocationStore struct {
+	redis     *database.RedisClient
+	keyPrefix string
+}
+
+// NewTokenRevocationStore membuat instance baru TokenRevocationStore
+func NewTokenRevocationStore(redis *database.RedisClient) *TokenRevocationStore {
+	return &TokenRevocationStore{
+		redis:     redis,
+		keyPrefix: "revoked:",
+	}
+}
+
+// RevokeToken memasukkan jti ke denylist sampai token expired
+func (s *TokenRevocationStore) RevokeToken(claims *JWTClaims) error {
+	if claims.ID == "" {
+		return fmt.Errorf("token has no jti and cannot be revoked individually")
+	}
+
+	remaining := time.Until(claims.ExpiresAt.Time)
+	if remaining <= 0 {
+		// Token sudah expired, tidak perlu disimpan
+		return nil
+	}
+
+	return s.redis.SetWithExpiration(s.jtiKey(claims.ID), "1", remaining)
+}
+
+// RevokeTokenString me-revoke token mentah, TTL diambil dari GetTokenRemainingTime
+func (s *TokenRevocationStore) RevokeTokenString(manager *JWTManager, tokenString string) error {
+	claims, err := manager.ValidateToken(tokenString)
+	if err != nil {
+		// Token invalid atau expired sudah tidak bisa dipakai
+		return nil
+	}
+
+	if claims.ID == "" {
+		return fmt.Errorf("token has no jti and cannot be revoked individually")
+	}
+
+	remaining, err := manager.GetTokenRemainingTime(tokenString)
+	if err != nil {
+		return nil
+	}
+
+	return s.redis.SetWithExpiration(s.jtiKey(claims.ID), "1", remaining)
+}
+
+// RevokeUserTokensBefore me-revoke semua token user yang dibuat sebelum waktu tertentu.
+// maxTokenLifetime harus >= masa berlaku access token supaya cutoff tidak hilang terlalu cepat.
+func (s *TokenRevocationStore) RevokeUserTokensBefore(userID string, before time.Time, maxTokenLifetime time.Duration) error {
+	// Presisi iat adalah detik, token yang dibuat di detik yang sama dengan cutoff tetap berlaku
+	// supaya token baru setelah ganti password tidak ikut ter-revoke.
+	cutoff := strconv.FormatInt(before.Unix(), 10)
+	return s.redis.SetWithExpiration(s.userKey(userID), cutoff, maxTokenLifetime)
+}
+
+// RevokeSession me-revoke semua token dengan claim sid tertentu (satu sesi login).
+// maxTokenLifetime harus >= masa berlaku access token.
+func (s *TokenRevocationStore) RevokeSession(sessionID string, maxTokenLifetime time.Duration) error {
+	if sessionID == "" {
+		return fmt.Errorf("session id is required")
+	}
+	return s.redis.SetWithExpiration(s.sessionKey(sessionID), "1", maxTokenLifetime)
+}
+
+// IsRevoked mengecek denylist jti, sesi dan cutoff per user
+func (s *TokenRevocationStore) IsRevoked(claims *JWTClaims) (bool, error) {
+	if claims.ID != "" {
+		exists, err := s.redis.Exists(s.jtiKey(claims.ID))
+		if err != nil {
+			return false, err
+		}
+		if exists {
+			return true, nil
+		}
+	}
+
+	if claims.SessionID != "" {
+		exists, err := s.redis.Exists(s.sessionKey(claims.SessionID))
+		if err != nil {
+			return false, err
+		}
+		if exists {
+			return true, nil
+		}
+	}
+
+	value, err := s.redis.Get(s.userKey(claims.UserID))
+	if err != nil {
+		if errors.Is(err, database.ErrKeyNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	cutoff, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return false, fmt.Errorf("invalid revocation cutoff for user %s: %w", claims.UserID, err)
+	}
+
+	if claims.IssuedAt == nil {
+		return true, nil
+	}
+	return claims.IssuedAt.Time.Unix() < cutoff, nil
+}
+
+func (s *TokenRevocationStore) jtiKey(jti string) string {
+	return s.keyPrefix + "jti:" + jti
+}
+
+func (s *TokenRevocationStore) userKey(userID string) string {
+	return s.keyPrefix + "user:" + userID
+}
+
+func (s *TokenRevocationStore) sessionKey(sessionID string) string {
+	return s.keyPrefix + "sid:" + sessionID
+}
+
+// RevocationAwareVerifier membungkus TokenVerifier dan menolak token yang sudah di-revoke
+type RevocationAwareVerifier struct {
+	verifier TokenVerifier
+	checker  RevocationChecker
+}
+
+// NewRevocationAwareVerifier membuat instance baru RevocationAwareVerifier
+func NewRevocationAwareVerifier(verifier TokenVerifier, checker RevocationChecker) *RevocationAwareVerifier {
+	return &RevocationAwareVerifier{
+		verifier: verifier,
+		checker:  checker,
+	}
+}
+
+// ValidateToken memvalidasi token lalu mengecek denylist.
+// Jika denylist tidak bisa dicek, token ditolak (fail closed).
+func (v *RevocationAwareVerifier) ValidateToken(tokenString string) (*JWTClaims, error) {
+	claims, err := v.verifier.ValidateToken(tokenString)
+	if err != nil {
+		return nil, err
+	}
+
+	revoked, err := v.checker.IsRevoked(claims)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check token revocation: %w", err)
+	}
+	if revoked {
+		return nil, ErrTokenRevoked
+	}
+
+	return claims, nil
+}
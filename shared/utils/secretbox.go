@@ -0,0 +1,66 @@
+package utils
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+// ErrSecretDecryption ciphertext rusak atau dienkripsi dengan key lain
+var ErrSecretDecryption = errors.New("failed to decrypt secret")
+
+// SecretBox enkripsi AES-256-GCM untuk data sensitif yang harus bisa dibaca lagi (misal secret TOTP)
+type SecretBox struct {
+	aead cipher.AEAD
+}
+
+// NewSecretBox membuat instance baru SecretBox. Key 256 bit diturunkan dari passphrase dengan SHA-256.
+func NewSecretBox(passphrase string) (*SecretBox, error) {
+	if passphrase == "" {
+		return nil, fmt.Errorf("encryption key is required")
+	}
+
+	key := sha256.Sum256([]byte(passphrase))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GCM: %w", err)
+	}
+
+	return &SecretBox{aead: aead}, nil
+}
+
+// Encrypt mengenkripsi plaintext, hasilnya base64(nonce + ciphertext)
+func (b *SecretBox) Encrypt(plaintext string) (string, error) {
+	nonce := make([]byte, b.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	sealed := b.aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt membuka ciphertext hasil Encrypt
+func (b *SecretBox) Decrypt(encoded string) (string, error) {
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(sealed) < b.aead.NonceSize() {
+		return "", ErrSecretDecryption
+	}
+
+	nonce, ciphertext := sealed[:b.aead.NonceSize()], sealed[b.aead.NonceSize():]
+	plaintext, err := b.aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", ErrSecretDecryption
+	}
+
+	return string(plaintext), nil
+}
@@ -0,0 +1,185 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ErrInvalidClient client ID atau secret salah
+var ErrInvalidClient = errors.New("invalid client credentials")
+
+// ServiceClient service internal yang boleh meminta token (misal api-gateway)
+type ServiceClient struct {
+	ClientID      string
+	SecretHash    string   // SHA-256 hex dari client secret, lihat HashToken
+	AllowedScopes []string // Scope yang boleh diminta
+	Audiences     []string // Service tujuan yang boleh dipanggil
+}
+
+// ServiceRegistry daftar service client yang terdaftar
+type ServiceRegistry struct {
+	clients map[string]ServiceClient
+}
+
+// NewServiceRegistry membuat instance baru ServiceRegistry
+func NewServiceRegistry(clients []ServiceClient) *ServiceRegistry {
+	registry := &ServiceRegistry{clients: make(map[string]ServiceClient, len(clients))}
+	for _, client := range clients {
+		registry.clients[client.ClientID] = client
+	}
+	return registry
+}
+
+// ParseServiceClients parsing konfigurasi service client.
+// Format: "client_id|secret_sha256|scope1 scope2|audience1 audience2;client_id2|..."
+func ParseServiceClients(spec string) ([]ServiceClient, error) {
+	clients := []ServiceClient{}
+
+	for _, entry := range strings.Split(spec, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		parts := strings.Split(entry, "|")
+		if len(parts) != 4 {
+			return nil, fmt.Errorf("invalid service client entry: %q", entry)
+		}
+
+		clients = append(clients, ServiceClient{
+			ClientID:      strings.TrimSpace(parts[0]),
+			SecretHash:    strings.ToLower(strings.TrimSpace(parts[1])),
+			AllowedScopes: strings.Fields(parts[2]),
+			Audiences:     strings.Fields(parts[3]),
+		})
+	}
+
+	return clients, nil
+}
+
+// Authenticate memverifikasi client ID dan secret dengan constant-time comparison
+func (r *ServiceRegistry) Authenticate(clientID, clientSecret string) (*ServiceClient, error) {
+	client, ok := r.clients[clientID]
+	if !ok {
+		// Tetap hitung hash supaya waktu respon sama dengan client yang terdaftar
+		CompareTokenHash(clientSecret, "")
+		return nil, ErrInvalidClient
+	}
+
+	if !CompareTokenHash(clientSecret, client.SecretHash) {
+		return nil, ErrInvalidClient
+	}
+
+	return &client, nil
+}
+
+// GrantScopes mengembalikan scope yang diminta jika semuanya diizinkan.
+// Jika tidak ada scope yang diminta, semua scope yang diizinkan diberikan.
+func (sc *ServiceClient) GrantScopes(requested []string) ([]string, error) {
+	if len(requested) == 0 {
+		return sc.AllowedScopes, nil
+	}
+
+	for _, scope := range requested {
+		if !containsString(sc.AllowedScopes, scope) {
+			return nil, fmt.Errorf("scope not allowed: %s", scope)
+		}
+	}
+	return requested, nil
+}
+
+// GrantAudience memvalidasi audience yang diminta
+func (sc *ServiceClient) GrantAudience(requested []string) ([]string, error) {
+	if len(requested) == 0 {
+		return sc.Audiences, nil
+	}
+
+	for _, audience := range requested {
+		if !containsString(sc.Audiences, audience) {
+			return nil, fmt.Errorf("audience not allowed: %s", audience)
+		}
+	}
+	return requested, nil
+}
+
+// ServiceTokenResponse response token sesuai OAuth 2.0 (RFC 6749 section 5.1)
+type ServiceTokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"`
+	Scope       string `json:"scope,omitempty"`
+}
+
+// ServiceTokenHandler handler untuk client credentials grant.
+// Response mengikuti format OAuth 2.0 supaya bisa dipakai client OAuth standar.
+func ServiceTokenHandler(registry *ServiceRegistry, jwtManager *JWTManager, expiration time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.PostForm("grant_type") != "client_credentials" {
+			oauthError(c, http.StatusBadRequest, "unsupported_grant_type", "Only client_credentials grant is supported")
+			return
+		}
+
+		// Client credentials bisa lewat HTTP Basic atau form body
+		clientID, clientSecret, ok := c.Request.BasicAuth()
+		if !ok {
+			clientID = c.PostForm("client_id")
+			clientSecret = c.PostForm("client_secret")
+		}
+
+		client, err := registry.Authenticate(clientID, clientSecret)
+		if err != nil {
+			c.Header("WWW-Authenticate", `Basic realm="service"`)
+			oauthError(c, http.StatusUnauthorized, "invalid_client", "Client authentication failed")
+			return
+		}
+
+		scopes, err := client.GrantScopes(strings.Fields(c.PostForm("scope")))
+		if err != nil {
+			oauthError(c, http.StatusBadRequest, "invalid_scope", err.Error())
+			return
+		}
+
+		audience, err := client.GrantAudience(strings.Fields(c.PostForm("audience")))
+		if err != nil {
+			oauthError(c, http.StatusBadRequest, "invalid_target", err.Error())
+			return
+		}
+
+		token, err := jwtManager.GenerateServiceToken(client.ClientID, audience, scopes, expiration)
+		if err != nil {
+			oauthError(c, http.StatusInternalServerError, "server_error", "Failed to issue token")
+			return
+		}
+
+		c.Header("Cache-Control", "no-store")
+		c.JSON(http.StatusOK, ServiceTokenResponse{
+			AccessToken: token,
+			TokenType:   "Bearer",
+			ExpiresIn:   int(expiration.Seconds()),
+			Scope:       strings.Join(scopes, " "),
+		})
+	}
+}
+
+// Helper function untuk error response OAuth 2.0
+func oauthError(c *gin.Context, statusCode int, errorCode, description string) {
+	c.JSON(statusCode, gin.H{
+		"error":             errorCode,
+		"error_description": description,
+	})
+}
+
+// Helper function untuk mengecek string di slice
+func containsString(values []string, target string) bool {
+	for _, value := range values {
+		if value == target {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,154 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ServiceTokenSource mengambil token client credentials dan menyimpannya di cache
+// sampai mendekati waktu expired.
+type ServiceTokenSource struct {
+	tokenURL      string
+	clientID      string
+	clientSecret  string
+	scopes        []string
+	audience      []string
+	refreshBefore time.Duration
+	httpClient    *http.Client
+
+	mu        sync.Mutex
+	token     string
+	expiresAt time.Time
+}
+
+// NewServiceTokenSource membuat instance baru ServiceTokenSource
+func NewServiceTokenSource(tokenURL, clientID, clientSecret string, scopes, audience []string) *ServiceTokenSource {
+	return &ServiceTokenSource{
+		tokenURL:      tokenURL,
+		clientID:      clientID,
+		clientSecret:  clientSecret,
+		scopes:        scopes,
+		audience:      audience,
+		refreshBefore: 30 * time.Second,
+		httpClient:    &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Token mengembalikan token dari cache, atau meminta token baru jika hampir expired
+func (s *ServiceTokenSource) Token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token != "" && time.Now().Add(s.refreshBefore).Before(s.expiresAt) {
+		return s.token, nil
+	}
+
+	token, expiresIn, err := s.fetchToken(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	s.token = token
+	s.expiresAt = time.Now().Add(expiresIn)
+	return s.token, nil
+}
+
+// Invalidate menghapus token dari cache, misal setelah service tujuan membalas 401
+func (s *ServiceTokenSource) Invalidate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.token = ""
+	s.expiresAt = time.Time{}
+}
+
+// Helper function untuk meminta token ke token endpoint
+func (s *ServiceTokenSource) fetchToken(ctx context.Context) (string, time.Duration, error) {
+	form := url.Values{}
+	form.Set("grant_type", "client_credentials")
+	if len(s.scopes) > 0 {
+		form.Set("scope", strings.Join(s.scopes, " "))
+	}
+	if len(s.audience) > 0 {
+		form.Set("audience", strings.Join(s.audience, " "))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to create token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(s.clientID, s.clientSecret)
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to request service token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to read token response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", 0, fmt.Errorf("token endpoint returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var tokenResp ServiceTokenResponse
+	if err := json.Unmarshal(body, &tokenResp); err != nil {
+		return "", 0, fmt.Errorf("failed to decode token response: %w", err)
+	}
+	if tokenResp.AccessToken == "" {
+		return "", 0, fmt.Errorf("token endpoint returned empty access token")
+	}
+
+	return tokenResp.AccessToken, time.Duration(tokenResp.ExpiresIn) * time.Second, nil
+}
+
+// serviceTokenTransport RoundTripper yang menambahkan Bearer token ke setiap request
+type serviceTokenTransport struct {
+	source *ServiceTokenSource
+	base   http.RoundTripper
+}
+
+// RoundTrip menambahkan header Authorization. Jika service tujuan membalas 401,
+// token di cache dibuang supaya request berikutnya memakai token baru.
+func (t *serviceTokenTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := t.source.Token(req.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	// RoundTripper tidak boleh mengubah request asli
+	authReq := req.Clone(req.Context())
+	authReq.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := t.base.RoundTrip(authReq)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		t.source.Invalidate()
+	}
+	return resp, nil
+}
+
+// NewServiceHTTPClient membuat http.Client yang otomatis mengirim token service
+func NewServiceHTTPClient(source *ServiceTokenSource, timeout time.Duration) *http.Client {
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &serviceTokenTransport{
+			source: source,
+			base:   http.DefaultTransport,
+		},
+	}
+}
@@ -0,0 +1,290 @@
+package utils
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jizak1/Microservices-Golang/shared/database"
+)
+
+// ErrSessionNotFound dikembalikan ketika session tidak ada atau sudah expired
+var ErrSessionNotFound = errors.New("session not found")
+
+// Session data session server-side yang disimpan di Redis
+type Session struct {
+	ID         string            `json:"id"`
+	UserID     string            `json:"user_id"`
+	Username   string            `json:"username"`
+	Email      string            `json:"email"`
+	Roles      []string          `json:"roles"`
+	AMR        []string          `json:"amr,omitempty"` // Metode login, sama seperti claim amr di JWT
+	CSRFToken  string            `json:"csrf_token"`
+	UserAgent  string            `json:"user_agent"`
+	IPAddress  string            `json:"ip_address"`
+	Data       map[string]string `json:"data,omitempty"`
+	CreatedAt  time.Time         `json:"created_at"`
+	LastSeenAt time.Time         `json:"last_seen_at"`
+	ExpiresAt  time.Time         `json:"expires_at"` // Batas absolut, tidak ikut diperpanjang
+}
+
+// Claims mengubah session menjadi claims dengan bentuk yang sama seperti claims dari JWT,
+// role pertama juga ditulis ke claim role
+func (s *Session) Claims() *JWTClaims {
+	role := ""
+	if len(s.Roles) > 0 {
+		role = s.Roles[0]
+	}
+	return &JWTClaims{
+		UserID:   s.UserID,
+		Username: s.Username,
+		Email:    s.Email,
+		Role:     role,
+		Roles:    s.Roles,
+		AMR:      s.AMR,
+	}
+}
+
+// SessionStore untuk mengelola session di Redis dengan sliding expiration
+type SessionStore struct {
+	redis           *database.RedisClient
+	idleTimeout     time.Duration
+	absoluteTimeout time.Duration
+	keyPrefix       string
+}
+
+// NewSessionStore membuat instance baru SessionStore
+func NewSessionStore(redis *database.RedisClient, idleTimeout, absoluteTimeout time.Duration) *SessionStore {
+	return &SessionStore{
+		redis:           redis,
+		idleTimeout:     idleTimeout,
+		absoluteTimeout: absoluteTimeout,
+		keyPrefix:       "session:",
+	}
+}
+
+// Create membuat session baru untuk user
+func (s *SessionStore) Create(userID, username, email string, roles, amr []string, userAgent, ipAddress string) (*Session, error) {
+	sessionID, err := GenerateSecureToken(32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate session ID: %w", err)
+	}
+
+	csrfToken, err := GenerateSecureToken(32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate CSRF token: %w", err)
+	}
+
+	now := time.Now().UTC()
+	session := &Session{
+		ID:         sessionID,
+		UserID:     userID,
+		Username:   username,
+		Email:      email,
+		Roles:      roles,
+		AMR:        amr,
+		CSRFToken:  csrfToken,
+		UserAgent:  userAgent,
+		IPAddress:  ipAddress,
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(s.absoluteTimeout),
+	}
+
+	ttl, err := s.ttl(session)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.redis.SetWithExpiration(s.sessionKey(session.ID), session, ttl); err != nil {
+		return nil, fmt.Errorf("failed to save session: %w", err)
+	}
+
+	// Simpan ke index per user supaya semua session bisa di-list dan di-revoke
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	pipe := s.redis.Client.TxPipeline()
+	pipe.SAdd(ctx, s.userIndexKey(userID), sessionID)
+	pipe.Expire(ctx, s.userIndexKey(userID), s.absoluteTimeout)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, fmt.Errorf("failed to index session: %w", err)
+	}
+
+	return session, nil
+}
+
+// Get mengambil session berdasarkan ID
+func (s *SessionStore) Get(sessionID string) (*Session, error) {
+	if sessionID == "" {
+		return nil, ErrSessionNotFound
+	}
+
+	var session Session
+	if err := s.redis.GetAndUnmarshal(s.sessionKey(sessionID), &session); err != nil {
+		if errors.Is(err, database.ErrKeyNotFound) {
+			return nil, ErrSessionNotFound
+		}
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	if time.Now().After(session.ExpiresAt) {
+		_ = s.Delete(sessionID)
+		return nil, ErrSessionNotFound
+	}
+
+	return &session, nil
+}
+
+// Touch memperpanjang session (sliding expiration) tanpa melewati batas absolut
+func (s *SessionStore) Touch(session *Session) error {
+	session.LastSeenAt = time.Now().UTC()
+	return s.save(session)
+}
+
+// Save menyimpan perubahan data session
+func (s *SessionStore) Save(session *Session) error {
+	return s.save(session)
+}
+
+// RotateCSRFToken membuat CSRF token baru untuk session
+func (s *SessionStore) RotateCSRFToken(session *Session) error {
+	csrfToken, err := GenerateSecureToken(32)
+	if err != nil {
+		return fmt.Errorf("failed to generate CSRF token: %w", err)
+	}
+
+	session.CSRFToken = csrfToken
+	return s.save(session)
+}
+
+// VerifyCSRFToken membandingkan CSRF token dengan constant-time comparison
+func (s *SessionStore) VerifyCSRFToken(session *Session, token string) bool {
+	if session == nil || token == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(session.CSRFToken), []byte(token)) == 1
+}
+
+// Delete menghapus satu session
+func (s *SessionStore) Delete(sessionID string) error {
+	var session Session
+	err := s.redis.GetAndUnmarshal(s.sessionKey(sessionID), &session)
+	if err != nil && !errors.Is(err, database.ErrKeyNotFound) {
+		return fmt.Errorf("failed to get session: %w", err)
+	}
+
+	if err := s.redis.Delete(s.sessionKey(sessionID)); err != nil {
+		return err
+	}
+
+	if session.UserID != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		if err := s.redis.Client.SRem(ctx, s.userIndexKey(session.UserID), sessionID).Err(); err != nil {
+			return fmt.Errorf("failed to remove session from index: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// ListUserSessions mengambil semua session aktif milik user
+func (s *SessionStore) ListUserSessions(userID string) ([]Session, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	sessionIDs, err := s.redis.Client.SMembers(ctx, s.userIndexKey(userID)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+
+	sessions := make([]Session, 0, len(sessionIDs))
+	staleIDs := []interface{}{}
+
+	for _, sessionID := range sessionIDs {
+		session, err := s.Get(sessionID)
+		if err != nil {
+			if errors.Is(err, ErrSessionNotFound) {
+				staleIDs = append(staleIDs, sessionID)
+				continue
+			}
+			return nil, err
+		}
+		sessions = append(sessions, *session)
+	}
+
+	// Bersihkan ID session yang sudah expired dari index
+	if len(staleIDs) > 0 {
+		s.redis.Client.SRem(ctx, s.userIndexKey(userID), staleIDs...)
+	}
+
+	return sessions, nil
+}
+
+// RevokeUserSessions menghapus semua session milik user
+func (s *SessionStore) RevokeUserSessions(userID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	sessionIDs, err := s.redis.Client.SMembers(ctx, s.userIndexKey(userID)).Result()
+	if err != nil {
+		return fmt.Errorf("failed to list sessions: %w", err)
+	}
+
+	keys := []string{s.userIndexKey(userID)}
+	for _, sessionID := range sessionIDs {
+		keys = append(keys, s.sessionKey(sessionID))
+	}
+
+	if err := s.redis.Client.Del(ctx, keys...).Err(); err != nil {
+		return fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+
+	return nil
+}
+
+// IdleTimeout mengembalikan durasi sliding expiration
+func (s *SessionStore) IdleTimeout() time.Duration {
+	return s.idleTimeout
+}
+
+// Helper function untuk menyimpan perubahan session yang sudah ada dengan TTL yang tepat.
+// Memakai SET XX supaya session yang di-revoke setelah Get tidak ditulis ulang oleh Touch atau Save.
+func (s *SessionStore) save(session *Session) error {
+	ttl, err := s.ttl(session)
+	if err != nil {
+		return err
+	}
+
+	updated, err := s.redis.UpdateWithExpiration(s.sessionKey(session.ID), session, ttl)
+	if err != nil {
+		return fmt.Errorf("failed to save session: %w", err)
+	}
+	if !updated {
+		return ErrSessionNotFound
+	}
+	return nil
+}
+
+// Helper function untuk menghitung TTL session: idle timeout, tapi tidak melewati batas absolut
+func (s *SessionStore) ttl(session *Session) (time.Duration, error) {
+	ttl := s.idleTimeout
+	if remaining := time.Until(session.ExpiresAt); remaining < ttl {
+		ttl = remaining
+	}
+	if ttl <= 0 {
+		return 0, ErrSessionNotFound
+	}
+	return ttl, nil
+}
+
+func (s *SessionStore) sessionKey(sessionID string) string {
+	return s.keyPrefix + sessionID
+}
+
+func (s *SessionStore) userIndexKey(userID string) string {
+	return s.keyPrefix + "user:" + userID
+}
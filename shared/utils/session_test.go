@@ -0,0 +1,102 @@
+package utils
+
+import (
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/jizak1/Microservices-Golang/shared/database"
+	"github.com/sirupsen/logrus"
+)
+
+// Helper function untuk membuat SessionStore di atas miniredis
+func newTestSessionStore(t *testing.T) (*SessionStore, *miniredis.Miniredis) {
+	t.Helper()
+
+	server := miniredis.RunT(t)
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	client, err := database.NewRedisConnection(database.RedisConfig{Host: server.Host(), Port: server.Port()}, logger)
+	if err != nil {
+		t.Fatalf("NewRedisConnection() error = %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+
+	return NewSessionStore(client, 30*time.Minute, 24*time.Hour), server
+}
+
+func TestSessionStoreTouchExtendsIdleTimeout(t *testing.T) {
+	store, server := newTestSessionStore(t)
+
+	session, err := store.Create("1", "john", "john@example.com", []string{"user"}, nil, "test", "127.0.0.1")
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	server.FastForward(20 * time.Minute)
+	if err := store.Touch(session); err != nil {
+		t.Fatalf("Touch() error = %v", err)
+	}
+	if ttl := server.TTL(store.sessionKey(session.ID)); ttl != 30*time.Minute {
+		t.Errorf("TTL after Touch() = %v, want %v", ttl, 30*time.Minute)
+	}
+}
+
+func TestSessionStoreDoesNotRecreateRevokedSession(t *testing.T) {
+	tests := []struct {
+		name   string
+		revoke func(store *SessionStore, session *Session) error
+		write  func(store *SessionStore, session *Session) error
+	}{
+		{
+			name:   "Touch after Delete",
+			revoke: func(store *SessionStore, session *Session) error { return store.Delete(session.ID) },
+			write:  func(store *SessionStore, session *Session) error { return store.Touch(session) },
+		},
+		{
+			name:   "Touch after RevokeUserSessions",
+			revoke: func(store *SessionStore, session *Session) error { return store.RevokeUserSessions(session.UserID) },
+			write:  func(store *SessionStore, session *Session) error { return store.Touch(session) },
+		},
+		{
+			name:   "Save after Delete",
+			revoke: func(store *SessionStore, session *Session) error { return store.Delete(session.ID) },
+			write:  func(store *SessionStore, session *Session) error { return store.Save(session) },
+		},
+		{
+			name:   "RotateCSRFToken after RevokeUserSessions",
+			revoke: func(store *SessionStore, session *Session) error { return store.RevokeUserSessions(session.UserID) },
+			write:  func(store *SessionStore, session *Session) error { return store.RotateCSRFToken(session) },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, server := newTestSessionStore(t)
+
+			created, err := store.Create("1", "john", "john@example.com", []string{"user"}, nil, "test", "127.0.0.1")
+			if err != nil {
+				t.Fatalf("Create() error = %v", err)
+			}
+
+			// Session dibaca middleware, lalu di-revoke sebelum sliding expiration ditulis
+			session, err := store.Get(created.ID)
+			if err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+			if err := tt.revoke(store, session); err != nil {
+				t.Fatalf("revoke error = %v", err)
+			}
+
+			if err := tt.write(store, session); !errors.Is(err, ErrSessionNotFound) {
+				t.Errorf("write error = %v, want %v", err, ErrSessionNotFound)
+			}
+			if server.Exists(store.sessionKey(session.ID)) {
+				t.Error("revoked session was written back to Redis")
+			}
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+)
+
+// GenerateSecureToken membuat random token yang aman (URL-safe base64)
+func GenerateSecureToken(numBytes int) (string, error) {
+	buffer := make([]byte, numBytes)
+	if _, err := rand.Read(buffer); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buffer), nil
+}
+
+// HashToken menghitung SHA-256 hex dari token opaque sebelum disimpan ke database.
+// Token opaque sudah high-entropy, jadi tidak perlu hash lambat seperti bcrypt.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// CompareTokenHash membandingkan token dengan hash-nya secara constant-time
+func CompareTokenHash(token, hash string) bool {
+	return subtle.ConstantTimeCompare([]byte(HashToken(token)), []byte(hash)) == 1
+}
@@ -0,0 +1,186 @@
+package utils
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Secret dari RFC 6238 appendix B ("12345678901234567890" ASCII) dalam base32
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestGenerateTOTPCodeRFC6238(t *testing.T) {
+	// Vector SHA1 dari RFC 6238 appendix B, diambil 6 digit terakhir karena TOTPDigits = 6
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{unix: 59, want: "287082"},
+		{unix: 1111111109, want: "081804"},
+		{unix: 1111111111, want: "050471"},
+		{unix: 1234567890, want: "005924"},
+		{unix: 2000000000, want: "279037"},
+		{unix: 20000000000, want: "353130"},
+	}
+
+	for _, tt := range tests {
+		t.Run(time.Unix(tt.unix, 0).UTC().Format(time.RFC3339), func(t *testing.T) {
+			got, err := GenerateTOTPCode(rfc6238Secret, TOTPStep(time.Unix(tt.unix, 0)))
+			if err != nil {
+				t.Fatalf("GenerateTOTPCode() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("GenerateTOTPCode() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenerateTOTPCodeSecretFormat(t *testing.T) {
+	step := TOTPStep(time.Unix(59, 0))
+
+	tests := []struct {
+		name    string
+		secret  string
+		wantErr bool
+	}{
+		{name: "uppercase", secret: rfc6238Secret},
+		{name: "lowercase", secret: strings.ToLower(rfc6238Secret)},
+		{name: "with padding", secret: "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ======"},
+		{name: "invalid base32", secret: "NOT-BASE32!", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := GenerateTOTPCode(tt.secret, step)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GenerateTOTPCode() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != "287082" {
+				t.Errorf("GenerateTOTPCode() = %q, want %q", got, "287082")
+			}
+		})
+	}
+}
+
+func TestTOTPStep(t *testing.T) {
+	tests := []struct {
+		unix int64
+		want int64
+	}{
+		{unix: 0, want: 0},
+		{unix: 29, want: 0},
+		{unix: 30, want: 1},
+		{unix: 59, want: 1},
+		{unix: 1111111109, want: 37037036},
+	}
+
+	for _, tt := range tests {
+		if got := TOTPStep(time.Unix(tt.unix, 0)); got != tt.want {
+			t.Errorf("TOTPStep(%d) = %d, want %d", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestValidateTOTPCodeSkew(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	current := TOTPStep(now)
+
+	codeAt := func(offset int64) string {
+		code, err := GenerateTOTPCode(rfc6238Secret, current+offset)
+		if err != nil {
+			t.Fatalf("GenerateTOTPCode() error = %v", err)
+		}
+		return code
+	}
+
+	tests := []struct {
+		name     string
+		code     string
+		skew     int
+		want     bool
+		wantStep int64
+	}{
+		{name: "current step", code: codeAt(0), skew: 1, want: true, wantStep: current},
+		{name: "current step without skew", code: codeAt(0), skew: 0, want: true, wantStep: current},
+		{name: "previous step within skew", code: codeAt(-1), skew: 1, want: true, wantStep: current - 1},
+		{name: "next step within skew", code: codeAt(1), skew: 1, want: true, wantStep: current + 1},
+		{name: "previous step without skew", code: codeAt(-1), skew: 0, want: false},
+		{name: "two steps behind", code: codeAt(-2), skew: 1, want: false},
+		{name: "two steps ahead", code: codeAt(2), skew: 1, want: false},
+		{name: "two steps behind with larger skew", code: codeAt(-2), skew: 2, want: true, wantStep: current - 2},
+		{name: "surrounding whitespace", code: " " + codeAt(0) + "\n", skew: 1, want: true, wantStep: current},
+		{name: "too short", code: codeAt(0)[:5], skew: 1, want: false},
+		{name: "too long", code: codeAt(0) + "0", skew: 1, want: false},
+		{name: "empty", code: "", skew: 1, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, step, err := ValidateTOTPCode(rfc6238Secret, tt.code, now, tt.skew)
+			if err != nil {
+				t.Fatalf("ValidateTOTPCode() error = %v", err)
+			}
+			if ok != tt.want {
+				t.Fatalf("ValidateTOTPCode() = %v, want %v", ok, tt.want)
+			}
+			if ok && step != tt.wantStep {
+				t.Errorf("ValidateTOTPCode() step = %d, want %d", step, tt.wantStep)
+			}
+		})
+	}
+}
+
+func TestValidateTOTPCodeInvalidSecret(t *testing.T) {
+	if _, _, err := ValidateTOTPCode("NOT-BASE32!", "123456", time.Now(), 1); err == nil {
+		t.Error("ValidateTOTPCode() error = nil, want error for invalid secret")
+	}
+}
+
+func TestGenerateTOTPSecret(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatalf("GenerateTOTPSecret() error = %v", err)
+	}
+
+	// 160 bit = 32 karakter base32 tanpa padding
+	if len(secret) != 32 || strings.Contains(secret, "=") {
+		t.Errorf("GenerateTOTPSecret() = %q, want 32 base32 characters without padding", secret)
+	}
+	if _, err := GenerateTOTPCode(secret, 1); err != nil {
+		t.Errorf("generated secret is not usable: %v", err)
+	}
+}
+
+func TestTOTPURI(t *testing.T) {
+	uri := TOTPURI("Microservices Golang", "john@example.com", rfc6238Secret)
+
+	parsed, err := url.Parse(uri)
+	if err != nil {
+		t.Fatalf("TOTPURI() returned invalid URL %q: %v", uri, err)
+	}
+	if parsed.Scheme != "otpauth" || parsed.Host != "totp" {
+		t.Errorf("TOTPURI() = %q, want otpauth://totp/...", uri)
+	}
+	if parsed.Path != "/Microservices Golang:john@example.com" {
+		t.Errorf("TOTPURI() label = %q", parsed.Path)
+	}
+	if strings.Contains(uri, "+") {
+		t.Errorf("TOTPURI() = %q, spaces must be encoded as %%20", uri)
+	}
+
+	query := parsed.Query()
+	want := map[string]string{
+		"secret":    rfc6238Secret,
+		"issuer":    "Microservices Golang",
+		"algorithm": "SHA1",
+		"digits":    "6",
+		"period":    "30",
+	}
+	for name, value := range want {
+		if got := query.Get(name); got != value {
+			t.Errorf("TOTPURI() %s = %q, want %q", name, got, value)
+		}
+	}
+}